@@ -70,7 +70,12 @@ func main() {
 		storeEventRepo.Close()
 	}()
 
-	wsCtrl := iot.NewWsCtrl(store, redisClient, redisSync, edgeMapService, storeEventRepo)
+	// iot 重啟前未正常結束的 session 不會再收到 edge 斷線通知，以最後回報時間結束
+	if err := store.EndStaleStoreDeviceSessions(context.Background(), time.Now().Add(-config.StoreDeviceSessionTimeout).UnixMilli()); err != nil {
+		logutil.GetLogger().Fatalf("end stale store device sessions error, err=%s", err)
+	}
+
+	wsCtrl := iot.NewWsCtrl(store, redisClient, redisSync, edgeMapService, storeEventRepo, config.StoreDeviceSessionTimeout)
 
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.Default()
@@ -105,7 +110,7 @@ func main() {
 		resRepo.Close()
 	}()
 
-	rbmqCtrl := iot.NewRbmqCtrl(edgeMapService, resRepo)
+	rbmqCtrl := iot.NewRbmqCtrl(edgeMapService, resRepo, redisClient)
 
 	rbmqServer := &server.RbmqServer{
 		Url:      config.Rabbitmq.Url,
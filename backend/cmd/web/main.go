@@ -6,6 +6,7 @@ import (
 	"backend/token"
 	configutil "backend/util/config"
 	logutil "backend/util/log"
+	roleutil "backend/util/role"
 	"backend/web"
 	"context"
 	"database/sql"
@@ -42,6 +43,19 @@ func main() {
 
 	store := db.NewStore(conn)
 
+	// 將內建 role 的 scopes 同步到 db
+	for _, role := range roleutil.GetBuiltInRoles() {
+		arg := db.UpsertBuiltInRoleParams{
+			ID:              role.ID,
+			Name:            role.Name,
+			UserScopes:      role.UserScopes,
+			StoreUserScopes: role.StoreUserScopes,
+		}
+		if err := store.UpsertBuiltInRole(context.Background(), arg); err != nil {
+			logutil.GetLogger().Fatalf("upsert built-in role error, err=%s, arg=%#v", err, arg)
+		}
+	}
+
 	logutil.GetLogger().Infof("init redis client, url=%s", config.Redis.Url)
 	client := goredislib.NewClient(&goredislib.Options{
 		Addr: config.Redis.Url,
@@ -68,7 +82,7 @@ func main() {
 		logutil.GetLogger().Fatalf("new jwt maker error, err=%s", err)
 	}
 
-	server, err := web.New(config, store, rs, tokenMaker, iot)
+	server, err := web.New(config, store, rs, client, tokenMaker, iot)
 	if err != nil {
 		logutil.GetLogger().Fatalf("init http server error, err=%s", err)
 	}
@@ -0,0 +1,16 @@
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY,
+    store_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    key_hash TEXT UNIQUE NOT NULL,
+    scopes TEXT[] NOT NULL,
+    allowed_ips TEXT[] NOT NULL,
+    expired_at BIGINT,
+    is_revoked BOOLEAN DEFAULT false NOT NULL,
+    revoked_by UUID,
+    revoked_at BIGINT,
+    created_by UUID NOT NULL,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL
+);
+
+CREATE INDEX ON api_keys (store_id);
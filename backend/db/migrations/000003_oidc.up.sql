@@ -0,0 +1,22 @@
+CREATE TABLE oidc_auth_requests (
+    state TEXT PRIMARY KEY,
+    provider TEXT NOT NULL,
+    code_verifier TEXT NOT NULL,
+    nonce TEXT NOT NULL,
+    is_used BOOLEAN DEFAULT false NOT NULL,
+    subject TEXT,
+    link_token TEXT UNIQUE,
+    link_expired_at BIGINT,
+    is_linked BOOLEAN DEFAULT false NOT NULL,
+    expired_at BIGINT NOT NULL,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL
+);
+
+CREATE TABLE user_identities (
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id UUID NOT NULL,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL,
+    PRIMARY KEY (provider, subject),
+    UNIQUE (user_id, provider)
+);
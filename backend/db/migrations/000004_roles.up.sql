@@ -0,0 +1,37 @@
+CREATE TABLE roles (
+    id SMALLINT GENERATED BY DEFAULT AS IDENTITY (START WITH 100) PRIMARY KEY,
+    name TEXT NOT NULL,
+    store_id UUID,
+    user_scopes TEXT[] NOT NULL,
+    store_user_scopes TEXT[] NOT NULL,
+    is_built_in BOOLEAN DEFAULT false NOT NULL,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL
+);
+
+CREATE INDEX ON roles (store_id);
+
+CREATE TABLE roles_history (
+    changed_at BIGINT NOT NULL,
+    changed_type TEXT NOT NULL,
+    changed_by UUID,
+    changed_user_agent TEXT,
+    changed_client_ip TEXT,
+    role_id SMALLINT NOT NULL,
+    name TEXT NOT NULL,
+    store_id UUID,
+    user_scopes TEXT[] NOT NULL,
+    store_user_scopes TEXT[] NOT NULL,
+    is_built_in BOOLEAN NOT NULL,
+    created_at BIGINT NOT NULL,
+    history_created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL
+);
+
+-- 內建 role，scopes 會在 web 啟動時依 roleutil 同步
+INSERT INTO roles (id, name, user_scopes, store_user_scopes, is_built_in)
+VALUES
+    (1, 'admin', '{}', '{}', true),
+    (2, 'hq', '{}', '{}', true),
+    (3, 'member', '{}', '{}', true),
+    (4, 'owner', '{}', '{}', true),
+    (5, 'mgr', '{}', '{}', true),
+    (6, 'cust', '{}', '{}', true);
@@ -0,0 +1,15 @@
+CREATE TABLE store_join_codes (
+    id UUID PRIMARY KEY,
+    store_id UUID NOT NULL,
+    code TEXT UNIQUE NOT NULL,
+    max_uses INT,
+    used_count INT DEFAULT 0 NOT NULL,
+    expired_at BIGINT NOT NULL,
+    is_revoked BOOLEAN DEFAULT false NOT NULL,
+    revoked_by UUID,
+    revoked_at BIGINT,
+    created_by UUID NOT NULL,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL
+);
+
+CREATE INDEX ON store_join_codes (store_id);
@@ -0,0 +1,22 @@
+CREATE TABLE store_user_scope_overrides (
+    store_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    granted_scopes TEXT[] NOT NULL,
+    denied_scopes TEXT[] NOT NULL,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL,
+    PRIMARY KEY (store_id, user_id)
+);
+
+CREATE TABLE store_user_scope_overrides_history (
+    changed_at BIGINT NOT NULL,
+    changed_type TEXT NOT NULL,
+    changed_by UUID,
+    changed_user_agent TEXT,
+    changed_client_ip TEXT,
+    store_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    granted_scopes TEXT[] NOT NULL,
+    denied_scopes TEXT[] NOT NULL,
+    created_at BIGINT NOT NULL,
+    history_created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL
+);
@@ -0,0 +1,26 @@
+CREATE TABLE store_groups (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_by UUID NOT NULL,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL
+);
+
+CREATE TABLE store_group_stores (
+    store_group_id UUID NOT NULL,
+    store_id UUID NOT NULL,
+    created_by UUID NOT NULL,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL,
+    PRIMARY KEY (store_group_id, store_id)
+);
+
+CREATE INDEX ON store_group_stores (store_id);
+
+CREATE TABLE store_group_users (
+    store_group_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    created_by UUID NOT NULL,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL,
+    PRIMARY KEY (store_group_id, user_id)
+);
+
+CREATE INDEX ON store_group_users (user_id);
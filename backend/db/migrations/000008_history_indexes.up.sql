@@ -0,0 +1,15 @@
+CREATE INDEX ON users_history (user_id, changed_at);
+
+CREATE INDEX ON users_history (changed_by);
+
+CREATE INDEX ON stores_history (store_id, changed_at);
+
+CREATE INDEX ON stores_history (changed_by);
+
+CREATE INDEX ON store_users_history (store_id, user_id, changed_at);
+
+CREATE INDEX ON store_users_history (changed_by);
+
+CREATE INDEX ON store_devices_history (store_id, device_id, changed_at);
+
+CREATE INDEX ON store_devices_history (changed_by);
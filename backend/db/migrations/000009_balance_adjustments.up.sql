@@ -0,0 +1,16 @@
+CREATE TABLE balance_adjustments (
+    id UUID PRIMARY KEY,
+    store_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    amount INT NOT NULL,
+    point_amount INT NOT NULL,
+    reason_code TEXT NOT NULL,
+    note TEXT NOT NULL,
+    state TEXT NOT NULL,
+    requested_by UUID NOT NULL,
+    reviewed_by UUID,
+    reviewed_at BIGINT,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL
+);
+
+CREATE INDEX ON balance_adjustments (store_id, state);
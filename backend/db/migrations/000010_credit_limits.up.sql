@@ -0,0 +1,42 @@
+CREATE TABLE store_credit_limits (
+    store_id UUID PRIMARY KEY,
+    default_credit_limit INT NOT NULL,
+    updated_by UUID NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+CREATE TABLE store_credit_limits_history (
+    changed_at BIGINT NOT NULL,
+    changed_type TEXT NOT NULL,
+    changed_by UUID,
+    changed_user_agent TEXT,
+    changed_client_ip TEXT,
+    store_id UUID NOT NULL,
+    default_credit_limit INT NOT NULL,
+    updated_by UUID NOT NULL,
+    updated_at BIGINT NOT NULL,
+    history_created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL
+);
+
+CREATE TABLE store_user_credit_limits (
+    store_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    credit_limit INT,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL,
+    PRIMARY KEY (store_id, user_id)
+);
+
+CREATE TABLE store_user_credit_limits_history (
+    changed_at BIGINT NOT NULL,
+    changed_type TEXT NOT NULL,
+    changed_by UUID,
+    changed_user_agent TEXT,
+    changed_client_ip TEXT,
+    store_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    credit_limit INT,
+    created_at BIGINT NOT NULL,
+    history_created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL
+);
+
+CREATE INDEX ON store_users (store_id, balance);
@@ -0,0 +1,34 @@
+CREATE TABLE family_wallets (
+    id UUID PRIMARY KEY,
+    store_id UUID NOT NULL,
+    owner_user_id UUID NOT NULL,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL,
+    UNIQUE (store_id, owner_user_id)
+);
+
+CREATE TABLE family_wallet_members (
+    family_wallet_id UUID NOT NULL,
+    store_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    spending_cap INT,
+    created_by UUID NOT NULL,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL,
+    accepted_at BIGINT,
+    PRIMARY KEY (store_id, user_id)
+);
+
+CREATE INDEX ON family_wallet_members (family_wallet_id);
+
+CREATE TABLE family_wallet_spendings (
+    id UUID PRIMARY KEY,
+    family_wallet_id UUID NOT NULL,
+    store_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    device_id TEXT NOT NULL,
+    amount INT NOT NULL,
+    point_amount INT NOT NULL,
+    ts BIGINT NOT NULL,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL
+);
+
+CREATE INDEX ON family_wallet_spendings (family_wallet_id, user_id, ts);
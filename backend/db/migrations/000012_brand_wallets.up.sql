@@ -0,0 +1,34 @@
+CREATE TABLE brand_wallet_stores (
+    store_id UUID PRIMARY KEY,
+    store_group_id UUID NOT NULL,
+    created_by UUID NOT NULL,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL
+);
+
+CREATE TABLE brand_wallet_lots (
+    id UUID PRIMARY KEY,
+    store_group_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    store_id UUID NOT NULL,
+    amount INT NOT NULL,
+    remaining INT NOT NULL,
+    created_by UUID NOT NULL,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL
+);
+
+CREATE INDEX ON brand_wallet_lots (store_group_id, user_id, created_at) WHERE remaining > 0;
+
+CREATE TABLE brand_wallet_consumptions (
+    consumption_id UUID NOT NULL,
+    lot_id UUID NOT NULL,
+    store_group_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    store_id UUID NOT NULL,
+    funded_store_id UUID NOT NULL,
+    amount INT NOT NULL,
+    ts BIGINT NOT NULL,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL,
+    PRIMARY KEY (consumption_id, lot_id)
+);
+
+CREATE INDEX ON brand_wallet_consumptions (store_group_id, ts);
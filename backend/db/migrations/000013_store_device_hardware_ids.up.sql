@@ -0,0 +1,18 @@
+ALTER TABLE store_devices ADD COLUMN hardware_id TEXT;
+UPDATE store_devices SET hardware_id = device_id;
+ALTER TABLE store_devices ALTER COLUMN hardware_id SET NOT NULL;
+
+CREATE UNIQUE INDEX ON store_devices (store_id, hardware_id);
+
+ALTER TABLE store_devices_history ADD COLUMN hardware_id TEXT;
+UPDATE store_devices_history SET hardware_id = device_id;
+ALTER TABLE store_devices_history ALTER COLUMN hardware_id SET NOT NULL;
+
+CREATE TABLE store_device_retired_hardware_ids (
+    store_id UUID NOT NULL,
+    hardware_id TEXT NOT NULL,
+    device_id TEXT NOT NULL,
+    retired_by UUID NOT NULL,
+    retired_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL,
+    PRIMARY KEY (store_id, hardware_id)
+);
@@ -0,0 +1,11 @@
+CREATE INDEX ON store_devices (hardware_id);
+
+CREATE TABLE store_device_misplacements (
+    store_id UUID NOT NULL,
+    hardware_id TEXT NOT NULL,
+    registered_store_id UUID NOT NULL,
+    registered_device_id TEXT NOT NULL,
+    first_seen_at BIGINT NOT NULL,
+    last_seen_at BIGINT NOT NULL,
+    PRIMARY KEY (store_id, hardware_id)
+);
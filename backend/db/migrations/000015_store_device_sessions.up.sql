@@ -0,0 +1,10 @@
+CREATE TABLE store_device_sessions (
+    store_id UUID NOT NULL,
+    device_id TEXT NOT NULL,
+    started_at BIGINT NOT NULL,
+    ended_at BIGINT,
+    last_seen_at BIGINT NOT NULL,
+    PRIMARY KEY (store_id, device_id, started_at)
+);
+
+CREATE UNIQUE INDEX ON store_device_sessions (store_id, device_id) WHERE ended_at IS NULL;
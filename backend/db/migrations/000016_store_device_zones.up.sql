@@ -0,0 +1,19 @@
+CREATE TABLE store_device_zones (
+    id UUID PRIMARY KEY,
+    store_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    sort_order INT NOT NULL,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL
+);
+
+CREATE INDEX ON store_device_zones (store_id);
+
+ALTER TABLE store_devices ADD COLUMN zone_id UUID;
+ALTER TABLE store_devices ADD COLUMN sort_order INT DEFAULT 0 NOT NULL;
+ALTER TABLE store_devices ADD COLUMN machine_number TEXT;
+ALTER TABLE store_devices ADD COLUMN capacity_kg DOUBLE PRECISION;
+
+ALTER TABLE store_devices_history ADD COLUMN zone_id UUID;
+ALTER TABLE store_devices_history ADD COLUMN sort_order INT DEFAULT 0 NOT NULL;
+ALTER TABLE store_devices_history ADD COLUMN machine_number TEXT;
+ALTER TABLE store_devices_history ADD COLUMN capacity_kg DOUBLE PRECISION;
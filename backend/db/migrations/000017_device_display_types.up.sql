@@ -0,0 +1,31 @@
+CREATE TABLE device_display_types (
+    code TEXT PRIMARY KEY,
+    names JSONB NOT NULL,
+    icon_key TEXT NOT NULL,
+    default_programs JSONB NOT NULL,
+    consumes_points_over_time BOOLEAN DEFAULT false NOT NULL,
+    is_built_in BOOLEAN DEFAULT false NOT NULL,
+    created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL
+);
+
+CREATE TABLE device_display_types_history (
+    changed_at BIGINT NOT NULL,
+    changed_type TEXT NOT NULL,
+    changed_by UUID,
+    changed_user_agent TEXT,
+    changed_client_ip TEXT,
+    code TEXT NOT NULL,
+    names JSONB NOT NULL,
+    icon_key TEXT NOT NULL,
+    default_programs JSONB NOT NULL,
+    consumes_points_over_time BOOLEAN NOT NULL,
+    is_built_in BOOLEAN NOT NULL,
+    created_at BIGINT NOT NULL,
+    history_created_at BIGINT DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 NOT NULL
+);
+
+-- 內建的 display type，新註冊的機台預設為 washer，不可刪除
+INSERT INTO device_display_types (code, names, icon_key, default_programs, consumes_points_over_time, is_built_in)
+VALUES
+    ('washer', '{"zh-TW": "洗衣機", "en": "Washer"}', 'washer', '[]', false, true),
+    ('dryer', '{"zh-TW": "烘衣機", "en": "Dryer"}', 'dryer', '[]', true, true);
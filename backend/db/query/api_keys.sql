@@ -0,0 +1,22 @@
+-- name: CreateApiKey :one
+INSERT INTO api_keys (id, store_id, name, key_hash, scopes, allowed_ips, expired_at, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetApiKeyByKeyHash :one
+SELECT * FROM api_keys
+WHERE key_hash = $1;
+
+-- name: GetApiKey :one
+SELECT * FROM api_keys
+WHERE store_id = $1 AND id = $2;
+
+-- name: GetStoreApiKeys :many
+SELECT * FROM api_keys
+WHERE store_id = $1
+ORDER BY created_at DESC;
+
+-- name: RevokeApiKey :exec
+UPDATE api_keys
+SET is_revoked = true, revoked_by = $3, revoked_at = $4
+WHERE store_id = $1 AND id = $2;
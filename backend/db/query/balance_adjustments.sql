@@ -0,0 +1,18 @@
+-- name: CreateBalanceAdjustment :one
+INSERT INTO balance_adjustments (id, store_id, user_id, amount, point_amount, reason_code, note, state, requested_by, reviewed_by, reviewed_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING *;
+
+-- name: GetBalanceAdjustment :one
+SELECT * FROM balance_adjustments
+WHERE id = $1;
+
+-- name: GetStoreBalanceAdjustments :many
+SELECT * FROM balance_adjustments
+WHERE store_id = $1 AND (sqlc.narg(state)::TEXT IS NULL OR state = sqlc.narg(state))
+ORDER BY created_at DESC;
+
+-- name: ReviewBalanceAdjustment :execrows
+UPDATE balance_adjustments
+SET state = $2, reviewed_by = $3, reviewed_at = $4
+WHERE id = $1 AND state = sqlc.arg(from_state);
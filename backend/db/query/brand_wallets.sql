@@ -0,0 +1,58 @@
+-- name: JoinBrandWallet :one
+INSERT INTO brand_wallet_stores (store_id, store_group_id, created_by)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: LeaveBrandWallet :execrows
+DELETE FROM brand_wallet_stores
+WHERE store_id = $1;
+
+-- name: GetBrandWalletStore :one
+SELECT * FROM brand_wallet_stores
+WHERE store_id = $1;
+
+-- name: GetBrandWalletStoreIDs :many
+SELECT store_id FROM brand_wallet_stores
+WHERE store_group_id = $1
+ORDER BY created_at;
+
+-- name: GetBrandWalletBalance :one
+SELECT COALESCE(SUM(remaining), 0)::BIGINT AS balance
+FROM brand_wallet_lots
+WHERE store_group_id = $1 AND user_id = $2 AND remaining > 0;
+
+-- name: CreateBrandWalletLot :one
+INSERT INTO brand_wallet_lots (id, store_group_id, user_id, store_id, amount, remaining, created_by)
+VALUES ($1, $2, $3, $4, $5, $5, $6)
+RETURNING *;
+
+-- name: GetBrandWalletLotsForUpdate :many
+SELECT * FROM brand_wallet_lots
+WHERE store_group_id = $1 AND user_id = $2 AND remaining > 0
+ORDER BY created_at, id
+FOR UPDATE;
+
+-- name: AddBrandWalletLotRemaining :exec
+UPDATE brand_wallet_lots
+SET remaining = remaining + $2
+WHERE id = $1;
+
+-- name: CreateBrandWalletConsumption :one
+INSERT INTO brand_wallet_consumptions (consumption_id, lot_id, store_group_id, user_id, store_id, funded_store_id, amount, ts)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetBrandWalletConsumptions :many
+SELECT * FROM brand_wallet_consumptions
+WHERE consumption_id = $1;
+
+-- name: DeleteBrandWalletConsumptions :exec
+DELETE FROM brand_wallet_consumptions
+WHERE consumption_id = $1;
+
+-- name: GetBrandWalletSettlement :many
+SELECT store_id, funded_store_id, SUM(amount)::BIGINT AS amount
+FROM brand_wallet_consumptions
+WHERE store_group_id = $1 AND ts >= sqlc.arg(from_ts)::BIGINT AND ts < sqlc.arg(to_ts)::BIGINT
+GROUP BY store_id, funded_store_id
+ORDER BY store_id, funded_store_id;
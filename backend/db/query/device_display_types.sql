@@ -0,0 +1,22 @@
+-- name: CreateDeviceDisplayType :one
+INSERT INTO device_display_types (code, names, icon_key, default_programs, consumes_points_over_time)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: GetDeviceDisplayType :one
+SELECT * FROM device_display_types
+WHERE code = $1;
+
+-- name: GetDeviceDisplayTypes :many
+SELECT * FROM device_display_types
+ORDER BY created_at, code;
+
+-- name: SetDeviceDisplayTypeInfo :exec
+UPDATE device_display_types
+SET names = $2, icon_key = $3, default_programs = $4, consumes_points_over_time = $5
+WHERE code = $1;
+
+-- name: DeleteDeviceDisplayType :exec
+DELETE FROM device_display_types
+WHERE code = $1 AND is_built_in = false;
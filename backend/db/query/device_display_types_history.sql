@@ -0,0 +1,6 @@
+-- name: CreateDeviceDisplayTypeHistory :one
+INSERT INTO device_display_types_history (changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, code, names, icon_key, default_programs, consumes_points_over_time, is_built_in, created_at)
+SELECT $2, $3, $4, $5, $6, code, names, icon_key, default_programs, consumes_points_over_time, is_built_in, created_at
+FROM device_display_types AS d
+WHERE d.code = $1
+RETURNING *;
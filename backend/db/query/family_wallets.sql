@@ -0,0 +1,65 @@
+-- name: CreateFamilyWallet :one
+INSERT INTO family_wallets (id, store_id, owner_user_id)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetFamilyWalletByOwner :one
+SELECT * FROM family_wallets
+WHERE store_id = $1 AND owner_user_id = $2;
+
+-- name: GetFamilyWallet :one
+SELECT * FROM family_wallets
+WHERE id = $1;
+
+-- name: AddFamilyWalletMember :one
+INSERT INTO family_wallet_members (family_wallet_id, store_id, user_id, spending_cap, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetFamilyWalletMember :one
+SELECT * FROM family_wallet_members
+WHERE store_id = $1 AND user_id = $2;
+
+-- name: GetFamilyWalletMembers :many
+SELECT fwm.user_id, u.phone_number, u.name, fwm.spending_cap, fwm.created_by, fwm.created_at, fwm.accepted_at
+FROM family_wallet_members fwm INNER JOIN users u ON fwm.user_id = u.id
+WHERE fwm.family_wallet_id = $1
+ORDER BY fwm.created_at;
+
+-- name: AcceptFamilyWalletMember :execrows
+UPDATE family_wallet_members
+SET accepted_at = $3
+WHERE store_id = $1 AND user_id = $2 AND accepted_at IS NULL;
+
+-- name: CountFamilyWalletMembers :one
+SELECT COUNT(*) FROM family_wallet_members
+WHERE family_wallet_id = $1;
+
+-- name: SetFamilyWalletMemberSpendingCap :execrows
+UPDATE family_wallet_members
+SET spending_cap = $3
+WHERE family_wallet_id = $1 AND user_id = $2;
+
+-- name: RemoveFamilyWalletMember :execrows
+DELETE FROM family_wallet_members
+WHERE family_wallet_id = $1 AND user_id = $2;
+
+-- name: CreateFamilyWalletSpending :one
+INSERT INTO family_wallet_spendings (id, family_wallet_id, store_id, user_id, device_id, amount, point_amount, ts)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: DeleteFamilyWalletSpending :exec
+DELETE FROM family_wallet_spendings
+WHERE id = $1;
+
+-- name: SumFamilyWalletMemberSpendings :one
+SELECT COALESCE(SUM(amount + point_amount), 0)::BIGINT AS amount
+FROM family_wallet_spendings
+WHERE family_wallet_id = $1 AND user_id = $2 AND ts >= $3;
+
+-- name: GetFamilyWalletSpendings :many
+SELECT fws.user_id, u.name AS user_name, fws.device_id, fws.amount, fws.point_amount, fws.ts
+FROM family_wallet_spendings fws LEFT JOIN users u ON fws.user_id = u.id
+WHERE fws.family_wallet_id = $1 AND fws.ts >= sqlc.arg(from_ts)::BIGINT AND fws.ts < sqlc.arg(to_ts)::BIGINT
+ORDER BY fws.ts DESC;
@@ -0,0 +1,22 @@
+-- name: CreateOidcAuthRequest :one
+INSERT INTO oidc_auth_requests (state, provider, code_verifier, nonce, expired_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetOidcAuthRequest :one
+SELECT * FROM oidc_auth_requests
+WHERE state = $1;
+
+-- name: GetOidcAuthRequestByLinkToken :one
+SELECT * FROM oidc_auth_requests
+WHERE link_token = $1;
+
+-- name: SetOidcAuthRequestVerified :exec
+UPDATE oidc_auth_requests
+SET is_used = true, subject = $2, link_token = $3, link_expired_at = $4
+WHERE state = $1;
+
+-- name: SetOidcAuthRequestLinked :exec
+UPDATE oidc_auth_requests
+SET is_linked = true
+WHERE state = $1;
@@ -33,4 +33,14 @@ WHERE (
   r.user_id = sqlc.arg(user_id)::UUID OR
   r.created_by = sqlc.arg(user_id)::UUID
 ) AND r.type = ANY(sqlc.arg(types)::TEXT[]) AND r.store_id = $1
-ORDER BY r.ts DESC;
\ No newline at end of file
+ORDER BY r.ts DESC;
+-- name: GetStoreUserRecordsBetween :many
+SELECT * FROM records
+WHERE store_id = $1 AND user_id = sqlc.arg(user_id)::UUID
+  AND ts >= sqlc.arg(from_ts)::BIGINT AND ts < sqlc.arg(to_ts)::BIGINT
+ORDER BY ts;
+
+-- name: GetStoreUserTransferOutSummary :one
+SELECT COUNT(*) AS count, COALESCE(SUM(-amount), 0)::BIGINT AS amount
+FROM records
+WHERE store_id = $1 AND user_id = $2 AND type = $3 AND amount < 0 AND ts >= $4;
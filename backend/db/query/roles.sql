@@ -0,0 +1,32 @@
+-- name: UpsertBuiltInRole :exec
+INSERT INTO roles (id, name, user_scopes, store_user_scopes, is_built_in)
+VALUES ($1, $2, $3, $4, true)
+ON CONFLICT (id) DO UPDATE
+SET name = EXCLUDED.name, user_scopes = EXCLUDED.user_scopes, store_user_scopes = EXCLUDED.store_user_scopes, is_built_in = true;
+
+-- name: CreateRole :one
+INSERT INTO roles (name, store_id, user_scopes, store_user_scopes)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetRole :one
+SELECT * FROM roles
+WHERE id = $1;
+
+-- name: GetRoles :many
+SELECT * FROM roles
+ORDER BY id;
+
+-- name: GetRolesByStoreID :many
+SELECT * FROM roles
+WHERE store_id IS NULL OR store_id = $1
+ORDER BY id;
+
+-- name: SetRoleNameAndScopes :exec
+UPDATE roles
+SET name = $2, user_scopes = $3, store_user_scopes = $4
+WHERE id = $1 AND is_built_in = false;
+
+-- name: DeleteRole :exec
+DELETE FROM roles
+WHERE id = $1 AND is_built_in = false;
@@ -0,0 +1,6 @@
+-- name: CreateRoleHistory :one
+INSERT INTO roles_history (changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, role_id, name, store_id, user_scopes, store_user_scopes, is_built_in, created_at)
+SELECT $2, $3, $4, $5, $6, id, name, store_id, user_scopes, store_user_scopes, is_built_in, created_at
+FROM roles AS r
+WHERE r.id = $1
+RETURNING *;
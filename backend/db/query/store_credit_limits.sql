@@ -0,0 +1,10 @@
+-- name: GetStoreCreditLimit :one
+SELECT * FROM store_credit_limits
+WHERE store_id = $1;
+
+-- name: UpsertStoreCreditLimit :one
+INSERT INTO store_credit_limits (store_id, default_credit_limit, updated_by, updated_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (store_id) DO UPDATE
+SET default_credit_limit = EXCLUDED.default_credit_limit, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at
+RETURNING *;
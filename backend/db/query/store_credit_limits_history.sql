@@ -0,0 +1,6 @@
+-- name: CreateStoreCreditLimitHistory :one
+INSERT INTO store_credit_limits_history (changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, store_id, default_credit_limit, updated_by, updated_at)
+SELECT $2, $3, $4, $5, $6, store_id, default_credit_limit, updated_by, updated_at
+FROM store_credit_limits AS scl
+WHERE scl.store_id = $1
+RETURNING *;
@@ -0,0 +1,39 @@
+-- name: TouchStoreDeviceSession :exec
+INSERT INTO store_device_sessions (store_id, device_id, started_at, last_seen_at)
+VALUES ($1, $2, $3, $3)
+ON CONFLICT (store_id, device_id) WHERE ended_at IS NULL DO UPDATE
+SET last_seen_at = GREATEST(store_device_sessions.last_seen_at, EXCLUDED.last_seen_at);
+
+-- name: EndStoreDeviceSession :exec
+UPDATE store_device_sessions
+SET ended_at = GREATEST(started_at, sqlc.arg(ts)::BIGINT), last_seen_at = GREATEST(last_seen_at, sqlc.arg(ts)::BIGINT)
+WHERE store_id = $1 AND device_id = $2 AND ended_at IS NULL;
+
+-- name: EndStaleStoreDeviceSession :exec
+UPDATE store_device_sessions
+SET ended_at = last_seen_at
+WHERE store_id = $1 AND device_id = $2 AND ended_at IS NULL AND last_seen_at < sqlc.arg(before)::BIGINT;
+
+-- name: EndStaleStoreDeviceSessions :exec
+UPDATE store_device_sessions
+SET ended_at = last_seen_at
+WHERE ended_at IS NULL AND last_seen_at < sqlc.arg(before)::BIGINT;
+
+-- name: EndStoreDeviceSessionsByStoreID :exec
+UPDATE store_device_sessions
+SET ended_at = last_seen_at
+WHERE store_id = $1 AND ended_at IS NULL;
+
+-- name: GetLatestStoreDeviceSessions :many
+SELECT DISTINCT ON (device_id) *
+FROM store_device_sessions
+WHERE store_id = $1
+ORDER BY device_id, started_at DESC;
+
+-- name: GetStoreDeviceSessionsInRange :many
+SELECT *
+FROM store_device_sessions
+WHERE store_id = $1
+  AND started_at < sqlc.arg(to_ts)::BIGINT
+  AND (ended_at IS NULL OR ended_at > sqlc.arg(from_ts)::BIGINT)
+ORDER BY device_id, started_at;
@@ -0,0 +1,25 @@
+-- name: CreateStoreDeviceZone :one
+INSERT INTO store_device_zones (id, store_id, name, sort_order)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetStoreDeviceZones :many
+SELECT *
+FROM store_device_zones
+WHERE store_id = $1
+ORDER BY sort_order, created_at;
+
+-- name: GetStoreDeviceZone :one
+SELECT *
+FROM store_device_zones
+WHERE store_id = $1 AND id = $2;
+
+-- name: UpdateStoreDeviceZone :one
+UPDATE store_device_zones
+SET name = $3, sort_order = $4
+WHERE store_id = $1 AND id = $2
+RETURNING *;
+
+-- name: DeleteStoreDeviceZone :exec
+DELETE FROM store_device_zones
+WHERE store_id = $1 AND id = $2;
@@ -1,20 +1,96 @@
 -- name: CreateStoreDevice :one
-INSERT INTO store_devices (store_id, device_id, name, real_type, display_type, state)
-VALUES ($1, $2, $3, $4, $5, $6)
-ON CONFLICT (store_id, device_id) DO NOTHING
+INSERT INTO store_devices (store_id, device_id, name, real_type, display_type, state, hardware_id)
+VALUES ($1, $2, $3, $4, $5, $6, $2)
+ON CONFLICT DO NOTHING
 RETURNING *;
 
 -- name: GetStoreDevices :many
 SELECT *
 FROM store_devices
-WHERE store_id = $1;
+WHERE store_id = sqlc.arg(store_id)
+  AND (sqlc.narg(state)::TEXT IS NULL OR state = sqlc.narg(state))
+ORDER BY sort_order, device_id;
 
 -- name: GetStoreDevice :one
 SELECT *
 FROM store_devices
 WHERE store_id = $1 AND device_id = $2;
 
+-- name: GetStoreDeviceByHardwareID :one
+SELECT *
+FROM store_devices
+WHERE store_id = $1 AND hardware_id = $2;
+
+-- name: CountStoreDevicesByDisplayType :one
+SELECT COUNT(*) FROM store_devices
+WHERE display_type = $1;
+
 -- name: SetStoreDeviceNameAndDisplayType :exec
 UPDATE store_devices
 SET name = $3, display_type=$4
-WHERE store_id = $1 AND device_id = $2;
\ No newline at end of file
+WHERE store_id = $1 AND device_id = $2;
+
+-- name: SetStoreDeviceLayout :exec
+UPDATE store_devices
+SET zone_id = $3, sort_order = $4, machine_number = $5, capacity_kg = $6
+WHERE store_id = $1 AND device_id = $2;
+
+-- name: ClearStoreDevicesZoneID :many
+UPDATE store_devices
+SET zone_id = NULL
+WHERE store_id = $1 AND zone_id = $2
+RETURNING device_id;
+
+-- name: SetStoreDeviceState :exec
+UPDATE store_devices
+SET state = $3
+WHERE store_id = $1 AND device_id = $2;
+
+-- name: SetStoreDeviceHardwareID :exec
+UPDATE store_devices
+SET hardware_id = $3
+WHERE store_id = $1 AND device_id = $2;
+
+-- name: DeleteStoreDevice :exec
+DELETE FROM store_devices
+WHERE store_id = $1 AND device_id = $2;
+
+-- name: CreateStoreDeviceRetiredHardwareID :exec
+INSERT INTO store_device_retired_hardware_ids (store_id, hardware_id, device_id, retired_by)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetStoreDeviceRetiredHardwareID :one
+SELECT *
+FROM store_device_retired_hardware_ids
+WHERE store_id = $1 AND hardware_id = $2;
+
+-- name: GetStoreDeviceInOtherStoreByHardwareID :one
+SELECT *
+FROM store_devices
+WHERE hardware_id = $1 AND store_id <> $2 AND state NOT IN ('archived', 'decommissioned')
+LIMIT 1;
+
+-- name: CreateTransferredStoreDevice :one
+INSERT INTO store_devices (store_id, device_id, name, real_type, display_type, state, hardware_id, machine_number, capacity_kg)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (store_id, device_id) DO UPDATE
+SET name = EXCLUDED.name, real_type = EXCLUDED.real_type, display_type = EXCLUDED.display_type, state = EXCLUDED.state, hardware_id = EXCLUDED.hardware_id,
+    zone_id = NULL, sort_order = 0, machine_number = EXCLUDED.machine_number, capacity_kg = EXCLUDED.capacity_kg
+WHERE store_devices.state = 'archived'
+RETURNING *;
+
+-- name: UpsertStoreDeviceMisplacement :exec
+INSERT INTO store_device_misplacements (store_id, hardware_id, registered_store_id, registered_device_id, first_seen_at, last_seen_at)
+VALUES ($1, $2, $3, $4, $5, $5)
+ON CONFLICT (store_id, hardware_id) DO UPDATE
+SET registered_store_id = EXCLUDED.registered_store_id, registered_device_id = EXCLUDED.registered_device_id, last_seen_at = EXCLUDED.last_seen_at;
+
+-- name: GetStoreDeviceMisplacements :many
+SELECT *
+FROM store_device_misplacements
+WHERE store_id = $1
+ORDER BY last_seen_at DESC;
+
+-- name: DeleteStoreDeviceMisplacement :exec
+DELETE FROM store_device_misplacements
+WHERE store_id = $1 AND hardware_id = $2;
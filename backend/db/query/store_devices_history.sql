@@ -1,6 +1,24 @@
 -- name: CreateStoreDeviceHistory :one
-INSERT INTO store_devices_history (changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, store_id, device_id, name, real_type, display_type, state, created_at)
-SELECT $3, $4, $5, $6, $7, store_id, device_id, name, real_type, display_type, state, created_at
+INSERT INTO store_devices_history (changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, store_id, device_id, name, real_type, display_type, state, created_at, hardware_id, zone_id, sort_order, machine_number, capacity_kg)
+SELECT $3, $4, $5, $6, $7, store_id, device_id, name, real_type, display_type, state, created_at, hardware_id, zone_id, sort_order, machine_number, capacity_kg
 FROM store_devices AS sd
 WHERE sd.store_id = $1 AND sd.device_id = $2
 RETURNING *;
+
+-- name: GetStoreDevicesHistory :many
+SELECT * FROM store_devices_history
+WHERE (sqlc.narg(store_id)::UUID IS NULL OR store_id = sqlc.narg(store_id))
+  AND (sqlc.narg(device_id)::TEXT IS NULL OR device_id = sqlc.narg(device_id))
+  AND (sqlc.narg(changed_by)::UUID IS NULL OR changed_by = sqlc.narg(changed_by))
+  AND (sqlc.narg(changed_type)::TEXT IS NULL OR changed_type = sqlc.narg(changed_type))
+  AND (sqlc.narg(changed_from)::BIGINT IS NULL OR changed_at >= sqlc.narg(changed_from))
+  AND (sqlc.narg(changed_to)::BIGINT IS NULL OR changed_at < sqlc.narg(changed_to))
+ORDER BY changed_at DESC, history_created_at DESC
+LIMIT sqlc.arg(limit_count);
+
+-- name: GetPreviousStoreDevicesHistory :one
+SELECT * FROM store_devices_history
+WHERE store_id = $1 AND device_id = $2
+  AND (changed_at, history_created_at) < (sqlc.arg(changed_at)::BIGINT, sqlc.arg(history_created_at)::BIGINT)
+ORDER BY changed_at DESC, history_created_at DESC
+LIMIT 1;
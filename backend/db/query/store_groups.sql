@@ -0,0 +1,67 @@
+-- name: CreateStoreGroup :one
+INSERT INTO store_groups (id, name, created_by)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetStoreGroup :one
+SELECT * FROM store_groups
+WHERE id = $1;
+
+-- name: GetStoreGroups :many
+SELECT * FROM store_groups
+ORDER BY created_at;
+
+-- name: AddStoreToStoreGroup :exec
+INSERT INTO store_group_stores (store_group_id, store_id, created_by)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveStoreFromStoreGroup :exec
+DELETE FROM store_group_stores
+WHERE store_group_id = $1 AND store_id = $2;
+
+-- name: AddUserToStoreGroup :exec
+INSERT INTO store_group_users (store_group_id, user_id, created_by)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveUserFromStoreGroup :exec
+DELETE FROM store_group_users
+WHERE store_group_id = $1 AND user_id = $2;
+
+-- name: GetStoreGroupStoreIDs :many
+SELECT store_id FROM store_group_stores
+WHERE store_group_id = $1
+ORDER BY created_at;
+
+-- name: GetStoreGroupUserIDs :many
+SELECT user_id FROM store_group_users
+WHERE store_group_id = $1
+ORDER BY created_at;
+
+-- name: GetStoresByStoreGroupUserID :many
+SELECT DISTINCT s.*
+FROM stores s
+INNER JOIN store_group_stores sgs ON s.id = sgs.store_id
+INNER JOIN store_group_users sgu ON sgs.store_group_id = sgu.store_group_id
+WHERE sgu.user_id = $1;
+
+-- name: IsStoreInUserStoreGroups :one
+SELECT EXISTS (
+    SELECT 1
+    FROM store_group_stores sgs
+    INNER JOIN store_group_users sgu ON sgs.store_group_id = sgu.store_group_id
+    WHERE sgu.user_id = $1 AND sgs.store_id = $2
+);
+
+-- name: IsUserInStoreGroup :one
+SELECT EXISTS (
+    SELECT 1 FROM store_group_users
+    WHERE store_group_id = $1 AND user_id = $2
+);
+
+-- name: IsStoreInStoreGroup :one
+SELECT EXISTS (
+    SELECT 1 FROM store_group_stores
+    WHERE store_group_id = $1 AND store_id = $2
+);
@@ -0,0 +1,27 @@
+-- name: CreateStoreJoinCode :one
+INSERT INTO store_join_codes (id, store_id, code, max_uses, expired_at, created_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetStoreJoinCode :one
+SELECT * FROM store_join_codes
+WHERE store_id = $1 AND id = $2;
+
+-- name: GetStoreJoinCodeByCode :one
+SELECT * FROM store_join_codes
+WHERE code = $1;
+
+-- name: GetStoreJoinCodes :many
+SELECT * FROM store_join_codes
+WHERE store_id = $1
+ORDER BY created_at DESC;
+
+-- name: UseStoreJoinCode :execrows
+UPDATE store_join_codes
+SET used_count = used_count + 1
+WHERE id = $1 AND is_revoked = false AND (max_uses IS NULL OR used_count < max_uses);
+
+-- name: RevokeStoreJoinCode :exec
+UPDATE store_join_codes
+SET is_revoked = true, revoked_by = $3, revoked_at = $4
+WHERE store_id = $1 AND id = $2;
@@ -0,0 +1,10 @@
+-- name: GetStoreUserCreditLimit :one
+SELECT * FROM store_user_credit_limits
+WHERE store_id = $1 AND user_id = $2;
+
+-- name: UpsertStoreUserCreditLimit :one
+INSERT INTO store_user_credit_limits (store_id, user_id, credit_limit)
+VALUES ($1, $2, $3)
+ON CONFLICT (store_id, user_id) DO UPDATE
+SET credit_limit = EXCLUDED.credit_limit
+RETURNING *;
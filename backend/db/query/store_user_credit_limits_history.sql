@@ -0,0 +1,6 @@
+-- name: CreateStoreUserCreditLimitHistory :one
+INSERT INTO store_user_credit_limits_history (changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, store_id, user_id, credit_limit, created_at)
+SELECT $3, $4, $5, $6, $7, store_id, user_id, credit_limit, created_at
+FROM store_user_credit_limits AS sucl
+WHERE sucl.store_id = $1 AND sucl.user_id = $2
+RETURNING *;
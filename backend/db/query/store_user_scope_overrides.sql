@@ -0,0 +1,15 @@
+-- name: GetStoreUserScopeOverride :one
+SELECT * FROM store_user_scope_overrides
+WHERE store_id = $1 AND user_id = $2;
+
+-- name: ClearStoreUserScopeOverride :execrows
+UPDATE store_user_scope_overrides
+SET granted_scopes = '{}', denied_scopes = '{}'
+WHERE store_id = $1 AND user_id = $2;
+
+-- name: UpsertStoreUserScopeOverride :one
+INSERT INTO store_user_scope_overrides (store_id, user_id, granted_scopes, denied_scopes)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (store_id, user_id) DO UPDATE
+SET granted_scopes = EXCLUDED.granted_scopes, denied_scopes = EXCLUDED.denied_scopes
+RETURNING *;
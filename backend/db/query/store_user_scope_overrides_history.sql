@@ -0,0 +1,6 @@
+-- name: CreateStoreUserScopeOverrideHistory :one
+INSERT INTO store_user_scope_overrides_history (changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, store_id, user_id, granted_scopes, denied_scopes, created_at)
+SELECT $3, $4, $5, $6, $7, store_id, user_id, granted_scopes, denied_scopes, created_at
+FROM store_user_scope_overrides AS suso
+WHERE suso.store_id = $1 AND suso.user_id = $2
+RETURNING *;
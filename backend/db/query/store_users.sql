@@ -25,4 +25,28 @@ WHERE su.store_id = s.id AND su.user_id = u.id AND su.store_id = $1;
 -- name: SetStoreUserBalance :exec
 UPDATE store_users
 SET balance = $3, points = $4, balance_earmark = $5, points_earmark = $6
-WHERE store_id = $1 AND user_id = $2;
\ No newline at end of file
+WHERE store_id = $1 AND user_id = $2;
+
+-- name: CountStoreUsersByRoleID :one
+SELECT COUNT(*) FROM store_users
+WHERE role_id = $1;
+
+-- name: SetStoreUserRoleIDAndState :exec
+UPDATE store_users
+SET role_id = $3, state = $4
+WHERE store_id = $1 AND user_id = $2;
+
+-- name: GetStoreUserInvitationsByUserID :many
+SELECT s.id AS store_id, s.name AS store_name, s.address AS store_address, su.role_id, su.created_at
+FROM store_users su INNER JOIN stores s
+ON su.store_id = s.id
+WHERE su.user_id = $1 AND su.state = 'pending'
+ORDER BY su.created_at DESC;
+
+-- name: GetStoreDebtors :many
+SELECT u.id AS user_id, u.phone_number, u.name, su.balance, su.points, su.balance_earmark, su.points_earmark, sucl.credit_limit
+FROM store_users su
+INNER JOIN users u ON su.user_id = u.id
+LEFT JOIN store_user_credit_limits sucl ON su.store_id = sucl.store_id AND su.user_id = sucl.user_id
+WHERE su.store_id = $1 AND su.balance < 0
+ORDER BY su.balance ASC;
@@ -4,3 +4,33 @@ SELECT $3, $4, $5, $6, $7, store_id, user_id, balance, points, balance_earmark,
 FROM store_users AS su
 WHERE su.store_id = $1 AND su.user_id = $2
 RETURNING *;
+
+-- name: GetStoreUsersHistory :many
+SELECT * FROM store_users_history
+WHERE (sqlc.narg(store_id)::UUID IS NULL OR store_id = sqlc.narg(store_id))
+  AND (sqlc.narg(user_id)::UUID IS NULL OR user_id = sqlc.narg(user_id))
+  AND (sqlc.narg(changed_by)::UUID IS NULL OR changed_by = sqlc.narg(changed_by))
+  AND (sqlc.narg(changed_type)::TEXT IS NULL OR changed_type = sqlc.narg(changed_type))
+  AND (sqlc.narg(changed_from)::BIGINT IS NULL OR changed_at >= sqlc.narg(changed_from))
+  AND (sqlc.narg(changed_to)::BIGINT IS NULL OR changed_at < sqlc.narg(changed_to))
+ORDER BY changed_at DESC, history_created_at DESC
+LIMIT sqlc.arg(limit_count);
+
+-- name: GetPreviousStoreUsersHistory :one
+SELECT * FROM store_users_history
+WHERE store_id = $1 AND user_id = $2
+  AND (changed_at, history_created_at) < (sqlc.arg(changed_at)::BIGINT, sqlc.arg(history_created_at)::BIGINT)
+ORDER BY changed_at DESC, history_created_at DESC
+LIMIT 1;
+
+-- name: GetStoreUserHistoryAt :one
+SELECT * FROM store_users_history
+WHERE store_id = $1 AND user_id = $2 AND changed_at <= sqlc.arg(ts)::BIGINT
+ORDER BY changed_at DESC, history_created_at DESC
+LIMIT 1;
+
+-- name: GetStoreUserHistoryBetween :many
+SELECT * FROM store_users_history
+WHERE store_id = $1 AND user_id = $2
+  AND changed_at >= sqlc.arg(from_ts)::BIGINT AND changed_at < sqlc.arg(to_ts)::BIGINT
+ORDER BY changed_at, history_created_at;
@@ -29,4 +29,4 @@ WHERE id = $1;
 SELECT s.*
 FROM stores s INNER JOIN store_users su
 ON s.id = su.store_id
-WHERE su.user_id = $1;
\ No newline at end of file
+WHERE su.user_id = $1 AND su.state <> 'pending';
\ No newline at end of file
@@ -4,3 +4,20 @@ SELECT $2, $3, $4, $5, $6, id, name, address, state, password, created_at
 FROM stores AS s
 WHERE s.id = $1
 RETURNING *;
+
+-- name: GetStoresHistory :many
+SELECT * FROM stores_history
+WHERE (sqlc.narg(store_id)::UUID IS NULL OR store_id = sqlc.narg(store_id))
+  AND (sqlc.narg(changed_by)::UUID IS NULL OR changed_by = sqlc.narg(changed_by))
+  AND (sqlc.narg(changed_type)::TEXT IS NULL OR changed_type = sqlc.narg(changed_type))
+  AND (sqlc.narg(changed_from)::BIGINT IS NULL OR changed_at >= sqlc.narg(changed_from))
+  AND (sqlc.narg(changed_to)::BIGINT IS NULL OR changed_at < sqlc.narg(changed_to))
+ORDER BY changed_at DESC, history_created_at DESC
+LIMIT sqlc.arg(limit_count);
+
+-- name: GetPreviousStoresHistory :one
+SELECT * FROM stores_history
+WHERE store_id = $1
+  AND (changed_at, history_created_at) < (sqlc.arg(changed_at)::BIGINT, sqlc.arg(history_created_at)::BIGINT)
+ORDER BY changed_at DESC, history_created_at DESC
+LIMIT 1;
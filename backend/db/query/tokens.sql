@@ -5,4 +5,9 @@ RETURNING *;
 
 -- name: GetToken :one
 SELECT * FROM tokens
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: BlockUserTokens :exec
+UPDATE tokens
+SET is_blocked = true
+WHERE user_id = $1 AND is_blocked = false;
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (provider, subject, user_id)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetUserIdentity :one
+SELECT * FROM user_identities
+WHERE provider = $1 AND subject = $2;
+
+-- name: GetUserIdentityByUserID :one
+SELECT * FROM user_identities
+WHERE user_id = $1 AND provider = $2;
@@ -19,4 +19,9 @@ WHERE id = $1;
 -- name: SetUserName :exec
 UPDATE users
 SET name = $2
+WHERE id = $1;
+
+-- name: SetUserPhoneNumber :exec
+UPDATE users
+SET phone_number = $2, tokens_revoked_at = $3
 WHERE id = $1;
\ No newline at end of file
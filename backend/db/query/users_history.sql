@@ -4,3 +4,31 @@ SELECT $2, $3, $4, $5, $6, id, phone_number, name, password, password_error_coun
 FROM users AS u
 WHERE u.id = $1
 RETURNING *;
+
+-- name: GetUserRecentPasswords :many
+SELECT h.password
+FROM (
+  SELECT password, MAX(changed_at) AS last_used_at
+  FROM users_history
+  WHERE user_id = $1
+  GROUP BY password
+) AS h
+ORDER BY h.last_used_at DESC
+LIMIT $2;
+
+-- name: GetUsersHistory :many
+SELECT * FROM users_history
+WHERE (sqlc.narg(user_id)::UUID IS NULL OR user_id = sqlc.narg(user_id))
+  AND (sqlc.narg(changed_by)::UUID IS NULL OR changed_by = sqlc.narg(changed_by))
+  AND (sqlc.narg(changed_type)::TEXT IS NULL OR changed_type = sqlc.narg(changed_type))
+  AND (sqlc.narg(changed_from)::BIGINT IS NULL OR changed_at >= sqlc.narg(changed_from))
+  AND (sqlc.narg(changed_to)::BIGINT IS NULL OR changed_at < sqlc.narg(changed_to))
+ORDER BY changed_at DESC, history_created_at DESC
+LIMIT sqlc.arg(limit_count);
+
+-- name: GetPreviousUsersHistory :one
+SELECT * FROM users_history
+WHERE user_id = $1
+  AND (changed_at, history_created_at) < (sqlc.arg(changed_at)::BIGINT, sqlc.arg(history_created_at)::BIGINT)
+ORDER BY changed_at DESC, history_created_at DESC
+LIMIT 1;
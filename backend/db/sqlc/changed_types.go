@@ -1,6 +1,17 @@
 package db
 
 const (
-	StoreDeviceChangedTypeCreate     string = "create"
-	StoreDeviceChangedTypeUpdateInfo string = "update_info"
+	StoreDeviceChangedTypeCreate            string = "create"
+	StoreDeviceChangedTypeUpdateInfo        string = "update_info"
+	StoreDeviceChangedTypeApprove           string = "approve"
+	StoreDeviceChangedTypeStartMaintenance  string = "start_maintenance"
+	StoreDeviceChangedTypeFinishMaintenance string = "finish_maintenance"
+	StoreDeviceChangedTypeDisable           string = "disable"
+	StoreDeviceChangedTypeEnable            string = "enable"
+	StoreDeviceChangedTypeDecommission      string = "decommission"
+	StoreDeviceChangedTypeReplaceHardware   string = "replace_hardware"
+	StoreDeviceChangedTypeMerge             string = "merge"
+	StoreDeviceChangedTypeTransferOut       string = "transfer_out"
+	StoreDeviceChangedTypeTransferIn        string = "transfer_in"
+	StoreDeviceChangedTypeUpdateLayout      string = "update_layout"
 )
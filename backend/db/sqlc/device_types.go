@@ -3,6 +3,7 @@ package db
 const (
 	StoreDeviceRealTypeCoinAcceptor string = "coin_acceptor"
 
+	// 內建的 display type，其餘由 device_display_types 登錄，新註冊的機台預設為 washer
 	StoreDeviceDisplayTypeWasher string = "washer"
 	StoreDeviceDisplayTypeDryer  string = "dryer"
 )
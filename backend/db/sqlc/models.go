@@ -6,10 +6,140 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 
 	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         uuid.UUID
+	StoreID    uuid.UUID
+	Name       string
+	KeyHash    string
+	Scopes     []string
+	AllowedIps []string
+	ExpiredAt  sql.NullInt64
+	IsRevoked  bool
+	RevokedBy  uuid.NullUUID
+	RevokedAt  sql.NullInt64
+	CreatedBy  uuid.UUID
+	CreatedAt  int64
+}
+
+type BalanceAdjustment struct {
+	ID          uuid.UUID
+	StoreID     uuid.UUID
+	UserID      uuid.UUID
+	Amount      int32
+	PointAmount int32
+	ReasonCode  string
+	Note        string
+	State       string
+	RequestedBy uuid.UUID
+	ReviewedBy  uuid.NullUUID
+	ReviewedAt  sql.NullInt64
+	CreatedAt   int64
+}
+
+type BrandWalletConsumption struct {
+	ConsumptionID uuid.UUID
+	LotID         uuid.UUID
+	StoreGroupID  uuid.UUID
+	UserID        uuid.UUID
+	StoreID       uuid.UUID
+	FundedStoreID uuid.UUID
+	Amount        int32
+	Ts            int64
+	CreatedAt     int64
+}
+
+type BrandWalletLot struct {
+	ID           uuid.UUID
+	StoreGroupID uuid.UUID
+	UserID       uuid.UUID
+	StoreID      uuid.UUID
+	Amount       int32
+	Remaining    int32
+	CreatedBy    uuid.UUID
+	CreatedAt    int64
+}
+
+type BrandWalletStore struct {
+	StoreID      uuid.UUID
+	StoreGroupID uuid.UUID
+	CreatedBy    uuid.UUID
+	CreatedAt    int64
+}
+
+type DeviceDisplayType struct {
+	Code                   string
+	Names                  json.RawMessage
+	IconKey                string
+	DefaultPrograms        json.RawMessage
+	ConsumesPointsOverTime bool
+	IsBuiltIn              bool
+	CreatedAt              int64
+}
+
+type DeviceDisplayTypesHistory struct {
+	ChangedAt              int64
+	ChangedType            string
+	ChangedBy              uuid.NullUUID
+	ChangedUserAgent       sql.NullString
+	ChangedClientIp        sql.NullString
+	Code                   string
+	Names                  json.RawMessage
+	IconKey                string
+	DefaultPrograms        json.RawMessage
+	ConsumesPointsOverTime bool
+	IsBuiltIn              bool
+	CreatedAt              int64
+	HistoryCreatedAt       int64
+}
+
+type FamilyWallet struct {
+	ID          uuid.UUID
+	StoreID     uuid.UUID
+	OwnerUserID uuid.UUID
+	CreatedAt   int64
+}
+
+type FamilyWalletMember struct {
+	FamilyWalletID uuid.UUID
+	StoreID        uuid.UUID
+	UserID         uuid.UUID
+	SpendingCap    sql.NullInt32
+	CreatedBy      uuid.UUID
+	CreatedAt      int64
+	AcceptedAt     sql.NullInt64
+}
+
+type FamilyWalletSpending struct {
+	ID             uuid.UUID
+	FamilyWalletID uuid.UUID
+	StoreID        uuid.UUID
+	UserID         uuid.UUID
+	DeviceID       string
+	Amount         int32
+	PointAmount    int32
+	Ts             int64
+	CreatedAt      int64
+}
+
+type OidcAuthRequest struct {
+	State         string
+	Provider      string
+	CodeVerifier  string
+	Nonce         string
+	IsUsed        bool
+	Subject       sql.NullString
+	LinkToken     sql.NullString
+	LinkExpiredAt sql.NullInt64
+	IsLinked      bool
+	ExpiredAt     int64
+	CreatedAt     int64
+}
+
 type Record struct {
 	CreatedBy         uuid.NullUUID
 	CreatedUserAgent  sql.NullString
@@ -26,6 +156,32 @@ type Record struct {
 	CreatedAt         int64
 }
 
+type Role struct {
+	ID              int16
+	Name            string
+	StoreID         uuid.NullUUID
+	UserScopes      []string
+	StoreUserScopes []string
+	IsBuiltIn       bool
+	CreatedAt       int64
+}
+
+type RolesHistory struct {
+	ChangedAt        int64
+	ChangedType      string
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	RoleID           int16
+	Name             string
+	StoreID          uuid.NullUUID
+	UserScopes       []string
+	StoreUserScopes  []string
+	IsBuiltIn        bool
+	CreatedAt        int64
+	HistoryCreatedAt int64
+}
+
 type Store struct {
 	ID        uuid.UUID
 	Name      string
@@ -35,14 +191,72 @@ type Store struct {
 	CreatedAt int64
 }
 
+type StoreCreditLimit struct {
+	StoreID            uuid.UUID
+	DefaultCreditLimit int32
+	UpdatedBy          uuid.UUID
+	UpdatedAt          int64
+}
+
+type StoreCreditLimitsHistory struct {
+	ChangedAt          int64
+	ChangedType        string
+	ChangedBy          uuid.NullUUID
+	ChangedUserAgent   sql.NullString
+	ChangedClientIp    sql.NullString
+	StoreID            uuid.UUID
+	DefaultCreditLimit int32
+	UpdatedBy          uuid.UUID
+	UpdatedAt          int64
+	HistoryCreatedAt   int64
+}
+
 type StoreDevice struct {
-	StoreID     uuid.UUID
-	DeviceID    string
-	Name        string
-	RealType    string
-	DisplayType string
-	State       string
-	CreatedAt   int64
+	StoreID       uuid.UUID
+	DeviceID      string
+	Name          string
+	RealType      string
+	DisplayType   string
+	State         string
+	CreatedAt     int64
+	HardwareID    string
+	ZoneID        uuid.NullUUID
+	SortOrder     int32
+	MachineNumber sql.NullString
+	CapacityKg    sql.NullFloat64
+}
+
+type StoreDeviceMisplacement struct {
+	StoreID            uuid.UUID
+	HardwareID         string
+	RegisteredStoreID  uuid.UUID
+	RegisteredDeviceID string
+	FirstSeenAt        int64
+	LastSeenAt         int64
+}
+
+type StoreDeviceRetiredHardwareID struct {
+	StoreID    uuid.UUID
+	HardwareID string
+	DeviceID   string
+	RetiredBy  uuid.UUID
+	RetiredAt  int64
+}
+
+type StoreDeviceSession struct {
+	StoreID    uuid.UUID
+	DeviceID   string
+	StartedAt  int64
+	EndedAt    sql.NullInt64
+	LastSeenAt int64
+}
+
+type StoreDeviceZone struct {
+	ID        uuid.UUID
+	StoreID   uuid.UUID
+	Name      string
+	SortOrder int32
+	CreatedAt int64
 }
 
 type StoreDevicesHistory struct {
@@ -59,6 +273,46 @@ type StoreDevicesHistory struct {
 	State            string
 	CreatedAt        int64
 	HistoryCreatedAt int64
+	HardwareID       string
+	ZoneID           uuid.NullUUID
+	SortOrder        int32
+	MachineNumber    sql.NullString
+	CapacityKg       sql.NullFloat64
+}
+
+type StoreGroup struct {
+	ID        uuid.UUID
+	Name      string
+	CreatedBy uuid.UUID
+	CreatedAt int64
+}
+
+type StoreGroupStore struct {
+	StoreGroupID uuid.UUID
+	StoreID      uuid.UUID
+	CreatedBy    uuid.UUID
+	CreatedAt    int64
+}
+
+type StoreGroupUser struct {
+	StoreGroupID uuid.UUID
+	UserID       uuid.UUID
+	CreatedBy    uuid.UUID
+	CreatedAt    int64
+}
+
+type StoreJoinCode struct {
+	ID        uuid.UUID
+	StoreID   uuid.UUID
+	Code      string
+	MaxUses   sql.NullInt32
+	UsedCount int32
+	ExpiredAt int64
+	IsRevoked bool
+	RevokedBy uuid.NullUUID
+	RevokedAt sql.NullInt64
+	CreatedBy uuid.UUID
+	CreatedAt int64
 }
 
 type StoreUser struct {
@@ -73,6 +327,48 @@ type StoreUser struct {
 	CreatedAt      int64
 }
 
+type StoreUserCreditLimit struct {
+	StoreID     uuid.UUID
+	UserID      uuid.UUID
+	CreditLimit sql.NullInt32
+	CreatedAt   int64
+}
+
+type StoreUserCreditLimitsHistory struct {
+	ChangedAt        int64
+	ChangedType      string
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	StoreID          uuid.UUID
+	UserID           uuid.UUID
+	CreditLimit      sql.NullInt32
+	CreatedAt        int64
+	HistoryCreatedAt int64
+}
+
+type StoreUserScopeOverride struct {
+	StoreID       uuid.UUID
+	UserID        uuid.UUID
+	GrantedScopes []string
+	DeniedScopes  []string
+	CreatedAt     int64
+}
+
+type StoreUserScopeOverridesHistory struct {
+	ChangedAt        int64
+	ChangedType      string
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	StoreID          uuid.UUID
+	UserID           uuid.UUID
+	GrantedScopes    []string
+	DeniedScopes     []string
+	CreatedAt        int64
+	HistoryCreatedAt int64
+}
+
 type StoreUsersHistory struct {
 	ChangedAt        int64
 	ChangedType      string
@@ -128,6 +424,14 @@ type User struct {
 	RoleID             int16
 	State              string
 	CreatedAt          int64
+	TokensRevokedAt    sql.NullInt64
+}
+
+type UserIdentity struct {
+	Provider  string
+	Subject   string
+	UserID    uuid.UUID
+	CreatedAt int64
 }
 
 type UsersHistory struct {
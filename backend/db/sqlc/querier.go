@@ -6,47 +6,179 @@ package db
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
+	AcceptFamilyWalletMember(ctx context.Context, arg AcceptFamilyWalletMemberParams) (int64, error)
+	AddBrandWalletLotRemaining(ctx context.Context, arg AddBrandWalletLotRemainingParams) error
+	AddFamilyWalletMember(ctx context.Context, arg AddFamilyWalletMemberParams) (FamilyWalletMember, error)
+	AddStoreToStoreGroup(ctx context.Context, arg AddStoreToStoreGroupParams) error
+	AddUserToStoreGroup(ctx context.Context, arg AddUserToStoreGroupParams) error
+	BlockUserTokens(ctx context.Context, userID uuid.UUID) error
 	BlockVerCodes(ctx context.Context, id uuid.UUID) error
+	ClearStoreDevicesZoneID(ctx context.Context, arg ClearStoreDevicesZoneIDParams) ([]string, error)
+	ClearStoreUserScopeOverride(ctx context.Context, arg ClearStoreUserScopeOverrideParams) (int64, error)
+	CountFamilyWalletMembers(ctx context.Context, familyWalletID uuid.UUID) (int64, error)
+	CountStoreDevicesByDisplayType(ctx context.Context, displayType string) (int64, error)
+	CountStoreUsersByRoleID(ctx context.Context, roleID int16) (int64, error)
+	CreateApiKey(ctx context.Context, arg CreateApiKeyParams) (ApiKey, error)
+	CreateBalanceAdjustment(ctx context.Context, arg CreateBalanceAdjustmentParams) (BalanceAdjustment, error)
+	CreateBrandWalletConsumption(ctx context.Context, arg CreateBrandWalletConsumptionParams) (BrandWalletConsumption, error)
+	CreateBrandWalletLot(ctx context.Context, arg CreateBrandWalletLotParams) (BrandWalletLot, error)
+	CreateDeviceDisplayType(ctx context.Context, arg CreateDeviceDisplayTypeParams) (DeviceDisplayType, error)
+	CreateDeviceDisplayTypeHistory(ctx context.Context, arg CreateDeviceDisplayTypeHistoryParams) (DeviceDisplayTypesHistory, error)
+	CreateFamilyWallet(ctx context.Context, arg CreateFamilyWalletParams) (FamilyWallet, error)
+	CreateFamilyWalletSpending(ctx context.Context, arg CreateFamilyWalletSpendingParams) (FamilyWalletSpending, error)
+	CreateOidcAuthRequest(ctx context.Context, arg CreateOidcAuthRequestParams) (OidcAuthRequest, error)
 	CreateRecord(ctx context.Context, arg CreateRecordParams) (Record, error)
+	CreateRole(ctx context.Context, arg CreateRoleParams) (Role, error)
+	CreateRoleHistory(ctx context.Context, arg CreateRoleHistoryParams) (RolesHistory, error)
 	CreateStore(ctx context.Context, arg CreateStoreParams) (Store, error)
+	CreateStoreCreditLimitHistory(ctx context.Context, arg CreateStoreCreditLimitHistoryParams) (StoreCreditLimitsHistory, error)
 	CreateStoreDevice(ctx context.Context, arg CreateStoreDeviceParams) (StoreDevice, error)
 	CreateStoreDeviceHistory(ctx context.Context, arg CreateStoreDeviceHistoryParams) (StoreDevicesHistory, error)
+	CreateStoreDeviceRetiredHardwareID(ctx context.Context, arg CreateStoreDeviceRetiredHardwareIDParams) error
+	CreateStoreDeviceZone(ctx context.Context, arg CreateStoreDeviceZoneParams) (StoreDeviceZone, error)
+	CreateStoreGroup(ctx context.Context, arg CreateStoreGroupParams) (StoreGroup, error)
 	CreateStoreHistory(ctx context.Context, arg CreateStoreHistoryParams) (StoresHistory, error)
+	CreateStoreJoinCode(ctx context.Context, arg CreateStoreJoinCodeParams) (StoreJoinCode, error)
 	CreateStoreUser(ctx context.Context, arg CreateStoreUserParams) (StoreUser, error)
+	CreateStoreUserCreditLimitHistory(ctx context.Context, arg CreateStoreUserCreditLimitHistoryParams) (StoreUserCreditLimitsHistory, error)
 	CreateStoreUserHistory(ctx context.Context, arg CreateStoreUserHistoryParams) (StoreUsersHistory, error)
+	CreateStoreUserScopeOverrideHistory(ctx context.Context, arg CreateStoreUserScopeOverrideHistoryParams) (StoreUserScopeOverridesHistory, error)
 	CreateToken(ctx context.Context, arg CreateTokenParams) (Token, error)
+	CreateTransferredStoreDevice(ctx context.Context, arg CreateTransferredStoreDeviceParams) (StoreDevice, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	CreateUserHistory(ctx context.Context, arg CreateUserHistoryParams) (UsersHistory, error)
+	CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error)
 	CreateVerCode(ctx context.Context, arg CreateVerCodeParams) (VerCode, error)
+	DeleteBrandWalletConsumptions(ctx context.Context, consumptionID uuid.UUID) error
+	DeleteDeviceDisplayType(ctx context.Context, code string) error
+	DeleteFamilyWalletSpending(ctx context.Context, id uuid.UUID) error
+	DeleteRole(ctx context.Context, id int16) error
+	DeleteStoreDevice(ctx context.Context, arg DeleteStoreDeviceParams) error
+	DeleteStoreDeviceMisplacement(ctx context.Context, arg DeleteStoreDeviceMisplacementParams) error
+	DeleteStoreDeviceZone(ctx context.Context, arg DeleteStoreDeviceZoneParams) error
+	EndStaleStoreDeviceSession(ctx context.Context, arg EndStaleStoreDeviceSessionParams) error
+	EndStaleStoreDeviceSessions(ctx context.Context, before int64) error
+	EndStoreDeviceSession(ctx context.Context, arg EndStoreDeviceSessionParams) error
+	EndStoreDeviceSessionsByStoreID(ctx context.Context, storeID uuid.UUID) error
+	GetApiKey(ctx context.Context, arg GetApiKeyParams) (ApiKey, error)
+	GetApiKeyByKeyHash(ctx context.Context, keyHash string) (ApiKey, error)
+	GetBalanceAdjustment(ctx context.Context, id uuid.UUID) (BalanceAdjustment, error)
+	GetBrandWalletBalance(ctx context.Context, arg GetBrandWalletBalanceParams) (int64, error)
+	GetBrandWalletConsumptions(ctx context.Context, consumptionID uuid.UUID) ([]BrandWalletConsumption, error)
+	GetBrandWalletLotsForUpdate(ctx context.Context, arg GetBrandWalletLotsForUpdateParams) ([]BrandWalletLot, error)
+	GetBrandWalletSettlement(ctx context.Context, arg GetBrandWalletSettlementParams) ([]GetBrandWalletSettlementRow, error)
+	GetBrandWalletStore(ctx context.Context, storeID uuid.UUID) (BrandWalletStore, error)
+	GetBrandWalletStoreIDs(ctx context.Context, storeGroupID uuid.UUID) ([]uuid.UUID, error)
+	GetDeviceDisplayType(ctx context.Context, code string) (DeviceDisplayType, error)
+	GetDeviceDisplayTypes(ctx context.Context) ([]DeviceDisplayType, error)
+	GetFamilyWallet(ctx context.Context, id uuid.UUID) (FamilyWallet, error)
+	GetFamilyWalletByOwner(ctx context.Context, arg GetFamilyWalletByOwnerParams) (FamilyWallet, error)
+	GetFamilyWalletMember(ctx context.Context, arg GetFamilyWalletMemberParams) (FamilyWalletMember, error)
+	GetFamilyWalletMembers(ctx context.Context, familyWalletID uuid.UUID) ([]GetFamilyWalletMembersRow, error)
+	GetFamilyWalletSpendings(ctx context.Context, arg GetFamilyWalletSpendingsParams) ([]GetFamilyWalletSpendingsRow, error)
+	GetLatestStoreDeviceSessions(ctx context.Context, storeID uuid.UUID) ([]StoreDeviceSession, error)
+	GetOidcAuthRequest(ctx context.Context, state string) (OidcAuthRequest, error)
+	GetOidcAuthRequestByLinkToken(ctx context.Context, linkToken sql.NullString) (OidcAuthRequest, error)
+	GetPreviousStoreDevicesHistory(ctx context.Context, arg GetPreviousStoreDevicesHistoryParams) (StoreDevicesHistory, error)
+	GetPreviousStoreUsersHistory(ctx context.Context, arg GetPreviousStoreUsersHistoryParams) (StoreUsersHistory, error)
+	GetPreviousStoresHistory(ctx context.Context, arg GetPreviousStoresHistoryParams) (StoresHistory, error)
+	GetPreviousUsersHistory(ctx context.Context, arg GetPreviousUsersHistoryParams) (UsersHistory, error)
+	GetRole(ctx context.Context, id int16) (Role, error)
+	GetRoles(ctx context.Context) ([]Role, error)
+	GetRolesByStoreID(ctx context.Context, storeID uuid.NullUUID) ([]Role, error)
 	GetStore(ctx context.Context, id uuid.UUID) (Store, error)
+	GetStoreApiKeys(ctx context.Context, storeID uuid.UUID) ([]ApiKey, error)
+	GetStoreBalanceAdjustments(ctx context.Context, arg GetStoreBalanceAdjustmentsParams) ([]BalanceAdjustment, error)
+	GetStoreCreditLimit(ctx context.Context, storeID uuid.UUID) (StoreCreditLimit, error)
+	GetStoreDebtors(ctx context.Context, storeID uuid.UUID) ([]GetStoreDebtorsRow, error)
 	GetStoreDevice(ctx context.Context, arg GetStoreDeviceParams) (StoreDevice, error)
+	GetStoreDeviceByHardwareID(ctx context.Context, arg GetStoreDeviceByHardwareIDParams) (StoreDevice, error)
+	GetStoreDeviceInOtherStoreByHardwareID(ctx context.Context, arg GetStoreDeviceInOtherStoreByHardwareIDParams) (StoreDevice, error)
+	GetStoreDeviceMisplacements(ctx context.Context, storeID uuid.UUID) ([]StoreDeviceMisplacement, error)
 	GetStoreDeviceRecords(ctx context.Context, arg GetStoreDeviceRecordsParams) ([]GetStoreDeviceRecordsRow, error)
-	GetStoreDevices(ctx context.Context, storeID uuid.UUID) ([]StoreDevice, error)
+	GetStoreDeviceRetiredHardwareID(ctx context.Context, arg GetStoreDeviceRetiredHardwareIDParams) (StoreDeviceRetiredHardwareID, error)
+	GetStoreDeviceSessionsInRange(ctx context.Context, arg GetStoreDeviceSessionsInRangeParams) ([]StoreDeviceSession, error)
+	GetStoreDeviceZone(ctx context.Context, arg GetStoreDeviceZoneParams) (StoreDeviceZone, error)
+	GetStoreDeviceZones(ctx context.Context, storeID uuid.UUID) ([]StoreDeviceZone, error)
+	GetStoreDevices(ctx context.Context, arg GetStoreDevicesParams) ([]StoreDevice, error)
+	GetStoreDevicesHistory(ctx context.Context, arg GetStoreDevicesHistoryParams) ([]StoreDevicesHistory, error)
+	GetStoreGroup(ctx context.Context, id uuid.UUID) (StoreGroup, error)
+	GetStoreGroupStoreIDs(ctx context.Context, storeGroupID uuid.UUID) ([]uuid.UUID, error)
+	GetStoreGroupUserIDs(ctx context.Context, storeGroupID uuid.UUID) ([]uuid.UUID, error)
+	GetStoreGroups(ctx context.Context) ([]StoreGroup, error)
+	GetStoreJoinCode(ctx context.Context, arg GetStoreJoinCodeParams) (StoreJoinCode, error)
+	GetStoreJoinCodeByCode(ctx context.Context, code string) (StoreJoinCode, error)
+	GetStoreJoinCodes(ctx context.Context, storeID uuid.UUID) ([]StoreJoinCode, error)
 	GetStoreUser(ctx context.Context, arg GetStoreUserParams) (StoreUser, error)
+	GetStoreUserCreditLimit(ctx context.Context, arg GetStoreUserCreditLimitParams) (StoreUserCreditLimit, error)
+	GetStoreUserHistoryAt(ctx context.Context, arg GetStoreUserHistoryAtParams) (StoreUsersHistory, error)
+	GetStoreUserHistoryBetween(ctx context.Context, arg GetStoreUserHistoryBetweenParams) ([]StoreUsersHistory, error)
+	GetStoreUserInvitationsByUserID(ctx context.Context, userID uuid.UUID) ([]GetStoreUserInvitationsByUserIDRow, error)
 	GetStoreUserRecords(ctx context.Context, arg GetStoreUserRecordsParams) ([]GetStoreUserRecordsRow, error)
+	GetStoreUserRecordsBetween(ctx context.Context, arg GetStoreUserRecordsBetweenParams) ([]Record, error)
+	GetStoreUserScopeOverride(ctx context.Context, arg GetStoreUserScopeOverrideParams) (StoreUserScopeOverride, error)
+	GetStoreUserTransferOutSummary(ctx context.Context, arg GetStoreUserTransferOutSummaryParams) (GetStoreUserTransferOutSummaryRow, error)
 	GetStoreUsersByStoreID(ctx context.Context, storeID uuid.UUID) ([]GetStoreUsersByStoreIDRow, error)
+	GetStoreUsersHistory(ctx context.Context, arg GetStoreUsersHistoryParams) ([]StoreUsersHistory, error)
 	GetStores(ctx context.Context) ([]Store, error)
+	GetStoresByStoreGroupUserID(ctx context.Context, userID uuid.UUID) ([]Store, error)
+	GetStoresHistory(ctx context.Context, arg GetStoresHistoryParams) ([]StoresHistory, error)
 	GetToken(ctx context.Context, id uuid.UUID) (Token, error)
 	GetUser(ctx context.Context, id uuid.UUID) (User, error)
 	GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (User, error)
+	GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (UserIdentity, error)
+	GetUserIdentityByUserID(ctx context.Context, arg GetUserIdentityByUserIDParams) (UserIdentity, error)
+	GetUserRecentPasswords(ctx context.Context, arg GetUserRecentPasswordsParams) ([]string, error)
 	GetUserStores(ctx context.Context, userID uuid.UUID) ([]Store, error)
+	GetUsersHistory(ctx context.Context, arg GetUsersHistoryParams) ([]UsersHistory, error)
 	GetVerCodesByTypeAndCode(ctx context.Context, arg GetVerCodesByTypeAndCodeParams) ([]VerCode, error)
 	GetVerCodesByTypeAndPhoneNumber(ctx context.Context, arg GetVerCodesByTypeAndPhoneNumberParams) ([]VerCode, error)
 	GetVerCodesByTypeAndPhoneNumberAndCode(ctx context.Context, arg GetVerCodesByTypeAndPhoneNumberAndCodeParams) ([]VerCode, error)
+	IsStoreInStoreGroup(ctx context.Context, arg IsStoreInStoreGroupParams) (bool, error)
+	IsStoreInUserStoreGroups(ctx context.Context, arg IsStoreInUserStoreGroupsParams) (bool, error)
+	IsUserInStoreGroup(ctx context.Context, arg IsUserInStoreGroupParams) (bool, error)
+	JoinBrandWallet(ctx context.Context, arg JoinBrandWalletParams) (BrandWalletStore, error)
+	LeaveBrandWallet(ctx context.Context, storeID uuid.UUID) (int64, error)
+	RemoveFamilyWalletMember(ctx context.Context, arg RemoveFamilyWalletMemberParams) (int64, error)
+	RemoveStoreFromStoreGroup(ctx context.Context, arg RemoveStoreFromStoreGroupParams) error
+	RemoveUserFromStoreGroup(ctx context.Context, arg RemoveUserFromStoreGroupParams) error
+	ReviewBalanceAdjustment(ctx context.Context, arg ReviewBalanceAdjustmentParams) (int64, error)
+	RevokeApiKey(ctx context.Context, arg RevokeApiKeyParams) error
+	RevokeStoreJoinCode(ctx context.Context, arg RevokeStoreJoinCodeParams) error
+	SetDeviceDisplayTypeInfo(ctx context.Context, arg SetDeviceDisplayTypeInfoParams) error
+	SetFamilyWalletMemberSpendingCap(ctx context.Context, arg SetFamilyWalletMemberSpendingCapParams) (int64, error)
+	SetOidcAuthRequestLinked(ctx context.Context, state string) error
+	SetOidcAuthRequestVerified(ctx context.Context, arg SetOidcAuthRequestVerifiedParams) error
+	SetRoleNameAndScopes(ctx context.Context, arg SetRoleNameAndScopesParams) error
+	SetStoreDeviceHardwareID(ctx context.Context, arg SetStoreDeviceHardwareIDParams) error
+	SetStoreDeviceLayout(ctx context.Context, arg SetStoreDeviceLayoutParams) error
 	SetStoreDeviceNameAndDisplayType(ctx context.Context, arg SetStoreDeviceNameAndDisplayTypeParams) error
+	SetStoreDeviceState(ctx context.Context, arg SetStoreDeviceStateParams) error
 	SetStoreNameAndAddress(ctx context.Context, arg SetStoreNameAndAddressParams) error
 	SetStorePassword(ctx context.Context, arg SetStorePasswordParams) error
 	SetStoreState(ctx context.Context, arg SetStoreStateParams) error
 	SetStoreUserBalance(ctx context.Context, arg SetStoreUserBalanceParams) error
 	SetStoreUserRoleID(ctx context.Context, arg SetStoreUserRoleIDParams) error
+	SetStoreUserRoleIDAndState(ctx context.Context, arg SetStoreUserRoleIDAndStateParams) error
 	SetStoreUserState(ctx context.Context, arg SetStoreUserStateParams) error
 	SetUserName(ctx context.Context, arg SetUserNameParams) error
 	SetUserPasswordAndState(ctx context.Context, arg SetUserPasswordAndStateParams) error
+	SetUserPhoneNumber(ctx context.Context, arg SetUserPhoneNumberParams) error
+	SumFamilyWalletMemberSpendings(ctx context.Context, arg SumFamilyWalletMemberSpendingsParams) (int64, error)
+	TouchStoreDeviceSession(ctx context.Context, arg TouchStoreDeviceSessionParams) error
+	UpdateStoreDeviceZone(ctx context.Context, arg UpdateStoreDeviceZoneParams) (StoreDeviceZone, error)
+	UpsertBuiltInRole(ctx context.Context, arg UpsertBuiltInRoleParams) error
+	UpsertStoreCreditLimit(ctx context.Context, arg UpsertStoreCreditLimitParams) (StoreCreditLimit, error)
+	UpsertStoreDeviceMisplacement(ctx context.Context, arg UpsertStoreDeviceMisplacementParams) error
+	UpsertStoreUserCreditLimit(ctx context.Context, arg UpsertStoreUserCreditLimitParams) (StoreUserCreditLimit, error)
+	UpsertStoreUserScopeOverride(ctx context.Context, arg UpsertStoreUserScopeOverrideParams) (StoreUserScopeOverride, error)
+	UseStoreJoinCode(ctx context.Context, id uuid.UUID) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
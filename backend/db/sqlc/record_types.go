@@ -4,4 +4,8 @@ const (
 	RecordTypeCoinAcceptorCoinInserted      string = "coin_acceptor_coin_inserted"
 	RecordTypeCoinAcceptorRemoteInsertCoins string = "coin_acceptor_remote_insert_coins"
 	RecordTypeCashTopUp                     string = "cash_top_up"
+	RecordTypeBalanceAdjustment             string = "balance_adjustment"
+	RecordTypeBalanceTransfer               string = "balance_transfer"
+	RecordTypeBrandWalletCashTopUp          string = "brand_wallet_cash_top_up"
+	RecordTypeBrandWalletInsertCoins        string = "brand_wallet_insert_coins"
 )
@@ -204,3 +204,92 @@ func (q *Queries) GetStoreUserRecords(ctx context.Context, arg GetStoreUserRecor
 	}
 	return items, nil
 }
+
+const getStoreUserRecordsBetween = `-- name: GetStoreUserRecordsBetween :many
+SELECT created_by, created_user_agent, created_client_ip, type, store_id, record_id, user_id, device_id, from_online_payment, amount, point_amount, ts, created_at FROM records
+WHERE store_id = $1 AND user_id = $2::UUID
+  AND ts >= $3::BIGINT AND ts < $4::BIGINT
+ORDER BY ts
+`
+
+type GetStoreUserRecordsBetweenParams struct {
+	StoreID uuid.UUID
+	UserID  uuid.UUID
+	FromTs  int64
+	ToTs    int64
+}
+
+func (q *Queries) GetStoreUserRecordsBetween(ctx context.Context, arg GetStoreUserRecordsBetweenParams) ([]Record, error) {
+	rows, err := q.db.QueryContext(ctx, getStoreUserRecordsBetween,
+		arg.StoreID,
+		arg.UserID,
+		arg.FromTs,
+		arg.ToTs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Record{}
+	for rows.Next() {
+		var i Record
+		if err := rows.Scan(
+			&i.CreatedBy,
+			&i.CreatedUserAgent,
+			&i.CreatedClientIp,
+			&i.Type,
+			&i.StoreID,
+			&i.RecordID,
+			&i.UserID,
+			&i.DeviceID,
+			&i.FromOnlinePayment,
+			&i.Amount,
+			&i.PointAmount,
+			&i.Ts,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStoreUserTransferOutSummary = `-- name: GetStoreUserTransferOutSummary :one
+SELECT COUNT(*) AS count, COALESCE(SUM(-amount), 0)::BIGINT AS amount
+FROM records
+WHERE store_id = $1 AND user_id = $2 AND type = $3 AND amount < 0 AND ts >= $4
+`
+
+type GetStoreUserTransferOutSummaryParams struct {
+	StoreID uuid.UUID
+	UserID  uuid.NullUUID
+	Type    string
+	Ts      int64
+}
+
+type GetStoreUserTransferOutSummaryRow struct {
+	Count  int64
+	Amount int64
+}
+
+func (q *Queries) GetStoreUserTransferOutSummary(ctx context.Context, arg GetStoreUserTransferOutSummaryParams) (GetStoreUserTransferOutSummaryRow, error) {
+	row := q.db.QueryRowContext(ctx, getStoreUserTransferOutSummary,
+		arg.StoreID,
+		arg.UserID,
+		arg.Type,
+		arg.Ts,
+	)
+	var i GetStoreUserTransferOutSummaryRow
+	err := row.Scan(
+		&i.Count,
+		&i.Amount,
+	)
+	return i, err
+}
@@ -3,11 +3,19 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
 )
 
+var ErrStoreJoinCodeUnavailable = errors.New("store join code is revoked or used up")
+var ErrBalanceAdjustmentReviewed = errors.New("balance adjustment has been reviewed")
+var ErrStoreUserBalanceNotEnough = errors.New("store user balance is not enough")
+var ErrBrandWalletBalanceNotEnough = errors.New("brand wallet balance is not enough")
+var ErrStoreDeviceRegistered = errors.New("store device is registered in target store")
+
 type IStore interface {
 	Querier
 
@@ -16,6 +24,8 @@ type IStore interface {
 	CreateUserWithLog(ctx context.Context, arg CreateUserWithLogParams) (User, error)
 	SetUserPasswordAndStateWithLog(ctx context.Context, arg SetUserPasswordAndStateWithLogParams) error
 	SetUserNameWithLog(ctx context.Context, arg SetUserNameWithLogParams) error
+	SetUserPhoneNumberWithLog(ctx context.Context, arg SetUserPhoneNumberWithLogParams) error
+	LinkUserIdentityWithLog(ctx context.Context, arg LinkUserIdentityWithLogParams) (UserIdentity, error)
 
 	CreateStoreWithLog(ctx context.Context, arg CreateStoreWithLogParams) (Store, error)
 	SetStoreStateWithLog(ctx context.Context, arg SetStoreStateWithLogParams) error
@@ -26,9 +36,35 @@ type IStore interface {
 	SetStoreUserStateWithLog(ctx context.Context, arg SetStoreUserStateWithLogParams) error
 	SetStoreUserRoleIDWithLog(ctx context.Context, arg SetStoreUserRoleIDWithLogParams) error
 	SetStoreUserBalanceWithLog(ctx context.Context, arg SetStoreUserBalanceWithLogParams) error
+	SetStoreUserBalanceWithFamilyWalletSpendingWithLog(ctx context.Context, arg SetStoreUserBalanceWithFamilyWalletSpendingWithLogParams) error
+	SetStoreUserRoleIDAndStateWithLog(ctx context.Context, arg SetStoreUserRoleIDAndStateWithLogParams) error
+	CreateStoreUserByJoinCodeWithLog(ctx context.Context, arg CreateStoreUserByJoinCodeWithLogParams) (StoreUser, error)
+	SetStoreUserScopeOverrideWithLog(ctx context.Context, arg SetStoreUserScopeOverrideWithLogParams) (StoreUserScopeOverride, error)
+	GetStoreUserWalletAt(ctx context.Context, arg GetStoreUserWalletAtParams) (StoreUserWalletAt, error)
+	CreateBalanceAdjustmentWithLog(ctx context.Context, arg CreateBalanceAdjustmentWithLogParams) (BalanceAdjustment, error)
+	ReviewBalanceAdjustmentWithLog(ctx context.Context, arg ReviewBalanceAdjustmentWithLogParams) error
+	SetStoreCreditLimitWithLog(ctx context.Context, arg SetStoreCreditLimitWithLogParams) (StoreCreditLimit, error)
+	SetStoreUserCreditLimitWithLog(ctx context.Context, arg SetStoreUserCreditLimitWithLogParams) (StoreUserCreditLimit, error)
+	TransferStoreUserBalanceWithLog(ctx context.Context, arg TransferStoreUserBalanceWithLogParams) error
+	TopUpBrandWallet(ctx context.Context, arg TopUpBrandWalletParams) (BrandWalletLot, error)
+	ConsumeBrandWallet(ctx context.Context, arg ConsumeBrandWalletParams) error
+	RefundBrandWalletConsumption(ctx context.Context, consumptionID uuid.UUID) error
 
 	CreateStoreDeviceWithLog(ctx context.Context, arg CreateStoreDeviceWithLogParams) (StoreDevice, error)
 	SetStoreDeviceNameAndDisplayTypeWithLog(ctx context.Context, arg SetStoreDeviceNameAndDisplayTypeWithLogParams) error
+	SetStoreDeviceStateWithLog(ctx context.Context, arg SetStoreDeviceStateWithLogParams) error
+	ReplaceStoreDeviceHardwareWithLog(ctx context.Context, arg ReplaceStoreDeviceHardwareWithLogParams) error
+	TransferStoreDeviceWithLog(ctx context.Context, arg TransferStoreDeviceWithLogParams) (StoreDevice, error)
+	SetStoreDeviceLayoutWithLog(ctx context.Context, arg SetStoreDeviceLayoutWithLogParams) error
+	DeleteStoreDeviceZoneWithLog(ctx context.Context, arg DeleteStoreDeviceZoneWithLogParams) error
+
+	CreateRoleWithLog(ctx context.Context, arg CreateRoleWithLogParams) (Role, error)
+	SetRoleNameAndScopesWithLog(ctx context.Context, arg SetRoleNameAndScopesWithLogParams) error
+	DeleteRoleWithLog(ctx context.Context, arg DeleteRoleWithLogParams) error
+
+	CreateDeviceDisplayTypeWithLog(ctx context.Context, arg CreateDeviceDisplayTypeWithLogParams) (DeviceDisplayType, error)
+	SetDeviceDisplayTypeInfoWithLog(ctx context.Context, arg SetDeviceDisplayTypeInfoWithLogParams) error
+	DeleteDeviceDisplayTypeWithLog(ctx context.Context, arg DeleteDeviceDisplayTypeWithLogParams) error
 }
 
 type SQLStore struct {
@@ -203,6 +239,92 @@ func (store *SQLStore) SetUserNameWithLog(ctx context.Context, arg SetUserNameWi
 	return oerr
 }
 
+type SetUserPhoneNumberWithLogParams struct {
+	ChangedAt        int64
+	ChangeType       string
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	ID               uuid.UUID
+	PhoneNumber      string
+}
+
+// SetUserPhoneNumberWithLog 更新手機號碼後，同時 block 該使用者所有的 token，並記錄撤銷時間讓之前簽發的 token 失效
+func (store *SQLStore) SetUserPhoneNumberWithLog(ctx context.Context, arg SetUserPhoneNumberWithLogParams) error {
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		err := q.SetUserPhoneNumber(ctx, SetUserPhoneNumberParams{
+			ID:              arg.ID,
+			PhoneNumber:     arg.PhoneNumber,
+			TokensRevokedAt: sql.NullInt64{Valid: true, Int64: arg.ChangedAt},
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.CreateUserHistory(ctx, CreateUserHistoryParams{
+			ID:               arg.ID,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      arg.ChangeType,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+		if err := q.BlockUserTokens(ctx, arg.ID); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	return oerr
+}
+
+type LinkUserIdentityWithLogParams struct {
+	ChangedAt        int64
+	ChangeType       string
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	UserID           uuid.UUID
+	Provider         string
+	Subject          string
+	State            string
+}
+
+// LinkUserIdentityWithLog 綁定外部身分，並將對應的 oidc auth request 標記為已綁定
+func (store *SQLStore) LinkUserIdentityWithLog(ctx context.Context, arg LinkUserIdentityWithLogParams) (UserIdentity, error) {
+	result := UserIdentity{}
+
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result, err = q.CreateUserIdentity(ctx, CreateUserIdentityParams{
+			Provider: arg.Provider,
+			Subject:  arg.Subject,
+			UserID:   arg.UserID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.SetOidcAuthRequestLinked(ctx, arg.State); err != nil {
+			return err
+		}
+		if _, err := q.CreateUserHistory(ctx, CreateUserHistoryParams{
+			ID:               arg.UserID,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      arg.ChangeType,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	return result, oerr
+}
+
 type CreateStoreWithLogParams struct {
 	ChangedAt        int64
 	ChangeType       string
@@ -213,6 +335,7 @@ type CreateStoreWithLogParams struct {
 	Name             string
 	Address          string
 	State            string
+	StoreGroupID     uuid.NullUUID
 }
 
 func (store *SQLStore) CreateStoreWithLog(ctx context.Context, arg CreateStoreWithLogParams) (Store, error) {
@@ -240,6 +363,15 @@ func (store *SQLStore) CreateStoreWithLog(ctx context.Context, arg CreateStoreWi
 		}); err != nil {
 			return err
 		}
+		if arg.StoreGroupID.Valid {
+			if err := q.AddStoreToStoreGroup(ctx, AddStoreToStoreGroupParams{
+				StoreGroupID: arg.StoreGroupID.UUID,
+				StoreID:      arg.ID,
+				CreatedBy:    arg.ChangedBy.UUID,
+			}); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 
@@ -467,6 +599,28 @@ func (store *SQLStore) SetStoreUserRoleIDWithLog(ctx context.Context, arg SetSto
 		}); err != nil {
 			return err
 		}
+		// 變更 role 時清除額外授予或禁止的 scopes，避免沿用到新的 role
+		rows, err := q.ClearStoreUserScopeOverride(ctx, ClearStoreUserScopeOverrideParams{
+			StoreID: arg.StoreID,
+			UserID:  arg.UserID,
+		})
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return nil
+		}
+		if _, err := q.CreateStoreUserScopeOverrideHistory(ctx, CreateStoreUserScopeOverrideHistoryParams{
+			StoreID:          arg.StoreID,
+			UserID:           arg.UserID,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      arg.ChangeType,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
 		return nil
 	})
 
@@ -517,40 +671,125 @@ func (store *SQLStore) SetStoreUserBalanceWithLog(ctx context.Context, arg SetSt
 	return oerr
 }
 
-type CreateStoreDeviceWithLogParams struct {
+type SetStoreUserBalanceWithFamilyWalletSpendingWithLogParams struct {
+	SetStoreUserBalanceWithLogParams
+	Spending CreateFamilyWalletSpendingParams
+}
+
+// SetStoreUserBalanceWithFamilyWalletSpendingWithLog 家庭錢包成員扣款時，owner 的 earmark 與成員的花費在同一個 transaction 內寫入，
+// 花費上限的檢查才不會被同時進行的扣款繞過
+func (store *SQLStore) SetStoreUserBalanceWithFamilyWalletSpendingWithLog(ctx context.Context, arg SetStoreUserBalanceWithFamilyWalletSpendingWithLogParams) error {
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		err := q.SetStoreUserBalance(ctx, SetStoreUserBalanceParams{
+			StoreID:        arg.StoreID,
+			UserID:         arg.UserID,
+			Balance:        arg.Balance,
+			Points:         arg.Points,
+			BalanceEarmark: arg.BalanceEarmark,
+			PointsEarmark:  arg.PointsEarmark,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.CreateStoreUserHistory(ctx, CreateStoreUserHistoryParams{
+			StoreID:          arg.StoreID,
+			UserID:           arg.UserID,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      arg.ChangeType,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+		if _, err := q.CreateFamilyWalletSpending(ctx, arg.Spending); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	return oerr
+}
+
+type SetStoreUserRoleIDAndStateWithLogParams struct {
 	ChangedAt        int64
 	ChangeType       string
 	ChangedBy        uuid.NullUUID
 	ChangedUserAgent sql.NullString
 	ChangedClientIp  sql.NullString
 	StoreID          uuid.UUID
-	DeviceID         string
-	Name             string
-	RealType         string
-	DisplayType      string
+	UserID           uuid.UUID
+	RoleID           int16
 	State            string
 }
 
-func (store *SQLStore) CreateStoreDeviceWithLog(ctx context.Context, arg CreateStoreDeviceWithLogParams) (StoreDevice, error) {
-	result := StoreDevice{}
+func (store *SQLStore) SetStoreUserRoleIDAndStateWithLog(ctx context.Context, arg SetStoreUserRoleIDAndStateWithLogParams) error {
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		err := q.SetStoreUserRoleIDAndState(ctx, SetStoreUserRoleIDAndStateParams{
+			StoreID: arg.StoreID,
+			UserID:  arg.UserID,
+			RoleID:  arg.RoleID,
+			State:   arg.State,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.CreateStoreUserHistory(ctx, CreateStoreUserHistoryParams{
+			StoreID:          arg.StoreID,
+			UserID:           arg.UserID,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      arg.ChangeType,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	return oerr
+}
+
+type CreateStoreUserByJoinCodeWithLogParams struct {
+	ChangedAt        int64
+	ChangeType       string
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	JoinCodeID       uuid.UUID
+	StoreID          uuid.UUID
+	UserID           uuid.UUID
+	RoleID           int16
+	State            string
+}
+
+// CreateStoreUserByJoinCodeWithLog 使用次數的累加與 store user 的建立在同一個 transaction，
+// join code 已撤銷或用完時回傳 ErrStoreJoinCodeUnavailable
+func (store *SQLStore) CreateStoreUserByJoinCodeWithLog(ctx context.Context, arg CreateStoreUserByJoinCodeWithLogParams) (StoreUser, error) {
+	result := StoreUser{}
 
 	oerr := store.execTx(ctx, func(q *Queries) error {
-		var err error
+		n, err := q.UseStoreJoinCode(ctx, arg.JoinCodeID)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrStoreJoinCodeUnavailable
+		}
 
-		result, err = q.CreateStoreDevice(ctx, CreateStoreDeviceParams{
-			StoreID:     arg.StoreID,
-			DeviceID:    arg.DeviceID,
-			Name:        arg.Name,
-			RealType:    arg.RealType,
-			DisplayType: arg.DisplayType,
-			State:       arg.State,
+		result, err = q.CreateStoreUser(ctx, CreateStoreUserParams{
+			StoreID: arg.StoreID,
+			UserID:  arg.UserID,
+			RoleID:  arg.RoleID,
+			State:   arg.State,
 		})
 		if err != nil {
 			return err
 		}
-		if _, err := q.CreateStoreDeviceHistory(ctx, CreateStoreDeviceHistoryParams{
+		if _, err := q.CreateStoreUserHistory(ctx, CreateStoreUserHistoryParams{
 			StoreID:          arg.StoreID,
-			DeviceID:         arg.DeviceID,
+			UserID:           arg.UserID,
 			ChangedAt:        arg.ChangedAt,
 			ChangedType:      arg.ChangeType,
 			ChangedBy:        arg.ChangedBy,
@@ -565,32 +804,36 @@ func (store *SQLStore) CreateStoreDeviceWithLog(ctx context.Context, arg CreateS
 	return result, oerr
 }
 
-type SetStoreDeviceNameAndDisplayTypeWithLogParams struct {
+type SetStoreUserScopeOverrideWithLogParams struct {
 	ChangedAt        int64
 	ChangeType       string
 	ChangedBy        uuid.NullUUID
 	ChangedUserAgent sql.NullString
 	ChangedClientIp  sql.NullString
 	StoreID          uuid.UUID
-	DeviceID         string
-	Name             string
-	DisplayType      string
+	UserID           uuid.UUID
+	GrantedScopes    []string
+	DeniedScopes     []string
 }
 
-func (store *SQLStore) SetStoreDeviceNameAndDisplayTypeWithLog(ctx context.Context, arg SetStoreDeviceNameAndDisplayTypeWithLogParams) error {
+func (store *SQLStore) SetStoreUserScopeOverrideWithLog(ctx context.Context, arg SetStoreUserScopeOverrideWithLogParams) (StoreUserScopeOverride, error) {
+	result := StoreUserScopeOverride{}
+
 	oerr := store.execTx(ctx, func(q *Queries) error {
-		err := q.SetStoreDeviceNameAndDisplayType(ctx, SetStoreDeviceNameAndDisplayTypeParams{
-			StoreID:     arg.StoreID,
-			DeviceID:    arg.DeviceID,
-			Name:        arg.Name,
-			DisplayType: arg.DisplayType,
+		var err error
+
+		result, err = q.UpsertStoreUserScopeOverride(ctx, UpsertStoreUserScopeOverrideParams{
+			StoreID:       arg.StoreID,
+			UserID:        arg.UserID,
+			GrantedScopes: arg.GrantedScopes,
+			DeniedScopes:  arg.DeniedScopes,
 		})
 		if err != nil {
 			return err
 		}
-		if _, err := q.CreateStoreDeviceHistory(ctx, CreateStoreDeviceHistoryParams{
+		if _, err := q.CreateStoreUserScopeOverrideHistory(ctx, CreateStoreUserScopeOverrideHistoryParams{
 			StoreID:          arg.StoreID,
-			DeviceID:         arg.DeviceID,
+			UserID:           arg.UserID,
 			ChangedAt:        arg.ChangedAt,
 			ChangedType:      arg.ChangeType,
 			ChangedBy:        arg.ChangedBy,
@@ -602,5 +845,1123 @@ func (store *SQLStore) SetStoreDeviceNameAndDisplayTypeWithLog(ctx context.Conte
 		return nil
 	})
 
+	return result, oerr
+}
+
+type GetStoreUserWalletAtParams struct {
+	StoreID uuid.UUID
+	UserID  uuid.UUID
+	Ts      int64
+	FromTs  int64
+	ToTs    int64
+}
+
+type StoreUserWalletAt struct {
+	// Snapshot Ts 當下的錢包狀態
+	Snapshot StoreUsersHistory
+	// Histories [FromTs, ToTs) 之間的變動，依時間排序
+	Histories []StoreUsersHistory
+	// Records [FromTs, ToTs) 之間的交易紀錄，依時間排序
+	Records []Record
+}
+
+// GetStoreUserWalletAt 由 store_users_history 重建 Ts 當下的錢包，Ts 時尚未成為 store user 時回傳 sql.ErrNoRows
+func (store *SQLStore) GetStoreUserWalletAt(ctx context.Context, arg GetStoreUserWalletAtParams) (StoreUserWalletAt, error) {
+	result := StoreUserWalletAt{}
+
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Snapshot, err = q.GetStoreUserHistoryAt(ctx, GetStoreUserHistoryAtParams{
+			StoreID: arg.StoreID,
+			UserID:  arg.UserID,
+			Ts:      arg.Ts,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Histories, err = q.GetStoreUserHistoryBetween(ctx, GetStoreUserHistoryBetweenParams{
+			StoreID: arg.StoreID,
+			UserID:  arg.UserID,
+			FromTs:  arg.FromTs,
+			ToTs:    arg.ToTs,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Records, err = q.GetStoreUserRecordsBetween(ctx, GetStoreUserRecordsBetweenParams{
+			StoreID: arg.StoreID,
+			UserID:  arg.UserID,
+			FromTs:  arg.FromTs,
+			ToTs:    arg.ToTs,
+		})
+		return err
+	})
+
+	return result, oerr
+}
+
+type applyBalanceAdjustmentParams struct {
+	ChangedAt        int64
+	ChangeType       string
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	ID               uuid.UUID
+	StoreID          uuid.UUID
+	UserID           uuid.UUID
+	Amount           int32
+	PointAmount      int32
+	Balance          int32
+	Points           int32
+	BalanceEarmark   int32
+	PointsEarmark    int32
+}
+
+// applyBalanceAdjustment 更新 store user 的餘額並寫入 history 與 record，須在 transaction 中呼叫
+func applyBalanceAdjustment(ctx context.Context, q *Queries, arg applyBalanceAdjustmentParams) error {
+	if err := q.SetStoreUserBalance(ctx, SetStoreUserBalanceParams{
+		StoreID:        arg.StoreID,
+		UserID:         arg.UserID,
+		Balance:        arg.Balance,
+		Points:         arg.Points,
+		BalanceEarmark: arg.BalanceEarmark,
+		PointsEarmark:  arg.PointsEarmark,
+	}); err != nil {
+		return err
+	}
+	if _, err := q.CreateStoreUserHistory(ctx, CreateStoreUserHistoryParams{
+		StoreID:          arg.StoreID,
+		UserID:           arg.UserID,
+		ChangedAt:        arg.ChangedAt,
+		ChangedType:      arg.ChangeType,
+		ChangedBy:        arg.ChangedBy,
+		ChangedUserAgent: arg.ChangedUserAgent,
+		ChangedClientIp:  arg.ChangedClientIp,
+	}); err != nil {
+		return err
+	}
+	if _, err := q.CreateRecord(ctx, CreateRecordParams{
+		CreatedBy:        arg.ChangedBy,
+		CreatedUserAgent: arg.ChangedUserAgent,
+		CreatedClientIp:  arg.ChangedClientIp,
+		Type:             RecordTypeBalanceAdjustment,
+		StoreID:          arg.StoreID,
+		RecordID:         sql.NullString{Valid: true, String: arg.ID.String()},
+		UserID:           uuid.NullUUID{Valid: true, UUID: arg.UserID},
+		Amount:           arg.Amount,
+		PointAmount:      sql.NullInt32{Valid: true, Int32: arg.PointAmount},
+		Ts:               arg.ChangedAt,
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+type CreateBalanceAdjustmentWithLogParams struct {
+	ChangedAt        int64
+	ChangeType       string
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	ID               uuid.UUID
+	StoreID          uuid.UUID
+	UserID           uuid.UUID
+	Amount           int32
+	PointAmount      int32
+	ReasonCode       string
+	Note             string
+	State            string
+	RequestedBy      uuid.UUID
+	// Apply 為 true 時直接套用至 store user，Balance、Points 等為套用後的值
+	Apply          bool
+	Balance        int32
+	Points         int32
+	BalanceEarmark int32
+	PointsEarmark  int32
+}
+
+func (store *SQLStore) CreateBalanceAdjustmentWithLog(ctx context.Context, arg CreateBalanceAdjustmentWithLogParams) (BalanceAdjustment, error) {
+	result := BalanceAdjustment{}
+
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result, err = q.CreateBalanceAdjustment(ctx, CreateBalanceAdjustmentParams{
+			ID:          arg.ID,
+			StoreID:     arg.StoreID,
+			UserID:      arg.UserID,
+			Amount:      arg.Amount,
+			PointAmount: arg.PointAmount,
+			ReasonCode:  arg.ReasonCode,
+			Note:        arg.Note,
+			State:       arg.State,
+			RequestedBy: arg.RequestedBy,
+		})
+		if err != nil {
+			return err
+		}
+		if !arg.Apply {
+			return nil
+		}
+		return applyBalanceAdjustment(ctx, q, applyBalanceAdjustmentParams{
+			ChangedAt:        arg.ChangedAt,
+			ChangeType:       arg.ChangeType,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+			ID:               arg.ID,
+			StoreID:          arg.StoreID,
+			UserID:           arg.UserID,
+			Amount:           arg.Amount,
+			PointAmount:      arg.PointAmount,
+			Balance:          arg.Balance,
+			Points:           arg.Points,
+			BalanceEarmark:   arg.BalanceEarmark,
+			PointsEarmark:    arg.PointsEarmark,
+		})
+	})
+
+	return result, oerr
+}
+
+type ReviewBalanceAdjustmentWithLogParams struct {
+	ChangedAt        int64
+	ChangeType       string
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	ID               uuid.UUID
+	StoreID          uuid.UUID
+	UserID           uuid.UUID
+	Amount           int32
+	PointAmount      int32
+	FromState        string
+	State            string
+	// Apply 為 true 時套用至 store user，Balance、Points 等為套用後的值
+	Apply          bool
+	Balance        int32
+	Points         int32
+	BalanceEarmark int32
+	PointsEarmark  int32
+}
+
+// ReviewBalanceAdjustmentWithLog 審核 balance adjustment，已被其他人審核時回傳 ErrBalanceAdjustmentReviewed
+func (store *SQLStore) ReviewBalanceAdjustmentWithLog(ctx context.Context, arg ReviewBalanceAdjustmentWithLogParams) error {
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		n, err := q.ReviewBalanceAdjustment(ctx, ReviewBalanceAdjustmentParams{
+			ID:         arg.ID,
+			State:      arg.State,
+			ReviewedBy: arg.ChangedBy,
+			ReviewedAt: sql.NullInt64{Valid: true, Int64: arg.ChangedAt},
+			FromState:  arg.FromState,
+		})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrBalanceAdjustmentReviewed
+		}
+		if !arg.Apply {
+			return nil
+		}
+		return applyBalanceAdjustment(ctx, q, applyBalanceAdjustmentParams{
+			ChangedAt:        arg.ChangedAt,
+			ChangeType:       arg.ChangeType,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+			ID:               arg.ID,
+			StoreID:          arg.StoreID,
+			UserID:           arg.UserID,
+			Amount:           arg.Amount,
+			PointAmount:      arg.PointAmount,
+			Balance:          arg.Balance,
+			Points:           arg.Points,
+			BalanceEarmark:   arg.BalanceEarmark,
+			PointsEarmark:    arg.PointsEarmark,
+		})
+	})
+
+	return oerr
+}
+
+type SetStoreCreditLimitWithLogParams struct {
+	ChangedAt          int64
+	ChangeType         string
+	ChangedBy          uuid.NullUUID
+	ChangedUserAgent   sql.NullString
+	ChangedClientIp    sql.NullString
+	StoreID            uuid.UUID
+	DefaultCreditLimit int32
+	UpdatedBy          uuid.UUID
+}
+
+func (store *SQLStore) SetStoreCreditLimitWithLog(ctx context.Context, arg SetStoreCreditLimitWithLogParams) (StoreCreditLimit, error) {
+	result := StoreCreditLimit{}
+
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result, err = q.UpsertStoreCreditLimit(ctx, UpsertStoreCreditLimitParams{
+			StoreID:            arg.StoreID,
+			DefaultCreditLimit: arg.DefaultCreditLimit,
+			UpdatedBy:          arg.UpdatedBy,
+			UpdatedAt:          arg.ChangedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.CreateStoreCreditLimitHistory(ctx, CreateStoreCreditLimitHistoryParams{
+			StoreID:          arg.StoreID,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      arg.ChangeType,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	return result, oerr
+}
+
+type SetStoreUserCreditLimitWithLogParams struct {
+	ChangedAt        int64
+	ChangeType       string
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	StoreID          uuid.UUID
+	UserID           uuid.UUID
+	CreditLimit      sql.NullInt32
+}
+
+func (store *SQLStore) SetStoreUserCreditLimitWithLog(ctx context.Context, arg SetStoreUserCreditLimitWithLogParams) (StoreUserCreditLimit, error) {
+	result := StoreUserCreditLimit{}
+
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result, err = q.UpsertStoreUserCreditLimit(ctx, UpsertStoreUserCreditLimitParams{
+			StoreID:     arg.StoreID,
+			UserID:      arg.UserID,
+			CreditLimit: arg.CreditLimit,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.CreateStoreUserCreditLimitHistory(ctx, CreateStoreUserCreditLimitHistoryParams{
+			StoreID:          arg.StoreID,
+			UserID:           arg.UserID,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      arg.ChangeType,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	return result, oerr
+}
+
+type TransferStoreUserBalanceWithLogParams struct {
+	ChangedAt        int64
+	ChangeType       string
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	ID               uuid.UUID
+	StoreID          uuid.UUID
+	FromUserID       uuid.UUID
+	ToUserID         uuid.UUID
+	Amount           int32
+}
+
+// TransferStoreUserBalanceWithLog 只轉移 balance 不含 points，轉出與轉入各寫一筆 record，record_id 分別以 -out、-in 結尾
+func (store *SQLStore) TransferStoreUserBalanceWithLog(ctx context.Context, arg TransferStoreUserBalanceWithLogParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		from, err := q.GetStoreUser(ctx, GetStoreUserParams{
+			StoreID: arg.StoreID,
+			UserID:  arg.FromUserID,
+		})
+		if err != nil {
+			return err
+		}
+		if from.Balance < arg.Amount {
+			return ErrStoreUserBalanceNotEnough
+		}
+		to, err := q.GetStoreUser(ctx, GetStoreUserParams{
+			StoreID: arg.StoreID,
+			UserID:  arg.ToUserID,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, v := range []struct {
+			storeUser StoreUser
+			amount    int32
+			suffix    string
+		}{
+			{from, -arg.Amount, "-out"},
+			{to, arg.Amount, "-in"},
+		} {
+			if err := q.SetStoreUserBalance(ctx, SetStoreUserBalanceParams{
+				StoreID:        arg.StoreID,
+				UserID:         v.storeUser.UserID,
+				Balance:        v.storeUser.Balance + v.amount,
+				Points:         v.storeUser.Points,
+				BalanceEarmark: v.storeUser.BalanceEarmark,
+				PointsEarmark:  v.storeUser.PointsEarmark,
+			}); err != nil {
+				return err
+			}
+			if _, err := q.CreateStoreUserHistory(ctx, CreateStoreUserHistoryParams{
+				StoreID:          arg.StoreID,
+				UserID:           v.storeUser.UserID,
+				ChangedAt:        arg.ChangedAt,
+				ChangedType:      arg.ChangeType,
+				ChangedBy:        arg.ChangedBy,
+				ChangedUserAgent: arg.ChangedUserAgent,
+				ChangedClientIp:  arg.ChangedClientIp,
+			}); err != nil {
+				return err
+			}
+			if _, err := q.CreateRecord(ctx, CreateRecordParams{
+				CreatedBy:        arg.ChangedBy,
+				CreatedUserAgent: arg.ChangedUserAgent,
+				CreatedClientIp:  arg.ChangedClientIp,
+				Type:             RecordTypeBalanceTransfer,
+				StoreID:          arg.StoreID,
+				RecordID:         sql.NullString{Valid: true, String: arg.ID.String() + v.suffix},
+				UserID:           uuid.NullUUID{Valid: true, UUID: v.storeUser.UserID},
+				Amount:           v.amount,
+				PointAmount:      sql.NullInt32{Valid: true, Int32: 0},
+				Ts:               arg.ChangedAt,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+type TopUpBrandWalletParams struct {
+	ID               uuid.UUID
+	StoreGroupID     uuid.UUID
+	UserID           uuid.UUID
+	StoreID          uuid.UUID
+	Amount           int32
+	CreatedBy        uuid.UUID
+	CreatedUserAgent sql.NullString
+	CreatedClientIp  sql.NullString
+	Ts               int64
+}
+
+// TopUpBrandWallet 每次儲值建立一筆 lot 並記錄儲值的商店，用於跨店拆帳
+func (store *SQLStore) TopUpBrandWallet(ctx context.Context, arg TopUpBrandWalletParams) (BrandWalletLot, error) {
+	result := BrandWalletLot{}
+
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result, err = q.CreateBrandWalletLot(ctx, CreateBrandWalletLotParams{
+			ID:           arg.ID,
+			StoreGroupID: arg.StoreGroupID,
+			UserID:       arg.UserID,
+			StoreID:      arg.StoreID,
+			Amount:       arg.Amount,
+			CreatedBy:    arg.CreatedBy,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.CreateRecord(ctx, CreateRecordParams{
+			CreatedBy:        uuid.NullUUID{Valid: true, UUID: arg.CreatedBy},
+			CreatedUserAgent: arg.CreatedUserAgent,
+			CreatedClientIp:  arg.CreatedClientIp,
+			Type:             RecordTypeBrandWalletCashTopUp,
+			StoreID:          arg.StoreID,
+			RecordID:         sql.NullString{Valid: true, String: arg.ID.String()},
+			UserID:           uuid.NullUUID{Valid: true, UUID: arg.UserID},
+			Amount:           arg.Amount,
+			PointAmount:      sql.NullInt32{Valid: true, Int32: 0},
+			Ts:               arg.Ts,
+		}); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	return result, oerr
+}
+
+type ConsumeBrandWalletParams struct {
+	ConsumptionID uuid.UUID
+	StoreGroupID  uuid.UUID
+	UserID        uuid.UUID
+	StoreID       uuid.UUID
+	Amount        int32
+	Ts            int64
+}
+
+// ConsumeBrandWallet 依儲值先後扣除 lot，並記錄每筆 lot 的儲值商店與消費商店
+func (store *SQLStore) ConsumeBrandWallet(ctx context.Context, arg ConsumeBrandWalletParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		lots, err := q.GetBrandWalletLotsForUpdate(ctx, GetBrandWalletLotsForUpdateParams{
+			StoreGroupID: arg.StoreGroupID,
+			UserID:       arg.UserID,
+		})
+		if err != nil {
+			return err
+		}
+
+		amount := arg.Amount
+		for _, lot := range lots {
+			if amount == 0 {
+				break
+			}
+			consumed := lot.Remaining
+			if consumed > amount {
+				consumed = amount
+			}
+			if err := q.AddBrandWalletLotRemaining(ctx, AddBrandWalletLotRemainingParams{
+				ID:        lot.ID,
+				Remaining: -consumed,
+			}); err != nil {
+				return err
+			}
+			if _, err := q.CreateBrandWalletConsumption(ctx, CreateBrandWalletConsumptionParams{
+				ConsumptionID: arg.ConsumptionID,
+				LotID:         lot.ID,
+				StoreGroupID:  arg.StoreGroupID,
+				UserID:        arg.UserID,
+				StoreID:       arg.StoreID,
+				FundedStoreID: lot.StoreID,
+				Amount:        consumed,
+				Ts:            arg.Ts,
+			}); err != nil {
+				return err
+			}
+			amount -= consumed
+		}
+
+		if amount > 0 {
+			return ErrBrandWalletBalanceNotEnough
+		}
+		return nil
+	})
+}
+
+// RefundBrandWalletConsumption 將消費退回原本的 lot，用於投幣失敗時
+func (store *SQLStore) RefundBrandWalletConsumption(ctx context.Context, consumptionID uuid.UUID) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		consumptions, err := q.GetBrandWalletConsumptions(ctx, consumptionID)
+		if err != nil {
+			return err
+		}
+		for _, consumption := range consumptions {
+			if err := q.AddBrandWalletLotRemaining(ctx, AddBrandWalletLotRemainingParams{
+				ID:        consumption.LotID,
+				Remaining: consumption.Amount,
+			}); err != nil {
+				return err
+			}
+		}
+		return q.DeleteBrandWalletConsumptions(ctx, consumptionID)
+	})
+}
+
+type CreateStoreDeviceWithLogParams struct {
+	ChangedAt        int64
+	ChangeType       string
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	StoreID          uuid.UUID
+	DeviceID         string
+	Name             string
+	RealType         string
+	DisplayType      string
+	State            string
+}
+
+func (store *SQLStore) CreateStoreDeviceWithLog(ctx context.Context, arg CreateStoreDeviceWithLogParams) (StoreDevice, error) {
+	result := StoreDevice{}
+
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result, err = q.CreateStoreDevice(ctx, CreateStoreDeviceParams{
+			StoreID:     arg.StoreID,
+			DeviceID:    arg.DeviceID,
+			Name:        arg.Name,
+			RealType:    arg.RealType,
+			DisplayType: arg.DisplayType,
+			State:       arg.State,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.CreateStoreDeviceHistory(ctx, CreateStoreDeviceHistoryParams{
+			StoreID:          arg.StoreID,
+			DeviceID:         arg.DeviceID,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      arg.ChangeType,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	return result, oerr
+}
+
+type SetStoreDeviceNameAndDisplayTypeWithLogParams struct {
+	ChangedAt        int64
+	ChangeType       string
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	StoreID          uuid.UUID
+	DeviceID         string
+	Name             string
+	DisplayType      string
+}
+
+func (store *SQLStore) SetStoreDeviceNameAndDisplayTypeWithLog(ctx context.Context, arg SetStoreDeviceNameAndDisplayTypeWithLogParams) error {
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		err := q.SetStoreDeviceNameAndDisplayType(ctx, SetStoreDeviceNameAndDisplayTypeParams{
+			StoreID:     arg.StoreID,
+			DeviceID:    arg.DeviceID,
+			Name:        arg.Name,
+			DisplayType: arg.DisplayType,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.CreateStoreDeviceHistory(ctx, CreateStoreDeviceHistoryParams{
+			StoreID:          arg.StoreID,
+			DeviceID:         arg.DeviceID,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      arg.ChangeType,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	return oerr
+}
+
+type SetStoreDeviceStateWithLogParams struct {
+	ChangedAt        int64
+	ChangeType       string
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	StoreID          uuid.UUID
+	DeviceID         string
+	State            string
+}
+
+func (store *SQLStore) SetStoreDeviceStateWithLog(ctx context.Context, arg SetStoreDeviceStateWithLogParams) error {
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		err := q.SetStoreDeviceState(ctx, SetStoreDeviceStateParams{
+			StoreID:  arg.StoreID,
+			DeviceID: arg.DeviceID,
+			State:    arg.State,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.CreateStoreDeviceHistory(ctx, CreateStoreDeviceHistoryParams{
+			StoreID:          arg.StoreID,
+			DeviceID:         arg.DeviceID,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      arg.ChangeType,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	return oerr
+}
+
+type ReplaceStoreDeviceHardwareWithLogParams struct {
+	ChangedAt        int64
+	ChangedBy        uuid.UUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	StoreID          uuid.UUID
+	DeviceID         string
+	OldHardwareID    string
+	NewHardwareID    string
+}
+
+// ReplaceStoreDeviceHardwareWithLog 將新的硬體 ID 對應到既有的 store device，新硬體註冊時建立的待核准機台會被合併刪除，舊的硬體 ID 則被停用
+func (store *SQLStore) ReplaceStoreDeviceHardwareWithLog(ctx context.Context, arg ReplaceStoreDeviceHardwareWithLogParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		pendingDevice, err := q.GetStoreDeviceByHardwareID(ctx, GetStoreDeviceByHardwareIDParams{
+			StoreID:    arg.StoreID,
+			HardwareID: arg.NewHardwareID,
+		})
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil {
+			if _, err := q.CreateStoreDeviceHistory(ctx, CreateStoreDeviceHistoryParams{
+				StoreID:          arg.StoreID,
+				DeviceID:         pendingDevice.DeviceID,
+				ChangedAt:        arg.ChangedAt,
+				ChangedType:      StoreDeviceChangedTypeMerge,
+				ChangedBy:        uuid.NullUUID{Valid: true, UUID: arg.ChangedBy},
+				ChangedUserAgent: arg.ChangedUserAgent,
+				ChangedClientIp:  arg.ChangedClientIp,
+			}); err != nil {
+				return err
+			}
+			if err := q.DeleteStoreDevice(ctx, DeleteStoreDeviceParams{
+				StoreID:  arg.StoreID,
+				DeviceID: pendingDevice.DeviceID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if err := q.CreateStoreDeviceRetiredHardwareID(ctx, CreateStoreDeviceRetiredHardwareIDParams{
+			StoreID:    arg.StoreID,
+			HardwareID: arg.OldHardwareID,
+			DeviceID:   arg.DeviceID,
+			RetiredBy:  arg.ChangedBy,
+		}); err != nil {
+			return err
+		}
+
+		if err := q.SetStoreDeviceHardwareID(ctx, SetStoreDeviceHardwareIDParams{
+			StoreID:    arg.StoreID,
+			DeviceID:   arg.DeviceID,
+			HardwareID: arg.NewHardwareID,
+		}); err != nil {
+			return err
+		}
+
+		if _, err := q.CreateStoreDeviceHistory(ctx, CreateStoreDeviceHistoryParams{
+			StoreID:          arg.StoreID,
+			DeviceID:         arg.DeviceID,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      StoreDeviceChangedTypeReplaceHardware,
+			ChangedBy:        uuid.NullUUID{Valid: true, UUID: arg.ChangedBy},
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+type TransferStoreDeviceWithLogParams struct {
+	ChangedAt        int64
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	SourceStoreID    uuid.UUID
+	TargetStoreID    uuid.UUID
+	DeviceID         string
+	SourceState      string
+}
+
+// TransferStoreDeviceWithLog 將來源商店的機台改為 SourceState (封存) 並在目標商店建立相同的機台，
+// 目標商店已有同 device_id 且未封存的機台時回傳 ErrStoreDeviceRegistered
+func (store *SQLStore) TransferStoreDeviceWithLog(ctx context.Context, arg TransferStoreDeviceWithLogParams) (StoreDevice, error) {
+	result := StoreDevice{}
+
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		sourceDevice, err := q.GetStoreDevice(ctx, GetStoreDeviceParams{
+			StoreID:  arg.SourceStoreID,
+			DeviceID: arg.DeviceID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := q.SetStoreDeviceState(ctx, SetStoreDeviceStateParams{
+			StoreID:  arg.SourceStoreID,
+			DeviceID: arg.DeviceID,
+			State:    arg.SourceState,
+		}); err != nil {
+			return err
+		}
+		if _, err := q.CreateStoreDeviceHistory(ctx, CreateStoreDeviceHistoryParams{
+			StoreID:          arg.SourceStoreID,
+			DeviceID:         arg.DeviceID,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      StoreDeviceChangedTypeTransferOut,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+
+		result, err = q.CreateTransferredStoreDevice(ctx, CreateTransferredStoreDeviceParams{
+			StoreID:       arg.TargetStoreID,
+			DeviceID:      sourceDevice.DeviceID,
+			Name:          sourceDevice.Name,
+			RealType:      sourceDevice.RealType,
+			DisplayType:   sourceDevice.DisplayType,
+			State:         sourceDevice.State,
+			HardwareID:    sourceDevice.HardwareID,
+			MachineNumber: sourceDevice.MachineNumber,
+			CapacityKg:    sourceDevice.CapacityKg,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrStoreDeviceRegistered
+			}
+			return err
+		}
+		if _, err := q.CreateStoreDeviceHistory(ctx, CreateStoreDeviceHistoryParams{
+			StoreID:          arg.TargetStoreID,
+			DeviceID:         arg.DeviceID,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      StoreDeviceChangedTypeTransferIn,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+
+		return q.DeleteStoreDeviceMisplacement(ctx, DeleteStoreDeviceMisplacementParams{
+			StoreID:    arg.TargetStoreID,
+			HardwareID: sourceDevice.HardwareID,
+		})
+	})
+
+	return result, oerr
+}
+
+type SetStoreDeviceLayoutWithLogParams struct {
+	ChangedAt        int64
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	StoreID          uuid.UUID
+	DeviceID         string
+	ZoneID           uuid.NullUUID
+	SortOrder        int32
+	MachineNumber    sql.NullString
+	CapacityKg       sql.NullFloat64
+}
+
+// SetStoreDeviceLayoutWithLog 設定機台所屬的區域、排序與標示（機台編號、容量）
+func (store *SQLStore) SetStoreDeviceLayoutWithLog(ctx context.Context, arg SetStoreDeviceLayoutWithLogParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		if err := q.SetStoreDeviceLayout(ctx, SetStoreDeviceLayoutParams{
+			StoreID:       arg.StoreID,
+			DeviceID:      arg.DeviceID,
+			ZoneID:        arg.ZoneID,
+			SortOrder:     arg.SortOrder,
+			MachineNumber: arg.MachineNumber,
+			CapacityKg:    arg.CapacityKg,
+		}); err != nil {
+			return err
+		}
+		if _, err := q.CreateStoreDeviceHistory(ctx, CreateStoreDeviceHistoryParams{
+			StoreID:          arg.StoreID,
+			DeviceID:         arg.DeviceID,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      StoreDeviceChangedTypeUpdateLayout,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+type DeleteStoreDeviceZoneWithLogParams struct {
+	ChangedAt        int64
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	StoreID          uuid.UUID
+	ZoneID           uuid.UUID
+}
+
+// DeleteStoreDeviceZoneWithLog 刪除區域，區域內的機台會被移出區域並留下紀錄
+func (store *SQLStore) DeleteStoreDeviceZoneWithLog(ctx context.Context, arg DeleteStoreDeviceZoneWithLogParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		deviceIDs, err := q.ClearStoreDevicesZoneID(ctx, ClearStoreDevicesZoneIDParams{
+			StoreID: arg.StoreID,
+			ZoneID:  uuid.NullUUID{UUID: arg.ZoneID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		for _, deviceID := range deviceIDs {
+			if _, err := q.CreateStoreDeviceHistory(ctx, CreateStoreDeviceHistoryParams{
+				StoreID:          arg.StoreID,
+				DeviceID:         deviceID,
+				ChangedAt:        arg.ChangedAt,
+				ChangedType:      StoreDeviceChangedTypeUpdateLayout,
+				ChangedBy:        arg.ChangedBy,
+				ChangedUserAgent: arg.ChangedUserAgent,
+				ChangedClientIp:  arg.ChangedClientIp,
+			}); err != nil {
+				return err
+			}
+		}
+		return q.DeleteStoreDeviceZone(ctx, DeleteStoreDeviceZoneParams{
+			StoreID: arg.StoreID,
+			ID:      arg.ZoneID,
+		})
+	})
+}
+
+type CreateRoleWithLogParams struct {
+	ChangedAt        int64
+	ChangeType       string
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	Name             string
+	StoreID          uuid.NullUUID
+	UserScopes       []string
+	StoreUserScopes  []string
+}
+
+func (store *SQLStore) CreateRoleWithLog(ctx context.Context, arg CreateRoleWithLogParams) (Role, error) {
+	result := Role{}
+
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result, err = q.CreateRole(ctx, CreateRoleParams{
+			Name:            arg.Name,
+			StoreID:         arg.StoreID,
+			UserScopes:      arg.UserScopes,
+			StoreUserScopes: arg.StoreUserScopes,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.CreateRoleHistory(ctx, CreateRoleHistoryParams{
+			ID:               result.ID,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      arg.ChangeType,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	return result, oerr
+}
+
+type SetRoleNameAndScopesWithLogParams struct {
+	ChangedAt        int64
+	ChangeType       string
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	ID               int16
+	Name             string
+	UserScopes       []string
+	StoreUserScopes  []string
+}
+
+func (store *SQLStore) SetRoleNameAndScopesWithLog(ctx context.Context, arg SetRoleNameAndScopesWithLogParams) error {
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		err := q.SetRoleNameAndScopes(ctx, SetRoleNameAndScopesParams{
+			ID:              arg.ID,
+			Name:            arg.Name,
+			UserScopes:      arg.UserScopes,
+			StoreUserScopes: arg.StoreUserScopes,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.CreateRoleHistory(ctx, CreateRoleHistoryParams{
+			ID:               arg.ID,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      arg.ChangeType,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	return oerr
+}
+
+type DeleteRoleWithLogParams struct {
+	ChangedAt        int64
+	ChangeType       string
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	ID               int16
+}
+
+// DeleteRoleWithLog 先寫入 history 再刪除，history 才會保留刪除前的內容
+func (store *SQLStore) DeleteRoleWithLog(ctx context.Context, arg DeleteRoleWithLogParams) error {
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		if _, err := q.CreateRoleHistory(ctx, CreateRoleHistoryParams{
+			ID:               arg.ID,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      arg.ChangeType,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+		return q.DeleteRole(ctx, arg.ID)
+	})
+
+	return oerr
+}
+
+type CreateDeviceDisplayTypeWithLogParams struct {
+	ChangedAt              int64
+	ChangeType             string
+	ChangedBy              uuid.NullUUID
+	ChangedUserAgent       sql.NullString
+	ChangedClientIp        sql.NullString
+	Code                   string
+	Names                  json.RawMessage
+	IconKey                string
+	DefaultPrograms        json.RawMessage
+	ConsumesPointsOverTime bool
+}
+
+// CreateDeviceDisplayTypeWithLog code 已存在時回傳 sql.ErrNoRows
+func (store *SQLStore) CreateDeviceDisplayTypeWithLog(ctx context.Context, arg CreateDeviceDisplayTypeWithLogParams) (DeviceDisplayType, error) {
+	result := DeviceDisplayType{}
+
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result, err = q.CreateDeviceDisplayType(ctx, CreateDeviceDisplayTypeParams{
+			Code:                   arg.Code,
+			Names:                  arg.Names,
+			IconKey:                arg.IconKey,
+			DefaultPrograms:        arg.DefaultPrograms,
+			ConsumesPointsOverTime: arg.ConsumesPointsOverTime,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.CreateDeviceDisplayTypeHistory(ctx, CreateDeviceDisplayTypeHistoryParams{
+			Code:             result.Code,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      arg.ChangeType,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	return result, oerr
+}
+
+type SetDeviceDisplayTypeInfoWithLogParams struct {
+	ChangedAt              int64
+	ChangeType             string
+	ChangedBy              uuid.NullUUID
+	ChangedUserAgent       sql.NullString
+	ChangedClientIp        sql.NullString
+	Code                   string
+	Names                  json.RawMessage
+	IconKey                string
+	DefaultPrograms        json.RawMessage
+	ConsumesPointsOverTime bool
+}
+
+func (store *SQLStore) SetDeviceDisplayTypeInfoWithLog(ctx context.Context, arg SetDeviceDisplayTypeInfoWithLogParams) error {
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		err := q.SetDeviceDisplayTypeInfo(ctx, SetDeviceDisplayTypeInfoParams{
+			Code:                   arg.Code,
+			Names:                  arg.Names,
+			IconKey:                arg.IconKey,
+			DefaultPrograms:        arg.DefaultPrograms,
+			ConsumesPointsOverTime: arg.ConsumesPointsOverTime,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.CreateDeviceDisplayTypeHistory(ctx, CreateDeviceDisplayTypeHistoryParams{
+			Code:             arg.Code,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      arg.ChangeType,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	return oerr
+}
+
+type DeleteDeviceDisplayTypeWithLogParams struct {
+	ChangedAt        int64
+	ChangeType       string
+	ChangedBy        uuid.NullUUID
+	ChangedUserAgent sql.NullString
+	ChangedClientIp  sql.NullString
+	Code             string
+}
+
+// DeleteDeviceDisplayTypeWithLog 先寫入 history 再刪除，內建的 display type 不會被刪除
+func (store *SQLStore) DeleteDeviceDisplayTypeWithLog(ctx context.Context, arg DeleteDeviceDisplayTypeWithLogParams) error {
+	oerr := store.execTx(ctx, func(q *Queries) error {
+		if _, err := q.CreateDeviceDisplayTypeHistory(ctx, CreateDeviceDisplayTypeHistoryParams{
+			Code:             arg.Code,
+			ChangedAt:        arg.ChangedAt,
+			ChangedType:      arg.ChangeType,
+			ChangedBy:        arg.ChangedBy,
+			ChangedUserAgent: arg.ChangedUserAgent,
+			ChangedClientIp:  arg.ChangedClientIp,
+		}); err != nil {
+			return err
+		}
+		return q.DeleteDeviceDisplayType(ctx, arg.Code)
+	})
+
 	return oerr
 }
@@ -7,15 +7,63 @@ package db
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
+const clearStoreDevicesZoneID = `-- name: ClearStoreDevicesZoneID :many
+UPDATE store_devices
+SET zone_id = NULL
+WHERE store_id = $1 AND zone_id = $2
+RETURNING device_id
+`
+
+type ClearStoreDevicesZoneIDParams struct {
+	StoreID uuid.UUID
+	ZoneID  uuid.NullUUID
+}
+
+func (q *Queries) ClearStoreDevicesZoneID(ctx context.Context, arg ClearStoreDevicesZoneIDParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, clearStoreDevicesZoneID, arg.StoreID, arg.ZoneID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var device_id string
+		if err := rows.Scan(&device_id); err != nil {
+			return nil, err
+		}
+		items = append(items, device_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countStoreDevicesByDisplayType = `-- name: CountStoreDevicesByDisplayType :one
+SELECT COUNT(*) FROM store_devices
+WHERE display_type = $1
+`
+
+func (q *Queries) CountStoreDevicesByDisplayType(ctx context.Context, displayType string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countStoreDevicesByDisplayType, displayType)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createStoreDevice = `-- name: CreateStoreDevice :one
-INSERT INTO store_devices (store_id, device_id, name, real_type, display_type, state)
-VALUES ($1, $2, $3, $4, $5, $6)
-ON CONFLICT (store_id, device_id) DO NOTHING
-RETURNING store_id, device_id, name, real_type, display_type, state, created_at
+INSERT INTO store_devices (store_id, device_id, name, real_type, display_type, state, hardware_id)
+VALUES ($1, $2, $3, $4, $5, $6, $2)
+ON CONFLICT DO NOTHING
+RETURNING store_id, device_id, name, real_type, display_type, state, created_at, hardware_id, zone_id, sort_order, machine_number, capacity_kg
 `
 
 type CreateStoreDeviceParams struct {
@@ -45,12 +93,121 @@ func (q *Queries) CreateStoreDevice(ctx context.Context, arg CreateStoreDevicePa
 		&i.DisplayType,
 		&i.State,
 		&i.CreatedAt,
+		&i.HardwareID,
+		&i.ZoneID,
+		&i.SortOrder,
+		&i.MachineNumber,
+		&i.CapacityKg,
 	)
 	return i, err
 }
 
+const createStoreDeviceRetiredHardwareID = `-- name: CreateStoreDeviceRetiredHardwareID :exec
+INSERT INTO store_device_retired_hardware_ids (store_id, hardware_id, device_id, retired_by)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateStoreDeviceRetiredHardwareIDParams struct {
+	StoreID    uuid.UUID
+	HardwareID string
+	DeviceID   string
+	RetiredBy  uuid.UUID
+}
+
+func (q *Queries) CreateStoreDeviceRetiredHardwareID(ctx context.Context, arg CreateStoreDeviceRetiredHardwareIDParams) error {
+	_, err := q.db.ExecContext(ctx, createStoreDeviceRetiredHardwareID,
+		arg.StoreID,
+		arg.HardwareID,
+		arg.DeviceID,
+		arg.RetiredBy,
+	)
+	return err
+}
+
+const createTransferredStoreDevice = `-- name: CreateTransferredStoreDevice :one
+INSERT INTO store_devices (store_id, device_id, name, real_type, display_type, state, hardware_id, machine_number, capacity_kg)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (store_id, device_id) DO UPDATE
+SET name = EXCLUDED.name, real_type = EXCLUDED.real_type, display_type = EXCLUDED.display_type, state = EXCLUDED.state, hardware_id = EXCLUDED.hardware_id,
+    zone_id = NULL, sort_order = 0, machine_number = EXCLUDED.machine_number, capacity_kg = EXCLUDED.capacity_kg
+WHERE store_devices.state = 'archived'
+RETURNING store_id, device_id, name, real_type, display_type, state, created_at, hardware_id, zone_id, sort_order, machine_number, capacity_kg
+`
+
+type CreateTransferredStoreDeviceParams struct {
+	StoreID       uuid.UUID
+	DeviceID      string
+	Name          string
+	RealType      string
+	DisplayType   string
+	State         string
+	HardwareID    string
+	MachineNumber sql.NullString
+	CapacityKg    sql.NullFloat64
+}
+
+func (q *Queries) CreateTransferredStoreDevice(ctx context.Context, arg CreateTransferredStoreDeviceParams) (StoreDevice, error) {
+	row := q.db.QueryRowContext(ctx, createTransferredStoreDevice,
+		arg.StoreID,
+		arg.DeviceID,
+		arg.Name,
+		arg.RealType,
+		arg.DisplayType,
+		arg.State,
+		arg.HardwareID,
+		arg.MachineNumber,
+		arg.CapacityKg,
+	)
+	var i StoreDevice
+	err := row.Scan(
+		&i.StoreID,
+		&i.DeviceID,
+		&i.Name,
+		&i.RealType,
+		&i.DisplayType,
+		&i.State,
+		&i.CreatedAt,
+		&i.HardwareID,
+		&i.ZoneID,
+		&i.SortOrder,
+		&i.MachineNumber,
+		&i.CapacityKg,
+	)
+	return i, err
+}
+
+const deleteStoreDevice = `-- name: DeleteStoreDevice :exec
+DELETE FROM store_devices
+WHERE store_id = $1 AND device_id = $2
+`
+
+type DeleteStoreDeviceParams struct {
+	StoreID  uuid.UUID
+	DeviceID string
+}
+
+func (q *Queries) DeleteStoreDevice(ctx context.Context, arg DeleteStoreDeviceParams) error {
+	_, err := q.db.ExecContext(ctx, deleteStoreDevice, arg.StoreID, arg.DeviceID)
+	return err
+}
+
+const deleteStoreDeviceMisplacement = `-- name: DeleteStoreDeviceMisplacement :exec
+DELETE FROM store_device_misplacements
+WHERE store_id = $1 AND hardware_id = $2
+`
+
+type DeleteStoreDeviceMisplacementParams struct {
+	StoreID    uuid.UUID
+	HardwareID string
+}
+
+func (q *Queries) DeleteStoreDeviceMisplacement(ctx context.Context, arg DeleteStoreDeviceMisplacementParams) error {
+	_, err := q.db.ExecContext(ctx, deleteStoreDeviceMisplacement, arg.StoreID, arg.HardwareID)
+	return err
+}
+
 const getStoreDevice = `-- name: GetStoreDevice :one
-SELECT store_id, device_id, name, real_type, display_type, state, created_at
+SELECT store_id, device_id, name, real_type, display_type, state, created_at, hardware_id, zone_id, sort_order, machine_number, capacity_kg
 FROM store_devices
 WHERE store_id = $1 AND device_id = $2
 `
@@ -71,18 +228,154 @@ func (q *Queries) GetStoreDevice(ctx context.Context, arg GetStoreDeviceParams)
 		&i.DisplayType,
 		&i.State,
 		&i.CreatedAt,
+		&i.HardwareID,
+		&i.ZoneID,
+		&i.SortOrder,
+		&i.MachineNumber,
+		&i.CapacityKg,
+	)
+	return i, err
+}
+
+const getStoreDeviceByHardwareID = `-- name: GetStoreDeviceByHardwareID :one
+SELECT store_id, device_id, name, real_type, display_type, state, created_at, hardware_id, zone_id, sort_order, machine_number, capacity_kg
+FROM store_devices
+WHERE store_id = $1 AND hardware_id = $2
+`
+
+type GetStoreDeviceByHardwareIDParams struct {
+	StoreID    uuid.UUID
+	HardwareID string
+}
+
+func (q *Queries) GetStoreDeviceByHardwareID(ctx context.Context, arg GetStoreDeviceByHardwareIDParams) (StoreDevice, error) {
+	row := q.db.QueryRowContext(ctx, getStoreDeviceByHardwareID, arg.StoreID, arg.HardwareID)
+	var i StoreDevice
+	err := row.Scan(
+		&i.StoreID,
+		&i.DeviceID,
+		&i.Name,
+		&i.RealType,
+		&i.DisplayType,
+		&i.State,
+		&i.CreatedAt,
+		&i.HardwareID,
+		&i.ZoneID,
+		&i.SortOrder,
+		&i.MachineNumber,
+		&i.CapacityKg,
+	)
+	return i, err
+}
+
+const getStoreDeviceInOtherStoreByHardwareID = `-- name: GetStoreDeviceInOtherStoreByHardwareID :one
+SELECT store_id, device_id, name, real_type, display_type, state, created_at, hardware_id, zone_id, sort_order, machine_number, capacity_kg
+FROM store_devices
+WHERE hardware_id = $1 AND store_id <> $2 AND state NOT IN ('archived', 'decommissioned')
+LIMIT 1
+`
+
+type GetStoreDeviceInOtherStoreByHardwareIDParams struct {
+	HardwareID string
+	StoreID    uuid.UUID
+}
+
+func (q *Queries) GetStoreDeviceInOtherStoreByHardwareID(ctx context.Context, arg GetStoreDeviceInOtherStoreByHardwareIDParams) (StoreDevice, error) {
+	row := q.db.QueryRowContext(ctx, getStoreDeviceInOtherStoreByHardwareID, arg.HardwareID, arg.StoreID)
+	var i StoreDevice
+	err := row.Scan(
+		&i.StoreID,
+		&i.DeviceID,
+		&i.Name,
+		&i.RealType,
+		&i.DisplayType,
+		&i.State,
+		&i.CreatedAt,
+		&i.HardwareID,
+		&i.ZoneID,
+		&i.SortOrder,
+		&i.MachineNumber,
+		&i.CapacityKg,
+	)
+	return i, err
+}
+
+const getStoreDeviceMisplacements = `-- name: GetStoreDeviceMisplacements :many
+SELECT store_id, hardware_id, registered_store_id, registered_device_id, first_seen_at, last_seen_at
+FROM store_device_misplacements
+WHERE store_id = $1
+ORDER BY last_seen_at DESC
+`
+
+func (q *Queries) GetStoreDeviceMisplacements(ctx context.Context, storeID uuid.UUID) ([]StoreDeviceMisplacement, error) {
+	rows, err := q.db.QueryContext(ctx, getStoreDeviceMisplacements, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []StoreDeviceMisplacement{}
+	for rows.Next() {
+		var i StoreDeviceMisplacement
+		if err := rows.Scan(
+			&i.StoreID,
+			&i.HardwareID,
+			&i.RegisteredStoreID,
+			&i.RegisteredDeviceID,
+			&i.FirstSeenAt,
+			&i.LastSeenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStoreDeviceRetiredHardwareID = `-- name: GetStoreDeviceRetiredHardwareID :one
+SELECT store_id, hardware_id, device_id, retired_by, retired_at
+FROM store_device_retired_hardware_ids
+WHERE store_id = $1 AND hardware_id = $2
+`
+
+type GetStoreDeviceRetiredHardwareIDParams struct {
+	StoreID    uuid.UUID
+	HardwareID string
+}
+
+func (q *Queries) GetStoreDeviceRetiredHardwareID(ctx context.Context, arg GetStoreDeviceRetiredHardwareIDParams) (StoreDeviceRetiredHardwareID, error) {
+	row := q.db.QueryRowContext(ctx, getStoreDeviceRetiredHardwareID, arg.StoreID, arg.HardwareID)
+	var i StoreDeviceRetiredHardwareID
+	err := row.Scan(
+		&i.StoreID,
+		&i.HardwareID,
+		&i.DeviceID,
+		&i.RetiredBy,
+		&i.RetiredAt,
 	)
 	return i, err
 }
 
 const getStoreDevices = `-- name: GetStoreDevices :many
-SELECT store_id, device_id, name, real_type, display_type, state, created_at
+SELECT store_id, device_id, name, real_type, display_type, state, created_at, hardware_id, zone_id, sort_order, machine_number, capacity_kg
 FROM store_devices
 WHERE store_id = $1
+  AND ($2::TEXT IS NULL OR state = $2)
+ORDER BY sort_order, device_id
 `
 
-func (q *Queries) GetStoreDevices(ctx context.Context, storeID uuid.UUID) ([]StoreDevice, error) {
-	rows, err := q.db.QueryContext(ctx, getStoreDevices, storeID)
+type GetStoreDevicesParams struct {
+	StoreID uuid.UUID
+	State   sql.NullString
+}
+
+func (q *Queries) GetStoreDevices(ctx context.Context, arg GetStoreDevicesParams) ([]StoreDevice, error) {
+	rows, err := q.db.QueryContext(ctx, getStoreDevices, arg.StoreID, arg.State)
 	if err != nil {
 		return nil, err
 	}
@@ -98,6 +391,11 @@ func (q *Queries) GetStoreDevices(ctx context.Context, storeID uuid.UUID) ([]Sto
 			&i.DisplayType,
 			&i.State,
 			&i.CreatedAt,
+			&i.HardwareID,
+			&i.ZoneID,
+			&i.SortOrder,
+			&i.MachineNumber,
+			&i.CapacityKg,
 		); err != nil {
 			return nil, err
 		}
@@ -112,6 +410,54 @@ func (q *Queries) GetStoreDevices(ctx context.Context, storeID uuid.UUID) ([]Sto
 	return items, nil
 }
 
+const setStoreDeviceHardwareID = `-- name: SetStoreDeviceHardwareID :exec
+UPDATE store_devices
+SET hardware_id = $3
+WHERE store_id = $1 AND device_id = $2
+`
+
+type SetStoreDeviceHardwareIDParams struct {
+	StoreID    uuid.UUID
+	DeviceID   string
+	HardwareID string
+}
+
+func (q *Queries) SetStoreDeviceHardwareID(ctx context.Context, arg SetStoreDeviceHardwareIDParams) error {
+	_, err := q.db.ExecContext(ctx, setStoreDeviceHardwareID,
+		arg.StoreID,
+		arg.DeviceID,
+		arg.HardwareID,
+	)
+	return err
+}
+
+const setStoreDeviceLayout = `-- name: SetStoreDeviceLayout :exec
+UPDATE store_devices
+SET zone_id = $3, sort_order = $4, machine_number = $5, capacity_kg = $6
+WHERE store_id = $1 AND device_id = $2
+`
+
+type SetStoreDeviceLayoutParams struct {
+	StoreID       uuid.UUID
+	DeviceID      string
+	ZoneID        uuid.NullUUID
+	SortOrder     int32
+	MachineNumber sql.NullString
+	CapacityKg    sql.NullFloat64
+}
+
+func (q *Queries) SetStoreDeviceLayout(ctx context.Context, arg SetStoreDeviceLayoutParams) error {
+	_, err := q.db.ExecContext(ctx, setStoreDeviceLayout,
+		arg.StoreID,
+		arg.DeviceID,
+		arg.ZoneID,
+		arg.SortOrder,
+		arg.MachineNumber,
+		arg.CapacityKg,
+	)
+	return err
+}
+
 const setStoreDeviceNameAndDisplayType = `-- name: SetStoreDeviceNameAndDisplayType :exec
 UPDATE store_devices
 SET name = $3, display_type=$4
@@ -134,3 +480,50 @@ func (q *Queries) SetStoreDeviceNameAndDisplayType(ctx context.Context, arg SetS
 	)
 	return err
 }
+
+const setStoreDeviceState = `-- name: SetStoreDeviceState :exec
+UPDATE store_devices
+SET state = $3
+WHERE store_id = $1 AND device_id = $2
+`
+
+type SetStoreDeviceStateParams struct {
+	StoreID  uuid.UUID
+	DeviceID string
+	State    string
+}
+
+func (q *Queries) SetStoreDeviceState(ctx context.Context, arg SetStoreDeviceStateParams) error {
+	_, err := q.db.ExecContext(ctx, setStoreDeviceState,
+		arg.StoreID,
+		arg.DeviceID,
+		arg.State,
+	)
+	return err
+}
+
+const upsertStoreDeviceMisplacement = `-- name: UpsertStoreDeviceMisplacement :exec
+INSERT INTO store_device_misplacements (store_id, hardware_id, registered_store_id, registered_device_id, first_seen_at, last_seen_at)
+VALUES ($1, $2, $3, $4, $5, $5)
+ON CONFLICT (store_id, hardware_id) DO UPDATE
+SET registered_store_id = EXCLUDED.registered_store_id, registered_device_id = EXCLUDED.registered_device_id, last_seen_at = EXCLUDED.last_seen_at
+`
+
+type UpsertStoreDeviceMisplacementParams struct {
+	StoreID            uuid.UUID
+	HardwareID         string
+	RegisteredStoreID  uuid.UUID
+	RegisteredDeviceID string
+	FirstSeenAt        int64
+}
+
+func (q *Queries) UpsertStoreDeviceMisplacement(ctx context.Context, arg UpsertStoreDeviceMisplacementParams) error {
+	_, err := q.db.ExecContext(ctx, upsertStoreDeviceMisplacement,
+		arg.StoreID,
+		arg.HardwareID,
+		arg.RegisteredStoreID,
+		arg.RegisteredDeviceID,
+		arg.FirstSeenAt,
+	)
+	return err
+}
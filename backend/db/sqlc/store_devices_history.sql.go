@@ -13,11 +13,11 @@ import (
 )
 
 const createStoreDeviceHistory = `-- name: CreateStoreDeviceHistory :one
-INSERT INTO store_devices_history (changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, store_id, device_id, name, real_type, display_type, state, created_at)
-SELECT $3, $4, $5, $6, $7, store_id, device_id, name, real_type, display_type, state, created_at
+INSERT INTO store_devices_history (changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, store_id, device_id, name, real_type, display_type, state, created_at, hardware_id, zone_id, sort_order, machine_number, capacity_kg)
+SELECT $3, $4, $5, $6, $7, store_id, device_id, name, real_type, display_type, state, created_at, hardware_id, zone_id, sort_order, machine_number, capacity_kg
 FROM store_devices AS sd
 WHERE sd.store_id = $1 AND sd.device_id = $2
-RETURNING changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, store_id, device_id, name, real_type, display_type, state, created_at, history_created_at
+RETURNING changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, store_id, device_id, name, real_type, display_type, state, created_at, history_created_at, hardware_id, zone_id, sort_order, machine_number, capacity_kg
 `
 
 type CreateStoreDeviceHistoryParams struct {
@@ -55,6 +55,142 @@ func (q *Queries) CreateStoreDeviceHistory(ctx context.Context, arg CreateStoreD
 		&i.State,
 		&i.CreatedAt,
 		&i.HistoryCreatedAt,
+		&i.HardwareID,
+		&i.ZoneID,
+		&i.SortOrder,
+		&i.MachineNumber,
+		&i.CapacityKg,
 	)
 	return i, err
 }
+
+const getPreviousStoreDevicesHistory = `-- name: GetPreviousStoreDevicesHistory :one
+SELECT changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, store_id, device_id, name, real_type, display_type, state, created_at, history_created_at, hardware_id, zone_id, sort_order, machine_number, capacity_kg FROM store_devices_history
+WHERE store_id = $1 AND device_id = $2
+  AND (changed_at, history_created_at) < ($3::BIGINT, $4::BIGINT)
+ORDER BY changed_at DESC, history_created_at DESC
+LIMIT 1
+`
+
+type GetPreviousStoreDevicesHistoryParams struct {
+	StoreID          uuid.UUID
+	DeviceID         string
+	ChangedAt        int64
+	HistoryCreatedAt int64
+}
+
+func (q *Queries) GetPreviousStoreDevicesHistory(ctx context.Context, arg GetPreviousStoreDevicesHistoryParams) (StoreDevicesHistory, error) {
+	row := q.db.QueryRowContext(ctx, getPreviousStoreDevicesHistory,
+		arg.StoreID,
+		arg.DeviceID,
+		arg.ChangedAt,
+		arg.HistoryCreatedAt,
+	)
+	var i StoreDevicesHistory
+	err := row.Scan(
+		&i.ChangedAt,
+		&i.ChangedType,
+		&i.ChangedBy,
+		&i.ChangedUserAgent,
+		&i.ChangedClientIp,
+		&i.StoreID,
+		&i.DeviceID,
+		&i.Name,
+		&i.RealType,
+		&i.DisplayType,
+		&i.State,
+		&i.CreatedAt,
+		&i.HistoryCreatedAt,
+		&i.HardwareID,
+		&i.ZoneID,
+		&i.SortOrder,
+		&i.MachineNumber,
+		&i.CapacityKg,
+	)
+	return i, err
+}
+
+const getStoreDevicesHistory = `-- name: GetStoreDevicesHistory :many
+SELECT changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, store_id, device_id, name, real_type, display_type, state, created_at, history_created_at, hardware_id, zone_id, sort_order, machine_number, capacity_kg FROM store_devices_history
+WHERE ($1::UUID IS NULL OR store_id = $1)
+  AND ($2::TEXT IS NULL OR device_id = $2)
+  AND ($3::UUID IS NULL OR changed_by = $3)
+  AND ($4::TEXT IS NULL OR changed_type = $4)
+  AND ($5::BIGINT IS NULL OR changed_at >= $5)
+  AND ($6::BIGINT IS NULL OR changed_at < $6)
+ORDER BY changed_at DESC, history_created_at DESC
+LIMIT $7
+`
+
+type GetStoreDevicesHistoryParams struct {
+	StoreID     uuid.NullUUID
+	DeviceID    sql.NullString
+	ChangedBy   uuid.NullUUID
+	ChangedType sql.NullString
+	ChangedFrom sql.NullInt64
+	ChangedTo   sql.NullInt64
+	LimitCount  int32
+}
+
+func (q *Queries) GetStoreDevicesHistory(ctx context.Context, arg GetStoreDevicesHistoryParams) ([]StoreDevicesHistory, error) {
+	rows, err := q.db.QueryContext(ctx, getStoreDevicesHistory,
+		arg.StoreID,
+		arg.DeviceID,
+		arg.ChangedBy,
+		arg.ChangedType,
+		arg.ChangedFrom,
+		arg.ChangedTo,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []StoreDevicesHistory{}
+	for rows.Next() {
+		var i StoreDevicesHistory
+		if err := rows.Scan(
+			&i.ChangedAt,
+			&i.ChangedType,
+			&i.ChangedBy,
+			&i.ChangedUserAgent,
+			&i.ChangedClientIp,
+			&i.StoreID,
+			&i.DeviceID,
+			&i.Name,
+			&i.RealType,
+			&i.DisplayType,
+			&i.State,
+			&i.CreatedAt,
+			&i.HistoryCreatedAt,
+			&i.HardwareID,
+			&i.ZoneID,
+			&i.SortOrder,
+			&i.MachineNumber,
+			&i.CapacityKg,
+			&i.ZoneID,
+			&i.SortOrder,
+			&i.MachineNumber,
+			&i.CapacityKg,
+			&i.HardwareID,
+			&i.ZoneID,
+			&i.SortOrder,
+			&i.MachineNumber,
+			&i.CapacityKg,
+			&i.ZoneID,
+			&i.SortOrder,
+			&i.MachineNumber,
+			&i.CapacityKg,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -7,10 +7,23 @@ package db
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
+const countStoreUsersByRoleID = `-- name: CountStoreUsersByRoleID :one
+SELECT COUNT(*) FROM store_users
+WHERE role_id = $1
+`
+
+func (q *Queries) CountStoreUsersByRoleID(ctx context.Context, roleID int16) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countStoreUsersByRoleID, roleID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createStoreUser = `-- name: CreateStoreUser :one
 INSERT INTO store_users (store_id, user_id, role_id, state)
 VALUES ($1, $2, $3, $4)
@@ -46,6 +59,58 @@ func (q *Queries) CreateStoreUser(ctx context.Context, arg CreateStoreUserParams
 	return i, err
 }
 
+const getStoreDebtors = `-- name: GetStoreDebtors :many
+SELECT u.id AS user_id, u.phone_number, u.name, su.balance, su.points, su.balance_earmark, su.points_earmark, sucl.credit_limit
+FROM store_users su
+INNER JOIN users u ON su.user_id = u.id
+LEFT JOIN store_user_credit_limits sucl ON su.store_id = sucl.store_id AND su.user_id = sucl.user_id
+WHERE su.store_id = $1 AND su.balance < 0
+ORDER BY su.balance ASC
+`
+
+type GetStoreDebtorsRow struct {
+	UserID         uuid.UUID
+	PhoneNumber    string
+	Name           string
+	Balance        int32
+	Points         int32
+	BalanceEarmark int32
+	PointsEarmark  int32
+	CreditLimit    sql.NullInt32
+}
+
+func (q *Queries) GetStoreDebtors(ctx context.Context, storeID uuid.UUID) ([]GetStoreDebtorsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getStoreDebtors, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetStoreDebtorsRow{}
+	for rows.Next() {
+		var i GetStoreDebtorsRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.PhoneNumber,
+			&i.Name,
+			&i.Balance,
+			&i.Points,
+			&i.BalanceEarmark,
+			&i.PointsEarmark,
+			&i.CreditLimit,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getStoreUser = `-- name: GetStoreUser :one
 SELECT store_id, user_id, balance, points, balance_earmark, points_earmark, role_id, state, created_at FROM store_users
 WHERE store_id = $1 AND user_id = $2
@@ -73,6 +138,51 @@ func (q *Queries) GetStoreUser(ctx context.Context, arg GetStoreUserParams) (Sto
 	return i, err
 }
 
+const getStoreUserInvitationsByUserID = `-- name: GetStoreUserInvitationsByUserID :many
+SELECT s.id AS store_id, s.name AS store_name, s.address AS store_address, su.role_id, su.created_at
+FROM store_users su INNER JOIN stores s
+ON su.store_id = s.id
+WHERE su.user_id = $1 AND su.state = 'pending'
+ORDER BY su.created_at DESC
+`
+
+type GetStoreUserInvitationsByUserIDRow struct {
+	StoreID      uuid.UUID
+	StoreName    string
+	StoreAddress string
+	RoleID       int16
+	CreatedAt    int64
+}
+
+func (q *Queries) GetStoreUserInvitationsByUserID(ctx context.Context, userID uuid.UUID) ([]GetStoreUserInvitationsByUserIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, getStoreUserInvitationsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetStoreUserInvitationsByUserIDRow{}
+	for rows.Next() {
+		var i GetStoreUserInvitationsByUserIDRow
+		if err := rows.Scan(
+			&i.StoreID,
+			&i.StoreName,
+			&i.StoreAddress,
+			&i.RoleID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getStoreUsersByStoreID = `-- name: GetStoreUsersByStoreID :many
 SELECT u.id, u.phone_number, u.name, su.state, su.role_id
 FROM store_users su, stores s, users u
@@ -160,6 +270,29 @@ func (q *Queries) SetStoreUserRoleID(ctx context.Context, arg SetStoreUserRoleID
 	return err
 }
 
+const setStoreUserRoleIDAndState = `-- name: SetStoreUserRoleIDAndState :exec
+UPDATE store_users
+SET role_id = $3, state = $4
+WHERE store_id = $1 AND user_id = $2
+`
+
+type SetStoreUserRoleIDAndStateParams struct {
+	StoreID uuid.UUID
+	UserID  uuid.UUID
+	RoleID  int16
+	State   string
+}
+
+func (q *Queries) SetStoreUserRoleIDAndState(ctx context.Context, arg SetStoreUserRoleIDAndStateParams) error {
+	_, err := q.db.ExecContext(ctx, setStoreUserRoleIDAndState,
+		arg.StoreID,
+		arg.UserID,
+		arg.RoleID,
+		arg.State,
+	)
+	return err
+}
+
 const setStoreUserState = `-- name: SetStoreUserState :exec
 UPDATE store_users
 SET state = $3
@@ -60,3 +60,213 @@ func (q *Queries) CreateStoreUserHistory(ctx context.Context, arg CreateStoreUse
 	)
 	return i, err
 }
+
+const getPreviousStoreUsersHistory = `-- name: GetPreviousStoreUsersHistory :one
+SELECT changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, store_id, user_id, balance, points, balance_earmark, points_earmark, role_id, state, created_at, history_created_at FROM store_users_history
+WHERE store_id = $1 AND user_id = $2
+  AND (changed_at, history_created_at) < ($3::BIGINT, $4::BIGINT)
+ORDER BY changed_at DESC, history_created_at DESC
+LIMIT 1
+`
+
+type GetPreviousStoreUsersHistoryParams struct {
+	StoreID          uuid.UUID
+	UserID           uuid.UUID
+	ChangedAt        int64
+	HistoryCreatedAt int64
+}
+
+func (q *Queries) GetPreviousStoreUsersHistory(ctx context.Context, arg GetPreviousStoreUsersHistoryParams) (StoreUsersHistory, error) {
+	row := q.db.QueryRowContext(ctx, getPreviousStoreUsersHistory,
+		arg.StoreID,
+		arg.UserID,
+		arg.ChangedAt,
+		arg.HistoryCreatedAt,
+	)
+	var i StoreUsersHistory
+	err := row.Scan(
+		&i.ChangedAt,
+		&i.ChangedType,
+		&i.ChangedBy,
+		&i.ChangedUserAgent,
+		&i.ChangedClientIp,
+		&i.StoreID,
+		&i.UserID,
+		&i.Balance,
+		&i.Points,
+		&i.BalanceEarmark,
+		&i.PointsEarmark,
+		&i.RoleID,
+		&i.State,
+		&i.CreatedAt,
+		&i.HistoryCreatedAt,
+	)
+	return i, err
+}
+
+const getStoreUserHistoryAt = `-- name: GetStoreUserHistoryAt :one
+SELECT changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, store_id, user_id, balance, points, balance_earmark, points_earmark, role_id, state, created_at, history_created_at FROM store_users_history
+WHERE store_id = $1 AND user_id = $2 AND changed_at <= $3::BIGINT
+ORDER BY changed_at DESC, history_created_at DESC
+LIMIT 1
+`
+
+type GetStoreUserHistoryAtParams struct {
+	StoreID uuid.UUID
+	UserID  uuid.UUID
+	Ts      int64
+}
+
+func (q *Queries) GetStoreUserHistoryAt(ctx context.Context, arg GetStoreUserHistoryAtParams) (StoreUsersHistory, error) {
+	row := q.db.QueryRowContext(ctx, getStoreUserHistoryAt,
+		arg.StoreID,
+		arg.UserID,
+		arg.Ts,
+	)
+	var i StoreUsersHistory
+	err := row.Scan(
+		&i.ChangedAt,
+		&i.ChangedType,
+		&i.ChangedBy,
+		&i.ChangedUserAgent,
+		&i.ChangedClientIp,
+		&i.StoreID,
+		&i.UserID,
+		&i.Balance,
+		&i.Points,
+		&i.BalanceEarmark,
+		&i.PointsEarmark,
+		&i.RoleID,
+		&i.State,
+		&i.CreatedAt,
+		&i.HistoryCreatedAt,
+	)
+	return i, err
+}
+
+const getStoreUserHistoryBetween = `-- name: GetStoreUserHistoryBetween :many
+SELECT changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, store_id, user_id, balance, points, balance_earmark, points_earmark, role_id, state, created_at, history_created_at FROM store_users_history
+WHERE store_id = $1 AND user_id = $2
+  AND changed_at >= $3::BIGINT AND changed_at < $4::BIGINT
+ORDER BY changed_at, history_created_at
+`
+
+type GetStoreUserHistoryBetweenParams struct {
+	StoreID uuid.UUID
+	UserID  uuid.UUID
+	FromTs  int64
+	ToTs    int64
+}
+
+func (q *Queries) GetStoreUserHistoryBetween(ctx context.Context, arg GetStoreUserHistoryBetweenParams) ([]StoreUsersHistory, error) {
+	rows, err := q.db.QueryContext(ctx, getStoreUserHistoryBetween,
+		arg.StoreID,
+		arg.UserID,
+		arg.FromTs,
+		arg.ToTs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []StoreUsersHistory{}
+	for rows.Next() {
+		var i StoreUsersHistory
+		if err := rows.Scan(
+			&i.ChangedAt,
+			&i.ChangedType,
+			&i.ChangedBy,
+			&i.ChangedUserAgent,
+			&i.ChangedClientIp,
+			&i.StoreID,
+			&i.UserID,
+			&i.Balance,
+			&i.Points,
+			&i.BalanceEarmark,
+			&i.PointsEarmark,
+			&i.RoleID,
+			&i.State,
+			&i.CreatedAt,
+			&i.HistoryCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStoreUsersHistory = `-- name: GetStoreUsersHistory :many
+SELECT changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, store_id, user_id, balance, points, balance_earmark, points_earmark, role_id, state, created_at, history_created_at FROM store_users_history
+WHERE ($1::UUID IS NULL OR store_id = $1)
+  AND ($2::UUID IS NULL OR user_id = $2)
+  AND ($3::UUID IS NULL OR changed_by = $3)
+  AND ($4::TEXT IS NULL OR changed_type = $4)
+  AND ($5::BIGINT IS NULL OR changed_at >= $5)
+  AND ($6::BIGINT IS NULL OR changed_at < $6)
+ORDER BY changed_at DESC, history_created_at DESC
+LIMIT $7
+`
+
+type GetStoreUsersHistoryParams struct {
+	StoreID     uuid.NullUUID
+	UserID      uuid.NullUUID
+	ChangedBy   uuid.NullUUID
+	ChangedType sql.NullString
+	ChangedFrom sql.NullInt64
+	ChangedTo   sql.NullInt64
+	LimitCount  int32
+}
+
+func (q *Queries) GetStoreUsersHistory(ctx context.Context, arg GetStoreUsersHistoryParams) ([]StoreUsersHistory, error) {
+	rows, err := q.db.QueryContext(ctx, getStoreUsersHistory,
+		arg.StoreID,
+		arg.UserID,
+		arg.ChangedBy,
+		arg.ChangedType,
+		arg.ChangedFrom,
+		arg.ChangedTo,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []StoreUsersHistory{}
+	for rows.Next() {
+		var i StoreUsersHistory
+		if err := rows.Scan(
+			&i.ChangedAt,
+			&i.ChangedType,
+			&i.ChangedBy,
+			&i.ChangedUserAgent,
+			&i.ChangedClientIp,
+			&i.StoreID,
+			&i.UserID,
+			&i.Balance,
+			&i.Points,
+			&i.BalanceEarmark,
+			&i.PointsEarmark,
+			&i.RoleID,
+			&i.State,
+			&i.CreatedAt,
+			&i.HistoryCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -101,7 +101,7 @@ const getUserStores = `-- name: GetUserStores :many
 SELECT s.id, s.name, s.address, s.state, s.password, s.created_at
 FROM stores s INNER JOIN store_users su
 ON s.id = su.store_id
-WHERE su.user_id = $1
+WHERE su.user_id = $1 AND su.state <> 'pending'
 `
 
 func (q *Queries) GetUserStores(ctx context.Context, userID uuid.UUID) ([]Store, error) {
@@ -55,3 +55,104 @@ func (q *Queries) CreateStoreHistory(ctx context.Context, arg CreateStoreHistory
 	)
 	return i, err
 }
+
+const getPreviousStoresHistory = `-- name: GetPreviousStoresHistory :one
+SELECT changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, store_id, name, address, state, password, created_at, history_created_at FROM stores_history
+WHERE store_id = $1
+  AND (changed_at, history_created_at) < ($2::BIGINT, $3::BIGINT)
+ORDER BY changed_at DESC, history_created_at DESC
+LIMIT 1
+`
+
+type GetPreviousStoresHistoryParams struct {
+	StoreID          uuid.UUID
+	ChangedAt        int64
+	HistoryCreatedAt int64
+}
+
+func (q *Queries) GetPreviousStoresHistory(ctx context.Context, arg GetPreviousStoresHistoryParams) (StoresHistory, error) {
+	row := q.db.QueryRowContext(ctx, getPreviousStoresHistory,
+		arg.StoreID,
+		arg.ChangedAt,
+		arg.HistoryCreatedAt,
+	)
+	var i StoresHistory
+	err := row.Scan(
+		&i.ChangedAt,
+		&i.ChangedType,
+		&i.ChangedBy,
+		&i.ChangedUserAgent,
+		&i.ChangedClientIp,
+		&i.StoreID,
+		&i.Name,
+		&i.Address,
+		&i.State,
+		&i.Password,
+		&i.CreatedAt,
+		&i.HistoryCreatedAt,
+	)
+	return i, err
+}
+
+const getStoresHistory = `-- name: GetStoresHistory :many
+SELECT changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, store_id, name, address, state, password, created_at, history_created_at FROM stores_history
+WHERE ($1::UUID IS NULL OR store_id = $1)
+  AND ($2::UUID IS NULL OR changed_by = $2)
+  AND ($3::TEXT IS NULL OR changed_type = $3)
+  AND ($4::BIGINT IS NULL OR changed_at >= $4)
+  AND ($5::BIGINT IS NULL OR changed_at < $5)
+ORDER BY changed_at DESC, history_created_at DESC
+LIMIT $6
+`
+
+type GetStoresHistoryParams struct {
+	StoreID     uuid.NullUUID
+	ChangedBy   uuid.NullUUID
+	ChangedType sql.NullString
+	ChangedFrom sql.NullInt64
+	ChangedTo   sql.NullInt64
+	LimitCount  int32
+}
+
+func (q *Queries) GetStoresHistory(ctx context.Context, arg GetStoresHistoryParams) ([]StoresHistory, error) {
+	rows, err := q.db.QueryContext(ctx, getStoresHistory,
+		arg.StoreID,
+		arg.ChangedBy,
+		arg.ChangedType,
+		arg.ChangedFrom,
+		arg.ChangedTo,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []StoresHistory{}
+	for rows.Next() {
+		var i StoresHistory
+		if err := rows.Scan(
+			&i.ChangedAt,
+			&i.ChangedType,
+			&i.ChangedBy,
+			&i.ChangedUserAgent,
+			&i.ChangedClientIp,
+			&i.StoreID,
+			&i.Name,
+			&i.Address,
+			&i.State,
+			&i.Password,
+			&i.CreatedAt,
+			&i.HistoryCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -73,3 +73,14 @@ func (q *Queries) GetToken(ctx context.Context, id uuid.UUID) (Token, error) {
 	)
 	return i, err
 }
+
+const blockUserTokens = `-- name: BlockUserTokens :exec
+UPDATE tokens
+SET is_blocked = true
+WHERE user_id = $1 AND is_blocked = false
+`
+
+func (q *Queries) BlockUserTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, blockUserTokens, userID)
+	return err
+}
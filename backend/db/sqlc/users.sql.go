@@ -15,7 +15,7 @@ import (
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, phone_number, name, password, role_id, state)
 VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, phone_number, name, password, password_error_count, password_changed_at, role_id, state, created_at
+RETURNING id, phone_number, name, password, password_error_count, password_changed_at, role_id, state, created_at, tokens_revoked_at
 `
 
 type CreateUserParams struct {
@@ -47,12 +47,13 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.RoleID,
 		&i.State,
 		&i.CreatedAt,
+		&i.TokensRevokedAt,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, phone_number, name, password, password_error_count, password_changed_at, role_id, state, created_at FROM users
+SELECT id, phone_number, name, password, password_error_count, password_changed_at, role_id, state, created_at, tokens_revoked_at FROM users
 WHERE id = $1
 `
 
@@ -69,12 +70,13 @@ func (q *Queries) GetUser(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.RoleID,
 		&i.State,
 		&i.CreatedAt,
+		&i.TokensRevokedAt,
 	)
 	return i, err
 }
 
 const getUserByPhoneNumber = `-- name: GetUserByPhoneNumber :one
-SELECT id, phone_number, name, password, password_error_count, password_changed_at, role_id, state, created_at FROM users
+SELECT id, phone_number, name, password, password_error_count, password_changed_at, role_id, state, created_at, tokens_revoked_at FROM users
 WHERE phone_number = $1
 `
 
@@ -91,6 +93,7 @@ func (q *Queries) GetUserByPhoneNumber(ctx context.Context, phoneNumber string)
 		&i.RoleID,
 		&i.State,
 		&i.CreatedAt,
+		&i.TokensRevokedAt,
 	)
 	return i, err
 }
@@ -135,3 +138,20 @@ func (q *Queries) SetUserPasswordAndState(ctx context.Context, arg SetUserPasswo
 	)
 	return err
 }
+
+const setUserPhoneNumber = `-- name: SetUserPhoneNumber :exec
+UPDATE users
+SET phone_number = $2, tokens_revoked_at = $3
+WHERE id = $1
+`
+
+type SetUserPhoneNumberParams struct {
+	ID              uuid.UUID
+	PhoneNumber     string
+	TokensRevokedAt sql.NullInt64
+}
+
+func (q *Queries) SetUserPhoneNumber(ctx context.Context, arg SetUserPhoneNumberParams) error {
+	_, err := q.db.ExecContext(ctx, setUserPhoneNumber, arg.ID, arg.PhoneNumber, arg.TokensRevokedAt)
+	return err
+}
@@ -58,3 +58,150 @@ func (q *Queries) CreateUserHistory(ctx context.Context, arg CreateUserHistoryPa
 	)
 	return i, err
 }
+
+const getPreviousUsersHistory = `-- name: GetPreviousUsersHistory :one
+SELECT changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, user_id, phone_number, name, password, password_error_count, password_changed_at, role_id, state, created_at, history_created_at FROM users_history
+WHERE user_id = $1
+  AND (changed_at, history_created_at) < ($2::BIGINT, $3::BIGINT)
+ORDER BY changed_at DESC, history_created_at DESC
+LIMIT 1
+`
+
+type GetPreviousUsersHistoryParams struct {
+	UserID           uuid.UUID
+	ChangedAt        int64
+	HistoryCreatedAt int64
+}
+
+func (q *Queries) GetPreviousUsersHistory(ctx context.Context, arg GetPreviousUsersHistoryParams) (UsersHistory, error) {
+	row := q.db.QueryRowContext(ctx, getPreviousUsersHistory,
+		arg.UserID,
+		arg.ChangedAt,
+		arg.HistoryCreatedAt,
+	)
+	var i UsersHistory
+	err := row.Scan(
+		&i.ChangedAt,
+		&i.ChangedType,
+		&i.ChangedBy,
+		&i.ChangedUserAgent,
+		&i.ChangedClientIp,
+		&i.UserID,
+		&i.PhoneNumber,
+		&i.Name,
+		&i.Password,
+		&i.PasswordErrorCount,
+		&i.PasswordChangedAt,
+		&i.RoleID,
+		&i.State,
+		&i.CreatedAt,
+		&i.HistoryCreatedAt,
+	)
+	return i, err
+}
+
+const getUserRecentPasswords = `-- name: GetUserRecentPasswords :many
+SELECT h.password
+FROM (
+  SELECT password, MAX(changed_at) AS last_used_at
+  FROM users_history
+  WHERE user_id = $1
+  GROUP BY password
+) AS h
+ORDER BY h.last_used_at DESC
+LIMIT $2
+`
+
+type GetUserRecentPasswordsParams struct {
+	UserID uuid.UUID
+	Limit  int32
+}
+
+func (q *Queries) GetUserRecentPasswords(ctx context.Context, arg GetUserRecentPasswordsParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getUserRecentPasswords, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var password string
+		if err := rows.Scan(&password); err != nil {
+			return nil, err
+		}
+		items = append(items, password)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsersHistory = `-- name: GetUsersHistory :many
+SELECT changed_at, changed_type, changed_by, changed_user_agent, changed_client_ip, user_id, phone_number, name, password, password_error_count, password_changed_at, role_id, state, created_at, history_created_at FROM users_history
+WHERE ($1::UUID IS NULL OR user_id = $1)
+  AND ($2::UUID IS NULL OR changed_by = $2)
+  AND ($3::TEXT IS NULL OR changed_type = $3)
+  AND ($4::BIGINT IS NULL OR changed_at >= $4)
+  AND ($5::BIGINT IS NULL OR changed_at < $5)
+ORDER BY changed_at DESC, history_created_at DESC
+LIMIT $6
+`
+
+type GetUsersHistoryParams struct {
+	UserID      uuid.NullUUID
+	ChangedBy   uuid.NullUUID
+	ChangedType sql.NullString
+	ChangedFrom sql.NullInt64
+	ChangedTo   sql.NullInt64
+	LimitCount  int32
+}
+
+func (q *Queries) GetUsersHistory(ctx context.Context, arg GetUsersHistoryParams) ([]UsersHistory, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersHistory,
+		arg.UserID,
+		arg.ChangedBy,
+		arg.ChangedType,
+		arg.ChangedFrom,
+		arg.ChangedTo,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []UsersHistory{}
+	for rows.Next() {
+		var i UsersHistory
+		if err := rows.Scan(
+			&i.ChangedAt,
+			&i.ChangedType,
+			&i.ChangedBy,
+			&i.ChangedUserAgent,
+			&i.ChangedClientIp,
+			&i.UserID,
+			&i.PhoneNumber,
+			&i.Name,
+			&i.Password,
+			&i.PasswordErrorCount,
+			&i.PasswordChangedAt,
+			&i.RoleID,
+			&i.State,
+			&i.CreatedAt,
+			&i.HistoryCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
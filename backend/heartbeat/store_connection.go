@@ -0,0 +1,61 @@
+package heartbeat
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// StoreConnection edge 連線到 iot 的資訊，與 heartbeat 同時更新，edge 斷線後隨 heartbeat 過期
+type StoreConnection struct {
+	ConnectedAt int64  `json:"connected_at"`
+	EdgeVersion string `json:"edge_version"`
+	ClientIp    string `json:"client_ip"`
+}
+
+func GetStoreConnectionName(storeID string) string {
+	return prefix + "store-connection:" + storeID
+}
+
+func SendStoreConnection(client *redis.Client, storeID string, conn StoreConnection, expiration time.Duration) error {
+	ctx := context.Background()
+	j, err := json.Marshal(conn)
+	if err != nil {
+		return err
+	}
+	_, err = client.Set(ctx, GetStoreConnectionName(storeID), j, expiration).Result()
+	return err
+}
+
+// GetStoreConnections 一次讀取多個商店的連線資訊，未連線的商店不會出現在結果中
+func GetStoreConnections(client *redis.Client, storeIDs []string) (map[string]StoreConnection, error) {
+	conns := make(map[string]StoreConnection, len(storeIDs))
+	if len(storeIDs) == 0 {
+		return conns, nil
+	}
+
+	ctx := context.Background()
+	keys := make([]string, 0, len(storeIDs))
+	for _, storeID := range storeIDs {
+		keys = append(keys, GetStoreConnectionName(storeID))
+	}
+	values, err := client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var conn StoreConnection
+		if err := json.Unmarshal([]byte(s), &conn); err != nil {
+			return nil, err
+		}
+		conns[storeIDs[i]] = conn
+	}
+	return conns, nil
+}
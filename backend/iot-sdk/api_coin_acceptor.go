@@ -2,6 +2,7 @@ package iotsdk
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -43,9 +44,10 @@ type getCoinAcceptorStatusResponse struct {
 
 func (m *getCoinAcceptorStatusResponse) convert() *CoinAcceptorStatus {
 	return &CoinAcceptorStatus{
-		Points: m.Points,
-		State:  m.State,
-		Ts:     m.Ts,
+		Points:    m.Points,
+		State:     m.State,
+		Ts:        m.Ts,
+		UpdatedAt: time.Now().UnixMilli(),
 	}
 }
 
@@ -70,6 +72,55 @@ func (i *iot) GetCoinAcceptorStatus(ctx context.Context, storeID uuid.UUID, devi
 	return m2.Response.convert(), nil
 }
 
+type getCoinAcceptorStatusesRequest struct {
+	StoreID   uuid.UUID `json:"store_id"`
+	DeviceIDs []string  `json:"device_ids"`
+}
+
+type getCoinAcceptorStatusesResponse struct {
+	Statuses []struct {
+		DeviceID string `json:"device_id"`
+		Points   int32  `json:"points"`
+		State    string `json:"state"`
+		Ts       int64  `json:"ts"`
+	} `json:"statuses"`
+}
+
+func (m *getCoinAcceptorStatusesResponse) convert() map[string]*CoinAcceptorStatus {
+	now := time.Now().UnixMilli()
+	statuses := make(map[string]*CoinAcceptorStatus, len(m.Statuses))
+	for _, s := range m.Statuses {
+		statuses[s.DeviceID] = &CoinAcceptorStatus{
+			Points:    s.Points,
+			State:     s.State,
+			Ts:        s.Ts,
+			UpdatedAt: now,
+		}
+	}
+	return statuses
+}
+
+func (i *iot) GetCoinAcceptorStatuses(ctx context.Context, storeID uuid.UUID, deviceIDs []string) (map[string]*CoinAcceptorStatus, error) {
+	m2, err := rpc[
+		getCoinAcceptorStatusesRequest,
+		getCoinAcceptorStatusesResponse,
+	](
+		ctx,
+		i.rpcRepo,
+		"get-coin-acceptor-statuses",
+		getCoinAcceptorStatusesRequest{
+			StoreID:   storeID,
+			DeviceIDs: deviceIDs,
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m2.Response.convert(), nil
+}
+
 type blinkCoinAcceptorRequest struct {
 	StoreID  uuid.UUID `json:"store_id"`
 	DeviceID string    `json:"device_id"`
@@ -0,0 +1,85 @@
+package iotsdk
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type getEdgeSystemInfoRequest struct {
+	StoreID uuid.UUID `json:"store_id"`
+}
+
+type getEdgeSystemInfoResponse struct {
+	EdgeVersion string `json:"edge_version"`
+}
+
+func (i *iot) GetEdgeSystemInfo(ctx context.Context, storeID uuid.UUID) (*EdgeSystemInfo, error) {
+	m2, err := rpc[
+		getEdgeSystemInfoRequest,
+		getEdgeSystemInfoResponse,
+	](
+		ctx,
+		i.rpcRepo,
+		"get-edge-system-info",
+		getEdgeSystemInfoRequest{
+			StoreID: storeID,
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &EdgeSystemInfo{
+		EdgeVersion: m2.Response.EdgeVersion,
+	}, nil
+}
+
+type getEdgeDiagnosticsRequest struct {
+	StoreID uuid.UUID `json:"store_id"`
+}
+
+type getEdgeDiagnosticsResponse struct {
+	EdgeVersion                string `json:"edge_version"`
+	UptimeMs                   int64  `json:"uptime_ms"`
+	CoinAcceptorCount          int32  `json:"coin_acceptor_count"`
+	UnuploadedRecords          int32  `json:"unuploaded_records"`
+	UnuploadedRecordsTruncated bool   `json:"unuploaded_records_truncated"`
+	MqttConnected              bool   `json:"mqtt_connected"`
+	DiskTotalBytes             uint64 `json:"disk_total_bytes"`
+	DiskFreeBytes              uint64 `json:"disk_free_bytes"`
+}
+
+func (m *getEdgeDiagnosticsResponse) convert() *EdgeDiagnostics {
+	return &EdgeDiagnostics{
+		EdgeVersion:                m.EdgeVersion,
+		UptimeMs:                   m.UptimeMs,
+		CoinAcceptorCount:          m.CoinAcceptorCount,
+		UnuploadedRecords:          m.UnuploadedRecords,
+		UnuploadedRecordsTruncated: m.UnuploadedRecordsTruncated,
+		MqttConnected:              m.MqttConnected,
+		DiskTotalBytes:             m.DiskTotalBytes,
+		DiskFreeBytes:              m.DiskFreeBytes,
+	}
+}
+
+func (i *iot) GetEdgeDiagnostics(ctx context.Context, storeID uuid.UUID) (*EdgeDiagnostics, error) {
+	m2, err := rpc[
+		getEdgeDiagnosticsRequest,
+		getEdgeDiagnosticsResponse,
+	](
+		ctx,
+		i.rpcRepo,
+		"get-edge-diagnostics",
+		getEdgeDiagnosticsRequest{
+			StoreID: storeID,
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m2.Response.convert(), nil
+}
@@ -2,8 +2,10 @@ package iotsdk
 
 import (
 	"backend/heartbeat"
+	"backend/statuscache"
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	goredislib "github.com/redis/go-redis/v9"
@@ -27,6 +29,28 @@ func New(url, appName string, rc *goredislib.Client) (*iotDecorator, error) {
 	return d, nil
 }
 
+func (d *iotDecorator) GetEdgeSystemInfo(ctx context.Context, storeID uuid.UUID) (*EdgeSystemInfo, error) {
+	exist, err := heartbeat.CheckHeartbeat(d.redisClient, heartbeat.GetStoreIDHeartbeatName(storeID.String()))
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, &StoreNotFoundError{S: fmt.Sprintf("store not found, store_id=%s", storeID)}
+	}
+	return d.iot.GetEdgeSystemInfo(ctx, storeID)
+}
+
+func (d *iotDecorator) GetEdgeDiagnostics(ctx context.Context, storeID uuid.UUID) (*EdgeDiagnostics, error) {
+	exist, err := heartbeat.CheckHeartbeat(d.redisClient, heartbeat.GetStoreIDHeartbeatName(storeID.String()))
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, &StoreNotFoundError{S: fmt.Sprintf("store not found, store_id=%s", storeID)}
+	}
+	return d.iot.GetEdgeDiagnostics(ctx, storeID)
+}
+
 func (d *iotDecorator) AddPointsToCoinAcceptor(ctx context.Context, storeID uuid.UUID, deviceID string, amount int32) error {
 	exist, err := heartbeat.CheckHeartbeat(d.redisClient, heartbeat.GetStoreIDHeartbeatName(storeID.String()))
 	if err != nil {
@@ -48,3 +72,98 @@ func (d *iotDecorator) GetCoinAcceptorStatus(ctx context.Context, storeID uuid.U
 	}
 	return d.iot.GetCoinAcceptorStatus(ctx, storeID, deviceID)
 }
+
+func (d *iotDecorator) GetCoinAcceptorStatuses(ctx context.Context, storeID uuid.UUID, deviceIDs []string) (map[string]*CoinAcceptorStatus, error) {
+	exist, err := heartbeat.CheckHeartbeat(d.redisClient, heartbeat.GetStoreIDHeartbeatName(storeID.String()))
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, &StoreNotFoundError{S: fmt.Sprintf("store not found, store_id=%s", storeID)}
+	}
+	return d.iot.GetCoinAcceptorStatuses(ctx, storeID, deviceIDs)
+}
+
+func (d *iotDecorator) getFreshCachedCoinAcceptorStatuses(storeID uuid.UUID, deviceIDs []string, maxAge time.Duration) (map[string]*CoinAcceptorStatus, error) {
+	cached, err := statuscache.GetCoinAcceptorStatuses(d.redisClient, storeID.String(), deviceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	minUpdatedAt := time.Now().Add(-maxAge).UnixMilli()
+	statuses := make(map[string]*CoinAcceptorStatus, len(cached))
+	for deviceID, status := range cached {
+		if status.UpdatedAt < minUpdatedAt {
+			continue
+		}
+		statuses[deviceID] = &CoinAcceptorStatus{
+			Points:    status.Points,
+			State:     status.State,
+			Ts:        status.Ts,
+			UpdatedAt: status.UpdatedAt,
+		}
+	}
+	return statuses, nil
+}
+
+func (d *iotDecorator) GetCachedCoinAcceptorStatus(ctx context.Context, storeID uuid.UUID, deviceID string, maxAge time.Duration) (*CoinAcceptorStatus, error) {
+	statuses, err := d.getFreshCachedCoinAcceptorStatuses(storeID, []string{deviceID}, maxAge)
+	if err != nil {
+		return nil, err
+	}
+	if status, ok := statuses[deviceID]; ok {
+		return status, nil
+	}
+	return d.GetCoinAcceptorStatus(ctx, storeID, deviceID)
+}
+
+func (d *iotDecorator) GetCachedCoinAcceptorStatuses(ctx context.Context, storeID uuid.UUID, deviceIDs []string, maxAge time.Duration) (map[string]*CoinAcceptorStatus, error) {
+	statuses, err := d.getFreshCachedCoinAcceptorStatuses(storeID, deviceIDs, maxAge)
+	if err != nil {
+		return nil, err
+	}
+
+	staleDeviceIDs := make([]string, 0, len(deviceIDs))
+	for _, deviceID := range deviceIDs {
+		if _, ok := statuses[deviceID]; !ok {
+			staleDeviceIDs = append(staleDeviceIDs, deviceID)
+		}
+	}
+	if len(staleDeviceIDs) == 0 {
+		return statuses, nil
+	}
+
+	// 即時查詢失敗時仍回傳已快取的狀態
+	live, err := d.GetCoinAcceptorStatuses(ctx, storeID, staleDeviceIDs)
+	if err != nil {
+		return statuses, err
+	}
+	for deviceID, status := range live {
+		statuses[deviceID] = status
+	}
+	return statuses, nil
+}
+
+func (d *iotDecorator) GetStoreConnections(ctx context.Context, storeIDs []uuid.UUID) (map[uuid.UUID]StoreConnection, error) {
+	ids := make([]string, 0, len(storeIDs))
+	for _, storeID := range storeIDs {
+		ids = append(ids, storeID.String())
+	}
+
+	conns, err := heartbeat.GetStoreConnections(d.redisClient, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[uuid.UUID]StoreConnection, len(conns))
+	for _, storeID := range storeIDs {
+		if conn, ok := conns[storeID.String()]; ok {
+			res[storeID] = StoreConnection{
+				ConnectedAt: conn.ConnectedAt,
+				EdgeVersion: conn.EdgeVersion,
+				ClientIp:    conn.ClientIp,
+			}
+		}
+	}
+	return res, nil
+}
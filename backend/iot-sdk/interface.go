@@ -12,9 +12,21 @@ type IoT interface {
 	GetDefaultTimeout() time.Duration
 	Close()
 
+	GetEdgeSystemInfo(ctx context.Context, storeID uuid.UUID) (*EdgeSystemInfo, error)
+	GetEdgeDiagnostics(ctx context.Context, storeID uuid.UUID) (*EdgeDiagnostics, error)
+
 	AddPointsToCoinAcceptor(ctx context.Context, storeID uuid.UUID, deviceID string, amount int32) error
 	GetCoinAcceptorStatus(ctx context.Context, storeID uuid.UUID, deviceID string) (*CoinAcceptorStatus, error)
+	// GetCoinAcceptorStatuses 一次查詢多台投幣器，未連線或逾時的投幣器不會出現在結果中
+	GetCoinAcceptorStatuses(ctx context.Context, storeID uuid.UUID, deviceIDs []string) (map[string]*CoinAcceptorStatus, error)
+	// GetCachedCoinAcceptorStatus 優先讀取 iot 快取的狀態，超過 maxAge 才即時查詢投幣器
+	GetCachedCoinAcceptorStatus(ctx context.Context, storeID uuid.UUID, deviceID string, maxAge time.Duration) (*CoinAcceptorStatus, error)
+	// GetCachedCoinAcceptorStatuses 即時查詢失敗時會同時回傳已快取的狀態與錯誤
+	GetCachedCoinAcceptorStatuses(ctx context.Context, storeID uuid.UUID, deviceIDs []string, maxAge time.Duration) (map[string]*CoinAcceptorStatus, error)
 	BlinkCoinAcceptor(ctx context.Context, storeID uuid.UUID, deviceID string) error
 
+	// GetStoreConnections 讀取 heartbeat 的連線資訊，未連線的商店不會出現在結果中
+	GetStoreConnections(ctx context.Context, storeIDs []uuid.UUID) (map[uuid.UUID]StoreConnection, error)
+
 	SubCoinAcceptorStatusChangedEvent() (ch <-chan CoinAcceptorStatusChangedEvent, cancel func())
 }
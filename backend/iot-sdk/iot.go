@@ -10,7 +10,7 @@ type iot struct {
 	eventClient *eventClient
 }
 
-var _ IoT = (*iot)(nil)
+var _ IoT = (*iotDecorator)(nil)
 
 func newIot(url, appName string) (*iot, error) {
 	bs := newBroadcastService()
@@ -6,6 +6,8 @@ type CoinAcceptorStatus struct {
 	Points int32  `json:"points"`
 	State  string `json:"state"`
 	Ts     int64  `json:"ts"`
+	// UpdatedAt iot 得知此狀態的時間，即時查詢的結果為查詢當下
+	UpdatedAt int64 `json:"updated_at"`
 }
 
 type CoinAcceptorStatusChangedEvent struct {
@@ -15,3 +17,24 @@ type CoinAcceptorStatusChangedEvent struct {
 	State    string    `json:"state"`
 	Ts       int64     `json:"ts"`
 }
+
+type EdgeSystemInfo struct {
+	EdgeVersion string `json:"edge_version"`
+}
+
+type EdgeDiagnostics struct {
+	EdgeVersion                string `json:"edge_version"`
+	UptimeMs                   int64  `json:"uptime_ms"`
+	CoinAcceptorCount          int32  `json:"coin_acceptor_count"`
+	UnuploadedRecords          int32  `json:"unuploaded_records"`
+	UnuploadedRecordsTruncated bool   `json:"unuploaded_records_truncated"`
+	MqttConnected              bool   `json:"mqtt_connected"`
+	DiskTotalBytes             uint64 `json:"disk_total_bytes"`
+	DiskFreeBytes              uint64 `json:"disk_free_bytes"`
+}
+
+type StoreConnection struct {
+	ConnectedAt int64  `json:"connected_at"`
+	EdgeVersion string `json:"edge_version"`
+	ClientIp    string `json:"client_ip"`
+}
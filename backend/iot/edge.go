@@ -5,9 +5,11 @@ import (
 	logutil "backend/util/log"
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	goredislib "github.com/redis/go-redis/v9"
 )
 
 type Error struct {
@@ -28,15 +30,15 @@ type Edge struct {
 	eventHandler    func(bytes []byte, m3 MessageType3[WsEvent])
 }
 
-func NewEdge(store db.IStore, storeID uuid.UUID, userAgent, clientIp string, conn *websocket.Conn, r *RbmqRepo) *Edge {
+func NewEdge(store db.IStore, rc *goredislib.Client, storeID uuid.UUID, userAgent, clientIp string, conn *websocket.Conn, r *RbmqRepo, sessionTimeout time.Duration) *Edge {
 	c := &Edge{conn: conn}
 	c.toClientChan = make(chan []byte, 100)
 	rpcRepo := newRpcRepo(c.toClientChan)
 	c.rpcRepo = rpcRepo
 
-	c.requestHandler = newIotWsCtrl(store, storeID, userAgent, clientIp, c.toClientChan).handleRequest
+	c.requestHandler = newIotWsCtrl(store, storeID, userAgent, clientIp, c.toClientChan, sessionTimeout).handleRequest
 	c.responseHandler = rpcRepo.handleResponse
-	c.eventHandler = newEdgeEventCtrl(r, storeID).handleEvent
+	c.eventHandler = newEdgeEventCtrl(r, rc, storeID).handleEvent
 	return c
 }
 
@@ -117,6 +119,29 @@ func (c *Edge) GetEdgeSystemInfo(ctx context.Context) (*EdgeSystemInfo, error) {
 	}, nil
 }
 
+func (c *Edge) GetEdgeDiagnostics(ctx context.Context) (*EdgeDiagnostics, error) {
+	m2, err := Rpc[struct{}](
+		ctx,
+		c.rpcRepo,
+		"get-edge-diagnostics",
+		struct{}{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &EdgeDiagnostics{
+		EdgeVersion:                m2.Response.EdgeVersion,
+		UptimeMs:                   m2.Response.UptimeMs,
+		CoinAcceptorCount:          m2.Response.CoinAcceptorCount,
+		UnuploadedRecords:          m2.Response.UnuploadedRecords,
+		UnuploadedRecordsTruncated: m2.Response.UnuploadedRecordsTruncated,
+		MqttConnected:              m2.Response.MqttConnected,
+		DiskTotalBytes:             m2.Response.DiskTotalBytes,
+		DiskFreeBytes:              m2.Response.DiskFreeBytes,
+	}, nil
+}
+
 type addPointsToCoinAcceptorRequest struct {
 	DeviceID string `json:"device_id"`
 	Amount   int32  `json:"amount"`
@@ -180,6 +205,26 @@ func (c *Edge) GetCoinAcceptorStatus(ctx context.Context, deviceID string) (*Coi
 	}, nil
 }
 
+type getCoinAcceptorStatusesRequest struct {
+	DeviceIDs []string `json:"device_ids"`
+}
+
+func (c *Edge) GetCoinAcceptorStatuses(ctx context.Context, deviceIDs []string) ([]DeviceCoinAcceptorStatus, error) {
+	m2, err := Rpc[getCoinAcceptorStatusesRequest](
+		ctx,
+		c.rpcRepo,
+		"get-coin-acceptor-statuses",
+		getCoinAcceptorStatusesRequest{
+			DeviceIDs: deviceIDs,
+		})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m2.Response.Statuses, nil
+}
+
 type blinkCoinAcceptorRequest struct {
 	DeviceID string `json:"device_id"`
 }
@@ -105,6 +105,17 @@ type EdgeSystemInfo struct {
 	EdgeVersion string `json:"edge_version"`
 }
 
+type EdgeDiagnostics struct {
+	EdgeVersion                string `json:"edge_version"`
+	UptimeMs                   int64  `json:"uptime_ms"`
+	CoinAcceptorCount          int32  `json:"coin_acceptor_count"`
+	UnuploadedRecords          int32  `json:"unuploaded_records"`
+	UnuploadedRecordsTruncated bool   `json:"unuploaded_records_truncated"`
+	MqttConnected              bool   `json:"mqtt_connected"`
+	DiskTotalBytes             uint64 `json:"disk_total_bytes"`
+	DiskFreeBytes              uint64 `json:"disk_free_bytes"`
+}
+
 type CoinAcceptorInfo struct {
 	FirmwareVersion string `json:"firmware_version"`
 }
@@ -114,3 +125,10 @@ type CoinAcceptorStatus struct {
 	State  string `json:"state"`
 	Ts     int64  `json:"ts"`
 }
+
+type DeviceCoinAcceptorStatus struct {
+	DeviceID string `json:"device_id"`
+	Points   int32  `json:"points"`
+	State    string `json:"state"`
+	Ts       int64  `json:"ts"`
+}
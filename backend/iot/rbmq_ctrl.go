@@ -1,6 +1,7 @@
 package iot
 
 import (
+	"backend/statuscache"
 	logutil "backend/util/log"
 	"context"
 	"encoding/json"
@@ -8,21 +9,25 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	goredislib "github.com/redis/go-redis/v9"
 )
 
 type RbmqCtrl struct {
 	edgeMapService *EdgeMapService
 	resRepo        *RbmqRepo
+	redisClient    *goredislib.Client
 	handlers       map[string]func(RbmqRequest) (any, *Error, bool)
 }
 
-func NewRbmqCtrl(ems *EdgeMapService, r *RbmqRepo) *RbmqCtrl {
-	c := &RbmqCtrl{edgeMapService: ems, resRepo: r}
+func NewRbmqCtrl(ems *EdgeMapService, r *RbmqRepo, rc *goredislib.Client) *RbmqCtrl {
+	c := &RbmqCtrl{edgeMapService: ems, resRepo: r, redisClient: rc}
 	c.handlers = map[string]func(RbmqRequest) (any, *Error, bool){
 		"get-edge-system-info":        c.getEdgeSystemInfo,
+		"get-edge-diagnostics":        c.getEdgeDiagnostics,
 		"add-points-to-coin-acceptor": c.addPointsToCoinAcceptor,
 		"get-coin-acceptor-info":      c.getCoinAcceptorInfo,
 		"get-coin-acceptor-status":    c.getCoinAcceptorStatus,
+		"get-coin-acceptor-statuses":  c.getCoinAcceptorStatuses,
 		"blink-coin-acceptor":         c.BlinkCoinAcceptor,
 	}
 	return c
@@ -104,6 +109,36 @@ func (c *RbmqCtrl) getEdgeSystemInfo(req RbmqRequest) (any, *Error, bool) {
 	}, nil, false
 }
 
+func (c *RbmqCtrl) getEdgeDiagnostics(req RbmqRequest) (any, *Error, bool) {
+	if req.StoreID == nil || *req.StoreID == "" {
+		return nil, nil, true
+	}
+
+	storeID, err := uuid.Parse(*req.StoreID)
+	if err != nil {
+		return nil, nil, true
+	}
+
+	edge := c.edgeMapService.Get(storeID)
+	if edge == nil {
+		return nil, nil, true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	diagnostics, err := edge.GetEdgeDiagnostics(ctx)
+	if err != nil {
+		if err == ErrRPCRequestTimeout {
+			return nil, &Error{Code: codeInternalError, Message: "internal error"}, false
+		}
+		logutil.GetLogger().Errorf("get edge diagnostics error, err=%s, store_id=%s", err, storeID)
+		return nil, &Error{Code: codeInternalError, Message: fmt.Sprintf("get edge diagnostics error, store_id=%s", storeID)}, false
+	}
+
+	return diagnostics, nil, false
+}
+
 func (c *RbmqCtrl) addPointsToCoinAcceptor(req RbmqRequest) (any, *Error, bool) {
 	if req.StoreID == nil || *req.StoreID == "" {
 		return nil, nil, true
@@ -221,9 +256,56 @@ func (c *RbmqCtrl) getCoinAcceptorStatus(req RbmqRequest) (any, *Error, bool) {
 		}
 	}
 
+	if err := statuscache.SetCoinAcceptorStatus(c.redisClient, storeID.String(), *req.DeviceID, status.Points, status.State, status.Ts); err != nil {
+		logutil.GetLogger().Errorf("set coin acceptor status cache error, err=%s, store_id=%s, device_id=%s", err, storeID, *req.DeviceID)
+	}
+
 	return status, nil, false
 }
 
+func (c *RbmqCtrl) getCoinAcceptorStatuses(req RbmqRequest) (any, *Error, bool) {
+	if req.StoreID == nil || *req.StoreID == "" {
+		return nil, nil, true
+	}
+
+	storeID, err := uuid.Parse(*req.StoreID)
+	if err != nil {
+		return nil, nil, true
+	}
+
+	edge := c.edgeMapService.Get(storeID)
+	if edge == nil {
+		return nil, nil, true
+	}
+
+	if len(req.DeviceIDs) == 0 {
+		return nil, &Error{Code: codeInvalidParameterError, Message: "device_ids is null or empty"}, false
+	}
+
+	// edge 對每台投幣器的查詢逾時為 1 秒，需多保留傳輸的時間
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	statuses, err := edge.GetCoinAcceptorStatuses(ctx, req.DeviceIDs)
+	if err != nil {
+		if err == ErrRPCRequestTimeout {
+			return nil, &Error{Code: codeInternalError, Message: "internal error"}, false
+		}
+		logutil.GetLogger().Errorf("get coin acceptor statuses error, err=%s, store_id=%s, device_ids=%v", err, storeID, req.DeviceIDs)
+		return nil, &Error{Code: codeInternalError, Message: fmt.Sprintf("get coin acceptor statuses error, store_id=%s", storeID)}, false
+	}
+
+	for _, status := range statuses {
+		if err := statuscache.SetCoinAcceptorStatus(c.redisClient, storeID.String(), status.DeviceID, status.Points, status.State, status.Ts); err != nil {
+			logutil.GetLogger().Errorf("set coin acceptor status cache error, err=%s, store_id=%s, device_id=%s", err, storeID, status.DeviceID)
+		}
+	}
+
+	return map[string]any{
+		"statuses": statuses,
+	}, nil, false
+}
+
 func (c *RbmqCtrl) BlinkCoinAcceptor(req RbmqRequest) (any, *Error, bool) {
 	if req.StoreID == nil || *req.StoreID == "" {
 		return nil, nil, true
@@ -1,7 +1,8 @@
 package iot
 
 type RbmqRequest struct {
-	StoreID  *string `json:"store_id"`
-	DeviceID *string `json:"device_id"`
-	Amount   *int32  `json:"amount"`
+	StoreID   *string  `json:"store_id"`
+	DeviceID  *string  `json:"device_id"`
+	DeviceIDs []string `json:"device_ids"`
+	Amount    *int32   `json:"amount"`
 }
@@ -31,10 +31,13 @@ type WsCtrl struct {
 	redisSync      *redsync.Redsync
 	edgeMapService *EdgeMapService
 	storeEventRepo *RbmqRepo
+
+	// 機台超過此時間未回報連線即視為離線
+	sessionTimeout time.Duration
 }
 
-func NewWsCtrl(store db.IStore, rc *goredislib.Client, rs *redsync.Redsync, ems *EdgeMapService, r *RbmqRepo) *WsCtrl {
-	return &WsCtrl{store: store, redisClient: rc, redisSync: rs, edgeMapService: ems, storeEventRepo: r}
+func NewWsCtrl(store db.IStore, rc *goredislib.Client, rs *redsync.Redsync, ems *EdgeMapService, r *RbmqRepo, sessionTimeout time.Duration) *WsCtrl {
+	return &WsCtrl{store: store, redisClient: rc, redisSync: rs, edgeMapService: ems, storeEventRepo: r, sessionTimeout: sessionTimeout}
 }
 
 func (ctrl *WsCtrl) HandleRequest(c *gin.Context) {
@@ -45,6 +48,7 @@ func (ctrl *WsCtrl) HandleRequest(c *gin.Context) {
 	defer conn.Close()
 
 	var storeID uuid.UUID
+	var edgeVersion string
 	loggedInChan := make(chan bool, 1)
 	m2 := MessageType2[any]{}
 	go func() {
@@ -112,6 +116,9 @@ func (ctrl *WsCtrl) HandleRequest(c *gin.Context) {
 			loggedInChan <- false
 			return
 		}
+		if m1.Request.EdgeVersion != nil {
+			edgeVersion = *m1.Request.EdgeVersion
+		}
 		m2.Response = struct{}{}
 		loggedInChan <- true
 	}()
@@ -157,6 +164,16 @@ func (ctrl *WsCtrl) HandleRequest(c *gin.Context) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// 讓 web 不需透過 rpc 即可得知商店是否連線
+	storeConnection := heartbeat.StoreConnection{
+		ConnectedAt: time.Now().UnixMilli(),
+		EdgeVersion: edgeVersion,
+		ClientIp:    c.ClientIP(),
+	}
+	if err := heartbeat.SendStoreConnection(ctrl.redisClient, storeID.String(), storeConnection, 3*time.Second); err != nil {
+		logutil.GetLogger().Errorf("send store connection error, err=%s, store_id=%s", err, storeID)
+	}
+
 	go func() {
 		logutil.GetLogger().Infof("start heartbeat, heartbeat_name=%s", heartbeat.GetStoreIDHeartbeatName(storeID.String()))
 		ticker := time.NewTicker(2900 * time.Millisecond)
@@ -166,11 +183,17 @@ func (ctrl *WsCtrl) HandleRequest(c *gin.Context) {
 				if err := heartbeat.SendHeartbeat(ctrl.redisClient, heartbeat.GetStoreIDHeartbeatName(storeID.String()), 3*time.Second); err != nil {
 					logutil.GetLogger().Errorf("send heartbeat error, err=%s, heartbeat_name=%s", err, heartbeat.GetStoreIDHeartbeatName(storeID.String()))
 				}
+				if err := heartbeat.SendStoreConnection(ctrl.redisClient, storeID.String(), storeConnection, 3*time.Second); err != nil {
+					logutil.GetLogger().Errorf("send store connection error, err=%s, store_id=%s", err, storeID)
+				}
 			case <-ctx.Done():
 				ticker.Stop()
 				if err := heartbeat.StopHeartbeat(ctrl.redisClient, heartbeat.GetStoreIDHeartbeatName(storeID.String())); err != nil {
 					logutil.GetLogger().Errorf("stop heartbeat error, err=%s, heartbeat_name=%s", err, heartbeat.GetStoreIDHeartbeatName(storeID.String()))
 				}
+				if err := heartbeat.StopHeartbeat(ctrl.redisClient, heartbeat.GetStoreConnectionName(storeID.String())); err != nil {
+					logutil.GetLogger().Errorf("stop heartbeat error, err=%s, heartbeat_name=%s", err, heartbeat.GetStoreConnectionName(storeID.String()))
+				}
 				logutil.GetLogger().Infof("stop send heartbeat loop, heartbeat_name=%s", heartbeat.GetStoreIDHeartbeatName(storeID.String()))
 				return
 			}
@@ -179,7 +202,7 @@ func (ctrl *WsCtrl) HandleRequest(c *gin.Context) {
 
 	unlock()
 
-	edge := NewEdge(ctrl.store, storeID, c.Request.UserAgent(), c.ClientIP(), conn, ctrl.storeEventRepo)
+	edge := NewEdge(ctrl.store, ctrl.redisClient, storeID, c.Request.UserAgent(), c.ClientIP(), conn, ctrl.storeEventRepo, ctrl.sessionTimeout)
 
 	ctrl.edgeMapService.Add(storeID, edge)
 	defer ctrl.edgeMapService.Delete(storeID)
@@ -187,6 +210,11 @@ func (ctrl *WsCtrl) HandleRequest(c *gin.Context) {
 	closedChan := make(chan struct{})
 	go edge.readLoop(closedChan)
 	edge.writeLoop(closedChan)
+
+	// edge 斷線後無法再得知機台狀態，以最後一次回報的時間結束所有連線中的 session
+	if err := ctrl.store.EndStoreDeviceSessionsByStoreID(context.Background(), storeID); err != nil {
+		logutil.GetLogger().Errorf("end store device sessions by store id error, err=%s, store_id=%s", err, storeID)
+	}
 }
 
 // ws server ctrl
@@ -196,21 +224,26 @@ type iotWsCtrl struct {
 	userAgent string
 	clientIp  string
 
+	sessionTimeout time.Duration
+
 	handlers     map[string]func(req WsRequest) (any, *Error)
 	toClientChan chan []byte
 }
 
-func newIotWsCtrl(store db.IStore, storeID uuid.UUID, userAgent, clientIp string, toClientChan chan []byte) *iotWsCtrl {
+func newIotWsCtrl(store db.IStore, storeID uuid.UUID, userAgent, clientIp string, toClientChan chan []byte, sessionTimeout time.Duration) *iotWsCtrl {
 	c := iotWsCtrl{
-		store:        store,
-		storeID:      storeID,
-		userAgent:    userAgent,
-		clientIp:     clientIp,
-		toClientChan: toClientChan,
+		store:          store,
+		storeID:        storeID,
+		userAgent:      userAgent,
+		clientIp:       clientIp,
+		sessionTimeout: sessionTimeout,
+		toClientChan:   toClientChan,
 	}
 	c.handlers = map[string]func(req WsRequest) (any, *Error){
 		"register-coin-acceptor":                 c.registerCoinAcceptor,
 		"add-coin-acceptor-coin-inserted-record": c.addCoinAcceptorCoinInsertedRecord,
+		"report-coin-acceptor-online":            c.reportCoinAcceptorOnline,
+		"report-coin-acceptor-offline":           c.reportCoinAcceptorOffline,
 	}
 	return &c
 }
@@ -254,7 +287,44 @@ func (c *iotWsCtrl) registerCoinAcceptor(req WsRequest) (any, *Error) {
 		return nil, &Error{Code: codeInvalidParameterError, Message: "device_id is null or empty"}
 	}
 
-	arg := db.CreateStoreDeviceWithLogParams{
+	// 已被替換下來的硬體重新連線時不再建立機台
+	arg1 := db.GetStoreDeviceRetiredHardwareIDParams{
+		StoreID:    c.storeID,
+		HardwareID: *req.DeviceID,
+	}
+	if _, err := c.store.GetStoreDeviceRetiredHardwareID(context.Background(), arg1); err == nil {
+		return struct{}{}, nil
+	} else if err != sql.ErrNoRows {
+		logutil.GetLogger().Errorf("get store device retired hardware id error, err=%s, arg=%#v", err, arg1)
+		return nil, &Error{Code: codeInternalError, Message: "internal error"}
+	}
+
+	// 已在其他商店使用中的硬體出現在此 edge 時只記錄，不建立機台，需由 admin/hq 移轉
+	arg2 := db.GetStoreDeviceInOtherStoreByHardwareIDParams{
+		HardwareID: *req.DeviceID,
+		StoreID:    c.storeID,
+	}
+	if registeredDevice, err := c.store.GetStoreDeviceInOtherStoreByHardwareID(context.Background(), arg2); err == nil {
+		arg3 := db.UpsertStoreDeviceMisplacementParams{
+			StoreID:            c.storeID,
+			HardwareID:         *req.DeviceID,
+			RegisteredStoreID:  registeredDevice.StoreID,
+			RegisteredDeviceID: registeredDevice.DeviceID,
+			FirstSeenAt:        time.Now().UnixMilli(),
+		}
+		if err := c.store.UpsertStoreDeviceMisplacement(context.Background(), arg3); err != nil {
+			logutil.GetLogger().Errorf("upsert store device misplacement error, err=%s, arg=%#v", err, arg3)
+			return nil, &Error{Code: codeInternalError, Message: "internal error"}
+		}
+		logutil.GetLogger().Warnf("store device is registered in other store, store_id=%s, hardware_id=%s, registered_store_id=%s", c.storeID, *req.DeviceID, registeredDevice.StoreID)
+		return struct{}{}, nil
+	} else if err != sql.ErrNoRows {
+		logutil.GetLogger().Errorf("get store device in other store by hardware id error, err=%s, arg=%#v", err, arg2)
+		return nil, &Error{Code: codeInternalError, Message: "internal error"}
+	}
+
+	// 新的機台需等待 owner 核准後才能投幣
+	arg4 := db.CreateStoreDeviceWithLogParams{
 		ChangedAt:        time.Now().UnixMilli(),
 		ChangeType:       db.StoreDeviceChangedTypeCreate,
 		ChangedBy:        uuid.NullUUID{Valid: false},
@@ -265,19 +335,45 @@ func (c *iotWsCtrl) registerCoinAcceptor(req WsRequest) (any, *Error) {
 		Name:             "洗衣機",
 		RealType:         db.StoreDeviceRealTypeCoinAcceptor,
 		DisplayType:      db.StoreDeviceDisplayTypeWasher,
-		State:            fsmutil.StoreDeviceStateActive,
+		State:            fsmutil.InitStoreDeviceState,
 	}
 
-	if _, err := c.store.CreateStoreDeviceWithLog(context.Background(), arg); err != nil {
+	if _, err := c.store.CreateStoreDeviceWithLog(context.Background(), arg4); err != nil {
 		if err == sql.ErrNoRows {
 			return struct{}{}, nil
 		}
-		logutil.GetLogger().Errorf("create store device with log error, err=%s, arg=%#v", err, arg)
+		logutil.GetLogger().Errorf("create store device with log error, err=%s, arg=%#v", err, arg4)
 		return nil, &Error{Code: codeInternalError, Message: "internal error"}
 	}
 	return struct{}{}, nil
 }
 
+// getStoreDeviceID edge 回報的 device_id 為硬體 ID，替換過硬體的機台需轉換為原本的 store device ID
+func (c *iotWsCtrl) getStoreDeviceID(hardwareID string) (string, error) {
+	storeDevice, err := c.store.GetStoreDeviceByHardwareID(context.Background(), db.GetStoreDeviceByHardwareIDParams{
+		StoreID:    c.storeID,
+		HardwareID: hardwareID,
+	})
+	if err == nil {
+		return storeDevice.DeviceID, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", err
+	}
+
+	retired, err := c.store.GetStoreDeviceRetiredHardwareID(context.Background(), db.GetStoreDeviceRetiredHardwareIDParams{
+		StoreID:    c.storeID,
+		HardwareID: hardwareID,
+	})
+	if err == nil {
+		return retired.DeviceID, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", err
+	}
+	return hardwareID, nil
+}
+
 func (c *iotWsCtrl) addCoinAcceptorCoinInsertedRecord(req WsRequest) (any, *Error) {
 	if req.DeviceID == nil || *req.DeviceID == "" {
 		return nil, &Error{Code: codeInvalidParameterError, Message: "device_id is null or empty"}
@@ -295,13 +391,19 @@ func (c *iotWsCtrl) addCoinAcceptorCoinInsertedRecord(req WsRequest) (any, *Erro
 		return nil, &Error{Code: codeInvalidParameterError, Message: "ts is null or smaller than or equal to 0"}
 	}
 
+	deviceID, err := c.getStoreDeviceID(*req.DeviceID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store device id error, err=%s, store_id=%s, hardware_id=%s", err, c.storeID, *req.DeviceID)
+		return nil, &Error{Code: codeInternalError, Message: "internal error"}
+	}
+
 	arg := db.CreateRecordParams{
 		CreatedUserAgent: sql.NullString{Valid: true, String: c.userAgent},
 		CreatedClientIp:  sql.NullString{Valid: true, String: c.clientIp},
 		Type:             db.RecordTypeCoinAcceptorCoinInserted,
 		StoreID:          c.storeID,
 		RecordID:         sql.NullString{Valid: true, String: *req.RecordID},
-		DeviceID:         sql.NullString{Valid: true, String: *req.DeviceID},
+		DeviceID:         sql.NullString{Valid: true, String: deviceID},
 		Amount:           *req.Amount,
 		Ts:               *req.Ts,
 	}
@@ -315,3 +417,85 @@ func (c *iotWsCtrl) addCoinAcceptorCoinInsertedRecord(req WsRequest) (any, *Erro
 	}
 	return struct{}{}, nil
 }
+
+// reportCoinAcceptorOnline edge 在投幣器健康檢查成功後定期回報，開啟或延續機台的連線 session
+func (c *iotWsCtrl) reportCoinAcceptorOnline(req WsRequest) (any, *Error) {
+	if req.DeviceID == nil || *req.DeviceID == "" {
+		return nil, &Error{Code: codeInvalidParameterError, Message: "device_id is null or empty"}
+	}
+
+	if req.Ts == nil || *req.Ts <= 0 {
+		return nil, &Error{Code: codeInvalidParameterError, Message: "ts is null or smaller than or equal to 0"}
+	}
+
+	// 只記錄此商店使用中的硬體，已替換或在其他商店使用的硬體不列入
+	arg1 := db.GetStoreDeviceByHardwareIDParams{
+		StoreID:    c.storeID,
+		HardwareID: *req.DeviceID,
+	}
+	storeDevice, err := c.store.GetStoreDeviceByHardwareID(context.Background(), arg1)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return struct{}{}, nil
+		}
+		logutil.GetLogger().Errorf("get store device by hardware id error, err=%s, arg=%#v", err, arg1)
+		return nil, &Error{Code: codeInternalError, Message: "internal error"}
+	}
+
+	// 超過 timeout 未回報的 session 以最後回報時間結束，此次回報開啟新的 session，不延續中斷的期間
+	arg2 := db.EndStaleStoreDeviceSessionParams{
+		StoreID:  c.storeID,
+		DeviceID: storeDevice.DeviceID,
+		Before:   *req.Ts - c.sessionTimeout.Milliseconds(),
+	}
+	if err := c.store.EndStaleStoreDeviceSession(context.Background(), arg2); err != nil {
+		logutil.GetLogger().Errorf("end stale store device session error, err=%s, arg=%#v", err, arg2)
+		return nil, &Error{Code: codeInternalError, Message: "internal error"}
+	}
+
+	arg3 := db.TouchStoreDeviceSessionParams{
+		StoreID:   c.storeID,
+		DeviceID:  storeDevice.DeviceID,
+		StartedAt: *req.Ts,
+	}
+	if err := c.store.TouchStoreDeviceSession(context.Background(), arg3); err != nil {
+		logutil.GetLogger().Errorf("touch store device session error, err=%s, arg=%#v", err, arg3)
+		return nil, &Error{Code: codeInternalError, Message: "internal error"}
+	}
+	return struct{}{}, nil
+}
+
+// reportCoinAcceptorOffline edge 健康檢查失敗、移除投幣器前回報，結束機台的連線 session
+func (c *iotWsCtrl) reportCoinAcceptorOffline(req WsRequest) (any, *Error) {
+	if req.DeviceID == nil || *req.DeviceID == "" {
+		return nil, &Error{Code: codeInvalidParameterError, Message: "device_id is null or empty"}
+	}
+
+	if req.Ts == nil || *req.Ts <= 0 {
+		return nil, &Error{Code: codeInvalidParameterError, Message: "ts is null or smaller than or equal to 0"}
+	}
+
+	arg1 := db.GetStoreDeviceByHardwareIDParams{
+		StoreID:    c.storeID,
+		HardwareID: *req.DeviceID,
+	}
+	storeDevice, err := c.store.GetStoreDeviceByHardwareID(context.Background(), arg1)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return struct{}{}, nil
+		}
+		logutil.GetLogger().Errorf("get store device by hardware id error, err=%s, arg=%#v", err, arg1)
+		return nil, &Error{Code: codeInternalError, Message: "internal error"}
+	}
+
+	arg2 := db.EndStoreDeviceSessionParams{
+		StoreID:  c.storeID,
+		DeviceID: storeDevice.DeviceID,
+		Ts:       *req.Ts,
+	}
+	if err := c.store.EndStoreDeviceSession(context.Background(), arg2); err != nil {
+		logutil.GetLogger().Errorf("end store device session error, err=%s, arg=%#v", err, arg2)
+		return nil, &Error{Code: codeInternalError, Message: "internal error"}
+	}
+	return struct{}{}, nil
+}
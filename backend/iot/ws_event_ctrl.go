@@ -1,21 +1,26 @@
 package iot
 
 import (
+	"backend/statuscache"
+	logutil "backend/util/log"
 	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
+	goredislib "github.com/redis/go-redis/v9"
 )
 
 type edgeEventCtrl struct {
 	storeEventRepo *RbmqRepo
+	redisClient    *goredislib.Client
 	storeID        uuid.UUID
 	handlers       map[string]func(MessageType3[WsEvent])
 }
 
-func newEdgeEventCtrl(r *RbmqRepo, storeID uuid.UUID) *edgeEventCtrl {
+func newEdgeEventCtrl(r *RbmqRepo, rc *goredislib.Client, storeID uuid.UUID) *edgeEventCtrl {
 	c := edgeEventCtrl{
 		storeEventRepo: r,
+		redisClient:    rc,
 		storeID:        storeID,
 	}
 	c.handlers = map[string]func(MessageType3[WsEvent]){
@@ -34,6 +39,10 @@ func (c *edgeEventCtrl) handleEvent(bytes []byte, m3 MessageType3[WsEvent]) {
 }
 
 func (c *edgeEventCtrl) handleCoinAcceptorStatusChangedEvent(m3 MessageType3[WsEvent]) {
+	if err := statuscache.SetCoinAcceptorStatus(c.redisClient, c.storeID.String(), m3.Event.DeviceID, m3.Event.Points, m3.Event.State, m3.Event.Ts); err != nil {
+		logutil.GetLogger().Errorf("set coin acceptor status cache error, err=%s, store_id=%s, device_id=%s", err, c.storeID, m3.Event.DeviceID)
+	}
+
 	rbmqM3 := MessageType3[any]{
 		Type: "coin-acceptor-status-changed",
 		Event: struct {
@@ -1,20 +1,29 @@
 package iot
 
 type WsRequest struct {
-	StoreID  *string `json:"store_id"`
-	Password *string `json:"password"`
-	DeviceID *string `json:"device_id"`
-	RecordID *string `json:"record_id"`
-	Amount   *int32  `json:"amount"`
-	Ts       *int64  `json:"ts"`
+	StoreID     *string `json:"store_id"`
+	Password    *string `json:"password"`
+	EdgeVersion *string `json:"edge_version"`
+	DeviceID    *string `json:"device_id"`
+	RecordID    *string `json:"record_id"`
+	Amount      *int32  `json:"amount"`
+	Ts          *int64  `json:"ts"`
 }
 
 type WsResponse struct {
-	EdgeVersion     string `json:"edge_version"`
-	FirmwareVersion string `json:"firmware_version"`
-	Points          int32  `json:"points"`
-	State           string `json:"state"`
-	Ts              int64  `json:"ts"`
+	EdgeVersion                string                     `json:"edge_version"`
+	FirmwareVersion            string                     `json:"firmware_version"`
+	Points                     int32                      `json:"points"`
+	State                      string                     `json:"state"`
+	Ts                         int64                      `json:"ts"`
+	UptimeMs                   int64                      `json:"uptime_ms"`
+	CoinAcceptorCount          int32                      `json:"coin_acceptor_count"`
+	UnuploadedRecords          int32                      `json:"unuploaded_records"`
+	UnuploadedRecordsTruncated bool                       `json:"unuploaded_records_truncated"`
+	MqttConnected              bool                       `json:"mqtt_connected"`
+	DiskTotalBytes             uint64                     `json:"disk_total_bytes"`
+	DiskFreeBytes              uint64                     `json:"disk_free_bytes"`
+	Statuses                   []DeviceCoinAcceptorStatus `json:"statuses"`
 }
 
 type WsEvent struct {
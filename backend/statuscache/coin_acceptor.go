@@ -0,0 +1,84 @@
+package statuscache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// CoinAcceptorStatus iot 最後一次得知的投幣器狀態，UpdatedAt 為 iot 寫入的時間，用來判斷資料是否過舊
+type CoinAcceptorStatus struct {
+	Points    int32  `json:"points"`
+	State     string `json:"state"`
+	Ts        int64  `json:"ts"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+var prefix = "status-cache:"
+
+// 快取只用於顯示，長時間沒有更新的機台直接過期
+var expiration = 24 * time.Hour
+
+func GetCoinAcceptorStatusName(storeID, deviceID string) string {
+	return prefix + "coin-acceptor:" + storeID + ":" + deviceID
+}
+
+// 事件與 rpc 回應可能不依序抵達，只有 ts 不比快取舊的狀態才會寫入
+var setIfNewerScript = redis.NewScript(`
+local cur = redis.call('GET', KEYS[1])
+if cur then
+	local ts = cjson.decode(cur)['ts']
+	if ts and ts > tonumber(ARGV[2]) then
+		return 0
+	end
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[3])
+return 1
+`)
+
+func SetCoinAcceptorStatus(client *redis.Client, storeID, deviceID string, points int32, state string, ts int64) error {
+	ctx := context.Background()
+	j, err := json.Marshal(CoinAcceptorStatus{
+		Points:    points,
+		State:     state,
+		Ts:        ts,
+		UpdatedAt: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return err
+	}
+	return setIfNewerScript.Run(ctx, client, []string{GetCoinAcceptorStatusName(storeID, deviceID)}, j, ts, expiration.Milliseconds()).Err()
+}
+
+// GetCoinAcceptorStatuses 沒有快取的投幣器不會出現在結果中
+func GetCoinAcceptorStatuses(client *redis.Client, storeID string, deviceIDs []string) (map[string]CoinAcceptorStatus, error) {
+	statuses := make(map[string]CoinAcceptorStatus, len(deviceIDs))
+	if len(deviceIDs) == 0 {
+		return statuses, nil
+	}
+
+	ctx := context.Background()
+	keys := make([]string, 0, len(deviceIDs))
+	for _, deviceID := range deviceIDs {
+		keys = append(keys, GetCoinAcceptorStatusName(storeID, deviceID))
+	}
+	values, err := client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var status CoinAcceptorStatus
+		if err := json.Unmarshal([]byte(s), &status); err != nil {
+			return nil, err
+		}
+		statuses[deviceIDs[i]] = status
+	}
+	return statuses, nil
+}
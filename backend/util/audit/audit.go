@@ -0,0 +1,47 @@
+package auditutil
+
+import "reflect"
+
+// RedactedValue 敏感欄位 (如密碼 hash) 在 diff 中以此值取代
+const RedactedValue = "[REDACTED]"
+
+// Field history 快照中的一個欄位，nullable 欄位的 Value 為 nil 或實際值
+type Field struct {
+	Name  string
+	Value any
+}
+
+type Change struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// Diff 比較前後兩個快照，依 curr 的欄位順序回傳有變動的欄位；prev 為 nil 表示第一筆快照
+func Diff(prev, curr []Field, redactedFields ...string) []Change {
+	prevValues := make(map[string]any, len(prev))
+	for _, f := range prev {
+		prevValues[f.Name] = f.Value
+	}
+
+	changes := []Change{}
+	for _, f := range curr {
+		old, ok := prevValues[f.Name]
+		if ok && reflect.DeepEqual(old, f.Value) {
+			continue
+		}
+
+		change := Change{Field: f.Name, Old: old, New: f.Value}
+		for _, name := range redactedFields {
+			if name == f.Name {
+				change.New = RedactedValue
+				if ok {
+					change.Old = RedactedValue
+				}
+				break
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
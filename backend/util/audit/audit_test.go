@@ -0,0 +1,44 @@
+package auditutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	prev := []Field{
+		{Name: "balance", Value: int32(100)},
+		{Name: "state", Value: "active"},
+		{Name: "password", Value: "hash1"},
+		{Name: "name", Value: nil},
+	}
+	curr := []Field{
+		{Name: "balance", Value: int32(50)},
+		{Name: "state", Value: "active"},
+		{Name: "password", Value: "hash2"},
+		{Name: "name", Value: "n"},
+	}
+
+	changes := Diff(prev, curr, "password")
+	require.Equal(t, []Change{
+		{Field: "balance", Old: int32(100), New: int32(50)},
+		{Field: "password", Old: RedactedValue, New: RedactedValue},
+		{Field: "name", Old: nil, New: "n"},
+	}, changes)
+
+	require.Empty(t, Diff(curr, curr, "password"))
+}
+
+func TestDiffFirstSnapshot(t *testing.T) {
+	curr := []Field{
+		{Name: "balance", Value: int32(0)},
+		{Name: "password", Value: "hash"},
+	}
+
+	changes := Diff(nil, curr, "password")
+	require.Equal(t, []Change{
+		{Field: "balance", Old: nil, New: int32(0)},
+		{Field: "password", Old: nil, New: RedactedValue},
+	}, changes)
+}
@@ -7,12 +7,21 @@ import (
 )
 
 type Config struct {
-	MaxPasswordAttempts   int16 `mapstructure:"max_password_attempts"`
-	MaxUserNameLength     int16 `mapstructure:"max_user_name_length"`
-	MaxStoreNameLength    int16 `mapstructure:"max_store_name_length"`
-	MaxStoreAddressLength int16 `mapstructure:"max_store_address_length"`
-	StorePasswordLength   int16 `mapstructure:"store_password_length"`
-	DB                    struct {
+	MaxPasswordAttempts   int16         `mapstructure:"max_password_attempts"`
+	MaxUserNameLength     int16         `mapstructure:"max_user_name_length"`
+	MaxStoreNameLength    int16         `mapstructure:"max_store_name_length"`
+	MaxStoreAddressLength int16         `mapstructure:"max_store_address_length"`
+	StorePasswordLength   int16         `mapstructure:"store_password_length"`
+	StoreJoinCodeLength   int           `mapstructure:"store_join_code_length"`
+	RoleCacheTTL          time.Duration `mapstructure:"role_cache_ttl"`
+	MaxAuditEntries       int32         `mapstructure:"max_audit_entries"`
+	MaxCreditLimit        int32         `mapstructure:"max_credit_limit"`
+	StoreUserWalletWindow time.Duration `mapstructure:"store_user_wallet_window"`
+	// StoreDeviceSessionTimeout 機台超過此時間未回報連線即視為離線 (edge 每 30 秒回報一次)
+	StoreDeviceSessionTimeout time.Duration `mapstructure:"store_device_session_timeout"`
+	// MaxStoreDeviceUptimeRange 機台上線率報表可查詢的最大區間
+	MaxStoreDeviceUptimeRange time.Duration `mapstructure:"max_store_device_uptime_range"`
+	DB                        struct {
 		Source string `mapstructure:"source"`
 	} `mapstructure:"database"`
 	Redis struct {
@@ -22,7 +31,8 @@ type Config struct {
 		Url string `mapstructure:"url"`
 	} `mapstructure:"rabbitmq"`
 	Web struct {
-		Port string `mapstructure:"port"`
+		Port           string   `mapstructure:"port"`
+		TrustedProxies []string `mapstructure:"trusted_proxies"`
 	} `mapstructure:"web"`
 	Iot struct {
 		Port string `mapstructure:"port"`
@@ -40,7 +50,55 @@ type Config struct {
 			LiveTime            time.Duration `mapstructure:"live_time"`
 			Length              int           `mapstructure:"length"`
 		} `mapstructure:"reset_password"`
+		ChangePhoneNumber struct {
+			MaxMsgPerTimePeriod int           `mapstructure:"max_msg_per_time_period"`
+			TimePeriod          time.Duration `mapstructure:"time_period"`
+			LiveTime            time.Duration `mapstructure:"live_time"`
+			Length              int           `mapstructure:"length"`
+		} `mapstructure:"change_phone_number"`
+		LinkExternalIdentity struct {
+			MaxMsgPerTimePeriod int           `mapstructure:"max_msg_per_time_period"`
+			TimePeriod          time.Duration `mapstructure:"time_period"`
+			LiveTime            time.Duration `mapstructure:"live_time"`
+			Length              int           `mapstructure:"length"`
+		} `mapstructure:"link_external_identity"`
 	} `mapstructure:"ver_code"`
+	PasswordPolicy struct {
+		MinLength     int      `mapstructure:"min_length"`
+		RequireUpper  bool     `mapstructure:"require_upper"`
+		RequireLower  bool     `mapstructure:"require_lower"`
+		RequireDigit  bool     `mapstructure:"require_digit"`
+		RequireSymbol bool     `mapstructure:"require_symbol"`
+		DenyList      []string `mapstructure:"deny_list"`
+		HistoryCount  int32    `mapstructure:"history_count"`
+	} `mapstructure:"password_policy"`
+	BalanceAdjustment struct {
+		ApprovalThreshold int32    `mapstructure:"approval_threshold"`
+		MaxAmount         int32    `mapstructure:"max_amount"`
+		ReasonCodes       []string `mapstructure:"reason_codes"`
+		MaxNoteLength     int      `mapstructure:"max_note_length"`
+	} `mapstructure:"balance_adjustment"`
+	BalanceTransfer struct {
+		MaxAmountPerTimePeriod int64         `mapstructure:"max_amount_per_time_period"`
+		MaxCountPerTimePeriod  int64         `mapstructure:"max_count_per_time_period"`
+		TimePeriod             time.Duration `mapstructure:"time_period"`
+	} `mapstructure:"balance_transfer"`
+	FamilyWallet struct {
+		MaxMembers        int           `mapstructure:"max_members"`
+		SpendingCapPeriod time.Duration `mapstructure:"spending_cap_period"`
+	} `mapstructure:"family_wallet"`
+	Oidc struct {
+		StateLiveTime time.Duration `mapstructure:"state_live_time"`
+		LinkLiveTime  time.Duration `mapstructure:"link_live_time"`
+		Providers     map[string]struct {
+			Issuer       string   `mapstructure:"issuer"`
+			ClientID     string   `mapstructure:"client_id"`
+			ClientSecret string   `mapstructure:"client_secret"`
+			RedirectURL  string   `mapstructure:"redirect_url"`
+			Scopes       []string `mapstructure:"scopes"`
+			SigningAlgs  []string `mapstructure:"signing_algs"`
+		} `mapstructure:"providers"`
+	} `mapstructure:"oidc"`
 	Token struct {
 		SymmetricKey         string        `mapstructure:"symmetric_key"`
 		AccessTokenDuration  time.Duration `mapstructure:"access_token_duration"`
@@ -1,5 +1,7 @@
 package distlockutil
 
+import "sort"
+
 var prefix = "mutex:"
 
 func GetEdgeStoreIDMutexName(storeID string) string {
@@ -21,3 +23,43 @@ func GetStoreIDMutexName(storeID string) string {
 func GetStoreUserIDMutexName(storeID string, userID string) string {
 	return prefix + "store-user-id:" + storeID + "+" + userID
 }
+
+// GetSortedStoreUserIDMutexNames 同時鎖定多個 store user 時須依此順序取得 lock，避免 deadlock
+func GetSortedStoreUserIDMutexNames(storeID string, userIDs ...string) []string {
+	names := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		names = append(names, GetStoreUserIDMutexName(storeID, userID))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func GetStoreDeviceIDMutexName(storeID string, deviceID string) string {
+	return prefix + "store-device-id:" + storeID + "+" + deviceID
+}
+
+// GetSortedStoreDeviceIDMutexNames 同時鎖定多個 store device 時須依此順序取得 lock，避免 deadlock
+func GetSortedStoreDeviceIDMutexNames(storeID string, deviceIDs ...string) []string {
+	names := make([]string, 0, len(deviceIDs))
+	for _, deviceID := range deviceIDs {
+		names = append(names, GetStoreDeviceIDMutexName(storeID, deviceID))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func GetOidcStateMutexName(state string) string {
+	return prefix + "oidc-state:" + state
+}
+
+func GetRoleIDMutexName(roleID string) string {
+	return prefix + "role-id:" + roleID
+}
+
+func GetStoreDeviceZoneIDMutexName(storeID, zoneID string) string {
+	return prefix + "store-device-zone-id:" + storeID + ":" + zoneID
+}
+
+func GetDeviceDisplayTypeCodeMutexName(code string) string {
+	return prefix + "device-display-type-code:" + code
+}
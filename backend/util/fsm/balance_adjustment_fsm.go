@@ -0,0 +1,23 @@
+package fsmutil
+
+import "github.com/looplab/fsm"
+
+const (
+	BalanceAdjustmentStatePending  string = "pending"
+	BalanceAdjustmentStateApplied  string = "applied"
+	BalanceAdjustmentStateRejected string = "rejected"
+
+	BalanceAdjustmentEventApprove string = "approve"
+	BalanceAdjustmentEventReject  string = "reject"
+)
+
+func NewBalanceAdjustmentFSM(initState string) *fsm.FSM {
+	return fsm.NewFSM(
+		initState,
+		fsm.Events{
+			{Name: BalanceAdjustmentEventApprove, Src: []string{BalanceAdjustmentStatePending}, Dst: BalanceAdjustmentStateApplied},
+			{Name: BalanceAdjustmentEventReject, Src: []string{BalanceAdjustmentStatePending}, Dst: BalanceAdjustmentStateRejected},
+		},
+		map[string]fsm.Callback{},
+	)
+}
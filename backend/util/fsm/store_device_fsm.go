@@ -1,5 +1,47 @@
 package fsmutil
 
+import "github.com/looplab/fsm"
+
 const (
-	StoreDeviceStateActive string = "active"
+	StoreDeviceStatePendingApproval string = "pending_approval"
+	StoreDeviceStateActive          string = "active"
+	StoreDeviceStateMaintenance     string = "maintenance"
+	StoreDeviceStateDisabled        string = "disabled"
+	StoreDeviceStateDecommissioned  string = "decommissioned"
+	StoreDeviceStateArchived        string = "archived"
+	InitStoreDeviceState            string = StoreDeviceStatePendingApproval
+
+	StoreDeviceEventApprove           string = "approve"
+	StoreDeviceEventStartMaintenance  string = "start_maintenance"
+	StoreDeviceEventFinishMaintenance string = "finish_maintenance"
+	StoreDeviceEventDisable           string = "disable"
+	StoreDeviceEventEnable            string = "enable"
+	StoreDeviceEventDecommission      string = "decommission"
+	StoreDeviceEventTransferOut       string = "transfer_out"
 )
+
+// StoreDeviceStates 所有的 store device state，用於檢查查詢條件是否合法
+var StoreDeviceStates = []string{
+	StoreDeviceStatePendingApproval,
+	StoreDeviceStateActive,
+	StoreDeviceStateMaintenance,
+	StoreDeviceStateDisabled,
+	StoreDeviceStateDecommissioned,
+	StoreDeviceStateArchived,
+}
+
+func NewStoreDeviceFSM(initState string) *fsm.FSM {
+	return fsm.NewFSM(
+		initState,
+		fsm.Events{
+			{Name: StoreDeviceEventApprove, Src: []string{StoreDeviceStatePendingApproval}, Dst: StoreDeviceStateActive},
+			{Name: StoreDeviceEventStartMaintenance, Src: []string{StoreDeviceStateActive}, Dst: StoreDeviceStateMaintenance},
+			{Name: StoreDeviceEventFinishMaintenance, Src: []string{StoreDeviceStateMaintenance}, Dst: StoreDeviceStateActive},
+			{Name: StoreDeviceEventDisable, Src: []string{StoreDeviceStateActive, StoreDeviceStateMaintenance}, Dst: StoreDeviceStateDisabled},
+			{Name: StoreDeviceEventEnable, Src: []string{StoreDeviceStateDisabled}, Dst: StoreDeviceStateActive},
+			{Name: StoreDeviceEventDecommission, Src: []string{StoreDeviceStatePendingApproval, StoreDeviceStateActive, StoreDeviceStateMaintenance, StoreDeviceStateDisabled}, Dst: StoreDeviceStateDecommissioned},
+			{Name: StoreDeviceEventTransferOut, Src: []string{StoreDeviceStatePendingApproval, StoreDeviceStateActive, StoreDeviceStateMaintenance, StoreDeviceStateDisabled}, Dst: StoreDeviceStateArchived},
+		},
+		map[string]fsm.Callback{},
+	)
+}
@@ -5,20 +5,31 @@ import "github.com/looplab/fsm"
 const (
 	StoreUserStateActive   string = "active"
 	StoreUserStateArchived string = "archived"
+	StoreUserStatePending  string = "pending"
 	InitStoreUserState     string = StoreUserStateActive
 
-	StoreUserEventDeactive  string = "deactive"
-	StoreUserEventEnable    string = "enable"
-	StoreUserEventCashTopUp string = "cash_top_up"
+	StoreUserEventDeactive          string = "deactive"
+	StoreUserEventEnable            string = "enable"
+	StoreUserEventCashTopUp         string = "cash_top_up"
+	StoreUserEventInvite            string = "invite"
+	StoreUserEventAcceptInvitation  string = "accept_invitation"
+	StoreUserEventDeclineInvitation string = "decline_invitation"
+	StoreUserEventAdjustBalance     string = "adjust_balance"
+	StoreUserEventTransferBalance   string = "transfer_balance"
 )
 
 func NewStoreUserFSM(initState string) *fsm.FSM {
 	return fsm.NewFSM(
 		initState,
 		fsm.Events{
-			{Name: StoreUserEventDeactive, Src: []string{StoreUserStateActive}, Dst: StoreUserStateArchived},
+			{Name: StoreUserEventDeactive, Src: []string{StoreUserStateActive, StoreUserStatePending}, Dst: StoreUserStateArchived},
 			{Name: StoreUserEventEnable, Src: []string{StoreUserStateArchived}, Dst: StoreUserStateActive},
 			{Name: StoreUserEventCashTopUp, Src: []string{StoreUserStateActive}, Dst: StoreUserStateActive},
+			{Name: StoreUserEventInvite, Src: []string{StoreUserStateArchived, StoreUserStatePending}, Dst: StoreUserStatePending},
+			{Name: StoreUserEventAcceptInvitation, Src: []string{StoreUserStatePending}, Dst: StoreUserStateActive},
+			{Name: StoreUserEventDeclineInvitation, Src: []string{StoreUserStatePending}, Dst: StoreUserStateArchived},
+			{Name: StoreUserEventAdjustBalance, Src: []string{StoreUserStateActive}, Dst: StoreUserStateActive},
+			{Name: StoreUserEventTransferBalance, Src: []string{StoreUserStateActive}, Dst: StoreUserStateActive},
 		},
 		map[string]fsm.Callback{},
 	)
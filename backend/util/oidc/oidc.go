@@ -0,0 +1,412 @@
+package oidcutil
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var (
+	ErrDiscoveryFailed     = errors.New("failed to get openid configuration")
+	ErrExchangeCodeFailed  = errors.New("failed to exchange authorization code")
+	ErrJWKSFailed          = errors.New("failed to get jwks")
+	ErrKeyNotFound         = errors.New("signing key not found in jwks")
+	ErrInvalidIDToken      = errors.New("invalid id token")
+	ErrInvalidIDTokenClaim = errors.New("invalid id token claim")
+)
+
+type ProviderConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// SigningAlgs 允許的 id token 簽章演算法，未設定時使用 discovery 的 id_token_signing_alg_values_supported 並排除 HMAC，
+	// HMAC (以 client secret 簽章，如 LINE) 只在明確設定時允許，避免以 client secret 偽造 id token
+	SigningAlgs []string
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type IDTokenClaims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	IssuedAt  int64    `json:"iat"`
+	Nonce     string   `json:"nonce"`
+	Name      string   `json:"name"`
+	Email     string   `json:"email"`
+}
+
+// Valid 只檢查時間，iss、aud、nonce 在 VerifyIDToken 中檢查
+func (c *IDTokenClaims) Valid() error {
+	now := time.Now().Unix()
+	if c.ExpiresAt == 0 || now > c.ExpiresAt {
+		return fmt.Errorf("%w: token is expired", ErrInvalidIDTokenClaim)
+	}
+	if c.IssuedAt > now+60 {
+		return fmt.Errorf("%w: token used before issued", ErrInvalidIDTokenClaim)
+	}
+	return nil
+}
+
+// audience id token 的 aud 可能是字串或字串陣列
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+	var ss []string
+	if err := json.Unmarshal(b, &ss); err != nil {
+		return err
+	}
+	*a = ss
+	return nil
+}
+
+type Provider struct {
+	config     ProviderConfig
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	discovery *discovery
+	keys      map[string]any
+}
+
+func NewProvider(config ProviderConfig) *Provider {
+	return &Provider{
+		config:     config,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// GenCodeVerifier 產生 PKCE 的 code verifier
+func GenCodeVerifier() (string, error) {
+	return randomURLSafeString(32)
+}
+
+// GenState 產生 state 或 nonce
+func GenState() (string, error) {
+	return randomURLSafeString(24)
+}
+
+// CodeChallengeS256 依 RFC 7636 計算 S256 code challenge
+func CodeChallengeS256(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomURLSafeString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (p *Provider) getDiscovery(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	wellKnown := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	var d discovery
+	if err := p.getJSON(ctx, wellKnown, &d); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDiscoveryFailed, err)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JwksURI == "" {
+		return nil, fmt.Errorf("%w: missing endpoint", ErrDiscoveryFailed)
+	}
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code, status_code=%d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// AuthCodeURL 產生導向 provider 登入頁的網址
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := p.config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid"}
+	}
+
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", p.config.ClientID)
+	v.Set("redirect_uri", p.config.RedirectURL)
+	v.Set("scope", strings.Join(scopes, " "))
+	v.Set("state", state)
+	v.Set("nonce", nonce)
+	v.Set("code_challenge", CodeChallengeS256(codeVerifier))
+	v.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + v.Encode(), nil
+}
+
+// Exchange 以 authorization code 與 code verifier 換取 id token
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	v := url.Values{}
+	v.Set("grant_type", "authorization_code")
+	v.Set("code", code)
+	v.Set("redirect_uri", p.config.RedirectURL)
+	v.Set("client_id", p.config.ClientID)
+	v.Set("client_secret", p.config.ClientSecret)
+	v.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(v.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrExchangeCodeFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: status_code=%d", ErrExchangeCodeFailed, resp.StatusCode)
+	}
+
+	var body struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrExchangeCodeFailed, err)
+	}
+	if body.IDToken == "" {
+		return "", fmt.Errorf("%w: id_token is empty", ErrExchangeCodeFailed)
+	}
+	return body.IDToken, nil
+}
+
+// signingAlgs 取得允許的簽章演算法，見 ProviderConfig.SigningAlgs
+func (p *Provider) signingAlgs(d *discovery) []string {
+	if len(p.config.SigningAlgs) > 0 {
+		return p.config.SigningAlgs
+	}
+
+	algs := make([]string, 0, len(d.IDTokenSigningAlgValuesSupported))
+	for _, alg := range d.IDTokenSigningAlgValuesSupported {
+		if _, ok := jwt.GetSigningMethod(alg).(*jwt.SigningMethodHMAC); ok {
+			continue
+		}
+		algs = append(algs, alg)
+	}
+	if len(algs) == 0 {
+		return []string{jwt.SigningMethodRS256.Alg()}
+	}
+	return algs
+}
+
+// VerifyIDToken 驗證 id token 的簽章 (JWKS，或設定允許 HMAC 時使用 client secret) 與 iss、aud、exp、nonce
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*IDTokenClaims, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 演算法由設定決定，不信任 token header 的 alg
+	parser := &jwt.Parser{ValidMethods: p.signingAlgs(d)}
+
+	claims := &IDTokenClaims{}
+	_, err = parser.ParseWithClaims(rawIDToken, claims, func(t *jwt.Token) (any, error) {
+		switch t.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			// LINE web login 的 id token 以 channel secret 簽章
+			if p.config.ClientSecret == "" {
+				return nil, errors.New("client secret is empty")
+			}
+			return []byte(p.config.ClientSecret), nil
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+			kid, _ := t.Header["kid"].(string)
+			return p.getKey(ctx, d.JwksURI, kid)
+		default:
+			return nil, fmt.Errorf("unexpected signing method, alg=%v", t.Header["alg"])
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidIDToken, err)
+	}
+
+	if claims.Issuer != d.Issuer {
+		return nil, fmt.Errorf("%w: iss mismatch", ErrInvalidIDTokenClaim)
+	}
+	if !containsString(claims.Audience, p.config.ClientID) {
+		return nil, fmt.Errorf("%w: aud mismatch", ErrInvalidIDTokenClaim)
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDTokenClaim)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: sub is empty", ErrInvalidIDTokenClaim)
+	}
+	return claims, nil
+}
+
+// getKey 從快取取得 key，找不到時重新下載 JWKS (provider 可能已輪替 key)
+func (p *Provider) getKey(ctx context.Context, jwksURI, kid string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrJWKSFailed, err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	p.keys = keys
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, ErrKeyNotFound
+}
+
+// lookupKey token 沒有 kid 且 JWKS 只有一把 key 時使用該 key
+func (p *Provider) lookupKey(kid string) (any, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve, crv=%s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type, kty=%s", k.Kty)
+	}
+}
+
+func containsString(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
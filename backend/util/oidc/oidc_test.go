@@ -0,0 +1,193 @@
+package oidcutil
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testClientID     = "test-client-id"
+	testClientSecret = "test-client-secret"
+	testKid          = "test-kid"
+)
+
+// fakeProvider 本地的假 OIDC provider，簽發 RS256 id token
+type fakeProvider struct {
+	srv        *httptest.Server
+	key        *rsa.PrivateKey
+	nonce      string
+	challenges map[string]string
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	f := &fakeProvider{key: key, challenges: map[string]string{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 f.srv.URL,
+			"authorization_endpoint": f.srv.URL + "/authorize",
+			"token_endpoint":         f.srv.URL + "/token",
+			"jwks_uri":               f.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kid": testKid,
+				"kty": "RSA",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		challenge, ok := f.challenges[r.Form.Get("code")]
+		if !ok || CodeChallengeS256(r.Form.Get("code_verifier")) != challenge || r.Form.Get("client_id") != testClientID {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": f.signIDToken(t, testClientID, f.nonce, time.Hour)})
+	})
+	f.srv = httptest.NewServer(mux)
+	return f
+}
+
+func (f *fakeProvider) signIDToken(t *testing.T, aud, nonce string, ttl time.Duration) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   f.srv.URL,
+		"sub":   "U1234567890",
+		"aud":   aud,
+		"exp":   time.Now().Add(ttl).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": nonce,
+		"name":  "tester",
+	})
+	token.Header["kid"] = testKid
+	s, err := token.SignedString(f.key)
+	require.NoError(t, err)
+	return s
+}
+
+func TestPKCE(t *testing.T) {
+	// RFC 7636 Appendix B
+	require.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", CodeChallengeS256("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+
+	v1, err := GenCodeVerifier()
+	require.NoError(t, err)
+	v2, err := GenCodeVerifier()
+	require.NoError(t, err)
+	require.NotEqual(t, v1, v2)
+	require.GreaterOrEqual(t, len(v1), 43)
+}
+
+func TestAuthCodeFlow(t *testing.T) {
+	f := newFakeProvider(t)
+	defer f.srv.Close()
+
+	p := NewProvider(ProviderConfig{
+		Issuer:       f.srv.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  "http://localhost/callback",
+		Scopes:       []string{"openid", "profile"},
+	})
+	ctx := context.Background()
+
+	state, err := GenState()
+	require.NoError(t, err)
+	nonce, err := GenState()
+	require.NoError(t, err)
+	verifier, err := GenCodeVerifier()
+	require.NoError(t, err)
+
+	authURL, err := p.AuthCodeURL(ctx, state, nonce, verifier)
+	require.NoError(t, err)
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	require.Equal(t, f.srv.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	require.Equal(t, state, u.Query().Get("state"))
+	require.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+	require.Equal(t, "openid profile", u.Query().Get("scope"))
+
+	// 模擬使用者在 provider 登入後帶著 code 回來
+	f.nonce = nonce
+	f.challenges["code-1"] = u.Query().Get("code_challenge")
+
+	_, err = p.Exchange(ctx, "code-1", "wrong-verifier")
+	require.ErrorIs(t, err, ErrExchangeCodeFailed)
+
+	idToken, err := p.Exchange(ctx, "code-1", verifier)
+	require.NoError(t, err)
+
+	claims, err := p.VerifyIDToken(ctx, idToken, nonce)
+	require.NoError(t, err)
+	require.Equal(t, "U1234567890", claims.Subject)
+	require.Equal(t, "tester", claims.Name)
+
+	_, err = p.VerifyIDToken(ctx, idToken, "other-nonce")
+	require.ErrorIs(t, err, ErrInvalidIDTokenClaim)
+}
+
+func TestVerifyIDTokenInvalid(t *testing.T) {
+	f := newFakeProvider(t)
+	defer f.srv.Close()
+
+	p := NewProvider(ProviderConfig{Issuer: f.srv.URL, ClientID: testClientID, ClientSecret: testClientSecret})
+	ctx := context.Background()
+
+	_, err := p.VerifyIDToken(ctx, f.signIDToken(t, "other-client", "n", time.Hour), "n")
+	require.ErrorIs(t, err, ErrInvalidIDTokenClaim)
+
+	_, err = p.VerifyIDToken(ctx, f.signIDToken(t, testClientID, "n", -time.Hour), "n")
+	require.ErrorIs(t, err, ErrInvalidIDToken)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": f.srv.URL, "sub": "x", "aud": testClientID, "nonce": "n",
+		"exp": time.Now().Add(time.Hour).Unix(), "iat": time.Now().Unix(),
+	})
+	token.Header["kid"] = testKid
+	forged, err := token.SignedString(otherKey)
+	require.NoError(t, err)
+	_, err = p.VerifyIDToken(ctx, forged, "n")
+	require.ErrorIs(t, err, ErrInvalidIDToken)
+
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": f.srv.URL, "sub": "line-user", "aud": []string{testClientID}, "nonce": "n",
+		"exp": time.Now().Add(time.Hour).Unix(), "iat": time.Now().Unix(),
+	})
+	signed, err := hs.SignedString([]byte(testClientSecret))
+	require.NoError(t, err)
+
+	// 未設定允許 HMAC 的 provider 不接受以 client secret 簽章的 token
+	_, err = p.VerifyIDToken(ctx, signed, "n")
+	require.ErrorIs(t, err, ErrInvalidIDToken)
+
+	line := NewProvider(ProviderConfig{Issuer: f.srv.URL, ClientID: testClientID, ClientSecret: testClientSecret, SigningAlgs: []string{"HS256"}})
+	claims, err := line.VerifyIDToken(ctx, signed, "n")
+	require.NoError(t, err)
+	require.Equal(t, "line-user", claims.Subject)
+
+	// 只允許 HMAC 的 provider 也不接受 RS256
+	_, err = line.VerifyIDToken(ctx, f.signIDToken(t, testClientID, "n", time.Hour), "n")
+	require.ErrorIs(t, err, ErrInvalidIDToken)
+}
@@ -2,6 +2,8 @@ package passwordutil
 
 import (
 	"errors"
+	"strings"
+	"unicode"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -9,9 +11,34 @@ import (
 var (
 	ErrFailedToHashPassword = errors.New("failed to hash password")
 
-	ErrInvalidPasswordLength = errors.New("invalid password length")
+	ErrInvalidPasswordLength    = errors.New("invalid password length")
+	ErrPasswordMissingUpper     = errors.New("password missing upper case letter")
+	ErrPasswordMissingLower     = errors.New("password missing lower case letter")
+	ErrPasswordMissingDigit     = errors.New("password missing digit")
+	ErrPasswordMissingSymbol    = errors.New("password missing symbol")
+	ErrPasswordIsCommonPassword = errors.New("password is a common password")
 )
 
+// commonPasswords 常見密碼，無論 policy 設定為何都會拒絕
+var commonPasswords = []string{
+	"123456", "1234567", "12345678", "123456789", "1234567890",
+	"111111", "000000", "123123", "654321", "666666", "888888",
+	"password", "password1", "passw0rd", "p@ssw0rd", "qwerty", "qwerty123",
+	"abc123", "abcd1234", "a123456", "aa123456", "iloveyou", "admin", "admin123",
+	"welcome", "letmein", "1qaz2wsx", "qwertyuiop", "asdfghjkl", "zxcvbnm",
+}
+
+type Policy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	DenyList      []string
+}
+
+var DefaultPolicy = Policy{MinLength: 6}
+
 func HashPassword(password string) (string, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -25,8 +52,62 @@ func CheckPassword(password string, hashedPassword string) error {
 }
 
 func DoesPasswordMeetRule(password string) error {
-	if len(password) < 6 {
+	return DefaultPolicy.Check(password)
+}
+
+func (p Policy) Check(password string) error {
+	if len(password) < p.MinLength {
 		return ErrInvalidPasswordLength
 	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.RequireUpper && !hasUpper {
+		return ErrPasswordMissingUpper
+	}
+	if p.RequireLower && !hasLower {
+		return ErrPasswordMissingLower
+	}
+	if p.RequireDigit && !hasDigit {
+		return ErrPasswordMissingDigit
+	}
+	if p.RequireSymbol && !hasSymbol {
+		return ErrPasswordMissingSymbol
+	}
+
+	// 不分大小寫比對
+	lower := strings.ToLower(password)
+	for _, common := range commonPasswords {
+		if lower == common {
+			return ErrPasswordIsCommonPassword
+		}
+	}
+	for _, denied := range p.DenyList {
+		if lower == strings.ToLower(denied) {
+			return ErrPasswordIsCommonPassword
+		}
+	}
 	return nil
 }
+
+// IsPasswordReused 檢查 password 是否與 hashedPasswords 中任一個相同
+func IsPasswordReused(password string, hashedPasswords []string) bool {
+	for _, hashedPassword := range hashedPasswords {
+		if CheckPassword(password, hashedPassword) == nil {
+			return true
+		}
+	}
+	return false
+}
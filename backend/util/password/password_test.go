@@ -35,3 +35,36 @@ func TestDoesPasswordMeetRule(t *testing.T) {
 	err = DoesPasswordMeetRule("thisisgoodp@ssw0rd")
 	require.NoError(t, err)
 }
+
+func TestPolicyCheck(t *testing.T) {
+	policy := Policy{
+		MinLength:     8,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		DenyList:      []string{"Laundry@2023"},
+	}
+
+	require.EqualError(t, policy.Check("Ab1@"), ErrInvalidPasswordLength.Error())
+	require.EqualError(t, policy.Check("abcd1234@"), ErrPasswordMissingUpper.Error())
+	require.EqualError(t, policy.Check("ABCD1234@"), ErrPasswordMissingLower.Error())
+	require.EqualError(t, policy.Check("Abcdefgh@"), ErrPasswordMissingDigit.Error())
+	require.EqualError(t, policy.Check("Abcd12345"), ErrPasswordMissingSymbol.Error())
+	require.EqualError(t, policy.Check("lAUNDRY@2023"), ErrPasswordIsCommonPassword.Error())
+	require.EqualError(t, DefaultPolicy.Check("Password"), ErrPasswordIsCommonPassword.Error())
+	require.NoError(t, policy.Check("thisisGoodp@ssw0rd"))
+}
+
+func TestIsPasswordReused(t *testing.T) {
+	password := randomutil.RandomAlphaNumString(16)
+
+	hashedPassword1, err := HashPassword(password)
+	require.NoError(t, err)
+	hashedPassword2, err := HashPassword(randomutil.RandomAlphaNumString(16))
+	require.NoError(t, err)
+
+	require.True(t, IsPasswordReused(password, []string{hashedPassword2, hashedPassword1}))
+	require.False(t, IsPasswordReused(password, []string{hashedPassword2}))
+	require.False(t, IsPasswordReused(password, []string{}))
+}
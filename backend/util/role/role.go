@@ -31,7 +31,17 @@ var adminRole = Role{
 		ScopeStoreWrite,
 		ScopeStorePasswordWrite,
 		ScopeStoreReportRead,
+		ScopeRoleRead,
+		ScopeRoleWrite,
 		ScopeStoreUserAdminRegister,
+		ScopeStoreGroupRead,
+		ScopeStoreGroupWrite,
+		ScopeStoreAllAccess,
+		ScopeAuditUserRead,
+		ScopeAuditStoreRead,
+		ScopeStoreDeviceTransfer,
+		ScopeStoreEdgeRead,
+		ScopeDeviceDisplayTypeWrite,
 	},
 	StoreUserScopes: []string{
 		ScopeStoreDevice_RecordsRead,
@@ -52,6 +62,22 @@ var adminRole = Role{
 		ScopeStoreDeviceBlink,
 		ScopeStoreDeviceInsertCoinsWithNegativeBalance,
 		ScopeStoreDeviceRecordsRead,
+		ScopeStoreApiKeyRead,
+		ScopeStoreApiKeyWrite,
+		ScopeStoreUserRoleWrite,
+		ScopeStoreJoinCodeRead,
+		ScopeStoreJoinCodeWrite,
+		ScopeStoreUserScopeWrite,
+		ScopeStoreAuditRead,
+		ScopeStoreUserWalletHistoryRead,
+		ScopeStoreUserBalanceAdjust,
+		ScopeStoreUserBalanceAdjustApprove,
+		ScopeStoreCreditLimitWrite,
+		ScopeStoreDebtReportRead,
+		ScopeStoreUserBalanceTransfer,
+		ScopeStoreUserFamilyWallet,
+		ScopeStoreBrandWalletWrite,
+		ScopeStoreDeviceStateWrite,
 	},
 }
 
@@ -67,7 +93,12 @@ var hqRole = Role{
 		ScopeStoreDeactive,
 		ScopeStoreWrite,
 		ScopeStoreReportRead,
+		ScopeRoleRead,
+		ScopeRoleWrite,
 		ScopeStoreUserHqRegister,
+		ScopeAuditStoreRead,
+		ScopeStoreDeviceTransfer,
+		ScopeStoreEdgeRead,
 	},
 	StoreUserScopes: []string{
 		ScopeStoreDevice_RecordsRead,
@@ -88,6 +119,22 @@ var hqRole = Role{
 		ScopeStoreDeviceBlink,
 		ScopeStoreDeviceInsertCoinsWithNegativeBalance,
 		ScopeStoreDeviceRecordsRead,
+		ScopeStoreApiKeyRead,
+		ScopeStoreApiKeyWrite,
+		ScopeStoreUserRoleWrite,
+		ScopeStoreJoinCodeRead,
+		ScopeStoreJoinCodeWrite,
+		ScopeStoreUserScopeWrite,
+		ScopeStoreAuditRead,
+		ScopeStoreUserWalletHistoryRead,
+		ScopeStoreUserBalanceAdjust,
+		ScopeStoreUserBalanceAdjustApprove,
+		ScopeStoreCreditLimitWrite,
+		ScopeStoreDebtReportRead,
+		ScopeStoreUserBalanceTransfer,
+		ScopeStoreUserFamilyWallet,
+		ScopeStoreBrandWalletWrite,
+		ScopeStoreDeviceStateWrite,
 	},
 }
 
@@ -124,6 +171,22 @@ var ownerRole = Role{
 		ScopeStoreDeviceBlink,
 		ScopeStoreDeviceInsertCoinsWithNegativeBalance,
 		ScopeStoreDeviceRecordsRead,
+		ScopeStoreApiKeyRead,
+		ScopeStoreApiKeyWrite,
+		ScopeStoreUserRoleWrite,
+		ScopeStoreJoinCodeRead,
+		ScopeStoreJoinCodeWrite,
+		ScopeStoreUserScopeWrite,
+		ScopeStoreAuditRead,
+		ScopeStoreUserWalletHistoryRead,
+		ScopeStoreUserBalanceAdjust,
+		ScopeStoreUserBalanceAdjustApprove,
+		ScopeStoreCreditLimitWrite,
+		ScopeStoreDebtReportRead,
+		ScopeStoreUserBalanceTransfer,
+		ScopeStoreUserFamilyWallet,
+		ScopeStoreBrandWalletWrite,
+		ScopeStoreDeviceStateWrite,
 	},
 }
 
@@ -146,6 +209,12 @@ var mgrRole = Role{
 		ScopeStoreDeviceBlink,
 		ScopeStoreDeviceInsertCoinsWithNegativeBalance,
 		ScopeStoreDeviceRecordsRead,
+		ScopeStoreJoinCodeRead,
+		ScopeStoreJoinCodeWrite,
+		ScopeStoreUserWalletHistoryRead,
+		ScopeStoreDebtReportRead,
+		ScopeStoreUserBalanceTransfer,
+		ScopeStoreUserFamilyWallet,
 	},
 }
 
@@ -159,11 +228,22 @@ var custRole = Role{
 		ScopeStoreUserRecordsReadSelf,
 		ScopeStoreDeviceRead,
 		ScopeStoreDeviceInsertCoins,
+		ScopeStoreUserBalanceTransfer,
+		ScopeStoreUserFamilyWallet,
 	},
 }
 
 var roles = []Role{adminRole, hqRole, memberRole, ownerRole, mgrRole, custRole}
 
+// GetBuiltInRoles 內建的 role，啟動時會同步到 db
+func GetBuiltInRoles() []Role {
+	return roles
+}
+
+func IsBuiltInRoleID(id int16) bool {
+	return GetRoleByID(id).ID != 0
+}
+
 func GetRoleByName(name string) Role {
 	for _, role := range roles {
 		if role.Name == name {
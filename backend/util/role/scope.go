@@ -14,6 +14,16 @@ const (
 	ScopeStoreUserAdminRegister = "store:user:admin:register"
 	ScopeStoreUserHqRegister    = "store:user:hq:register"
 	ScopeStoreUserCustRegister  = "store:user:cust:register"
+	ScopeRoleRead               = "role:read"
+	ScopeRoleWrite              = "role:write"
+	ScopeStoreGroupRead         = "store-group:read"
+	ScopeStoreGroupWrite        = "store-group:write"
+	ScopeStoreAllAccess         = "store:all:access"
+	ScopeAuditUserRead          = "audit:user:read"
+	ScopeAuditStoreRead         = "audit:store:read"
+	ScopeStoreDeviceTransfer    = "store-device:transfer"
+	ScopeStoreEdgeRead          = "store:edge:read"
+	ScopeDeviceDisplayTypeWrite = "device-display-type:write"
 
 	// store user scope
 	ScopeStoreDevice_RecordsRead                   = "store:device-records:read"
@@ -36,4 +46,86 @@ const (
 	ScopeStoreDeviceInsertCoins                    = "store:device:insert-coins"
 	ScopeStoreDeviceInsertCoinsWithNegativeBalance = "store:device:insert-coins-with-negative-balance"
 	ScopeStoreDeviceRecordsRead                    = "store:device:records:read"
+	ScopeStoreDeviceStateWrite                     = "store:device:state:write"
+	ScopeStoreApiKeyRead                           = "store:api-key:read"
+	ScopeStoreApiKeyWrite                          = "store:api-key:write"
+	ScopeStoreUserRoleWrite                        = "store:user:role:write"
+	ScopeStoreJoinCodeRead                         = "store:join-code:read"
+	ScopeStoreJoinCodeWrite                        = "store:join-code:write"
+	ScopeStoreUserScopeWrite                       = "store:user:scope:write"
+	ScopeStoreAuditRead                            = "store:audit:read"
+	ScopeStoreUserWalletHistoryRead                = "store:user:wallet-history:read"
+	ScopeStoreUserBalanceAdjust                    = "store:user:balance:adjust"
+	ScopeStoreUserBalanceAdjustApprove             = "store:user:balance:adjust:approve"
+	ScopeStoreCreditLimitWrite                     = "store:credit-limit:write"
+	ScopeStoreDebtReportRead                       = "store:report:debt:read"
+	ScopeStoreUserBalanceTransfer                  = "store:user:balance:transfer"
+	ScopeStoreUserFamilyWallet                     = "store:user:family-wallet"
+	ScopeStoreBrandWalletWrite                     = "store:brand-wallet:write"
 )
+
+// UserScopes 所有的 user scope，用於檢查自訂 role 的 scopes 是否合法
+var UserScopes = Scopes{
+	ScopeUserDataWrite,
+	ScopeUserDataRead,
+	ScopeStoreRead,
+	ScopeStoreCreate,
+	ScopeStoreEnable,
+	ScopeStoreDeactive,
+	ScopeStoreWrite,
+	ScopeStorePasswordWrite,
+	ScopeStoreReportRead,
+	ScopeStoreUserAdminRegister,
+	ScopeStoreUserHqRegister,
+	ScopeStoreUserCustRegister,
+	ScopeRoleRead,
+	ScopeRoleWrite,
+	ScopeStoreGroupRead,
+	ScopeStoreGroupWrite,
+	ScopeStoreAllAccess,
+	ScopeAuditUserRead,
+	ScopeAuditStoreRead,
+	ScopeStoreDeviceTransfer,
+	ScopeStoreEdgeRead,
+	ScopeDeviceDisplayTypeWrite,
+}
+
+// StoreUserScopes 所有的 store user scope，用於檢查自訂 role 的 scopes 是否合法
+var StoreUserScopes = Scopes{
+	ScopeStoreDevice_RecordsRead,
+	ScopeStoreUser_RecordsRead,
+	ScopeStoreUserDataRead,
+	ScopeStoreUserOwnerEnable,
+	ScopeStoreUserMgrEnable,
+	ScopeStoreUserCustEnable,
+	ScopeStoreUserOwnerDeactive,
+	ScopeStoreUserMgrDeactive,
+	ScopeStoreUserCustDeactive,
+	ScopeStoreUserOnlineTopUp,
+	ScopeStoreUserCustCashTopUp,
+	ScopeStoreUserRecordsReadSelf,
+	ScopeStoreUserRecordsReadOthers,
+	ScopeStoreUserRead,
+	ScopeStoreDeviceRead,
+	ScopeStoreDeviceWrite,
+	ScopeStoreDeviceBlink,
+	ScopeStoreDeviceInsertCoins,
+	ScopeStoreDeviceInsertCoinsWithNegativeBalance,
+	ScopeStoreDeviceRecordsRead,
+	ScopeStoreApiKeyRead,
+	ScopeStoreApiKeyWrite,
+	ScopeStoreUserRoleWrite,
+	ScopeStoreJoinCodeRead,
+	ScopeStoreJoinCodeWrite,
+	ScopeStoreUserScopeWrite,
+	ScopeStoreAuditRead,
+	ScopeStoreUserWalletHistoryRead,
+	ScopeStoreUserBalanceAdjust,
+	ScopeStoreUserBalanceAdjustApprove,
+	ScopeStoreCreditLimitWrite,
+	ScopeStoreDebtReportRead,
+	ScopeStoreUserBalanceTransfer,
+	ScopeStoreUserFamilyWallet,
+	ScopeStoreBrandWalletWrite,
+	ScopeStoreDeviceStateWrite,
+}
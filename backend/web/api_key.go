@@ -0,0 +1,234 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	"backend/token"
+	logutil "backend/util/log"
+	roleutil "backend/util/role"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type createStoreApiKeyUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+type createStoreApiKeyRequest struct {
+	Name       *string  `json:"name"`
+	Scopes     []string `json:"scopes"`
+	AllowedIps []string `json:"allowed_ips"`
+	ExpiredAt  *int64   `json:"expired_at"`
+}
+
+func (s *Server) createStoreApiKey(c *gin.Context) {
+	var reqUri createStoreApiKeyUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	var req createStoreApiKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	if req.Name == nil || *req.Name == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "name is null or empty"))
+		return
+	}
+
+	if len(req.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "scopes is null or empty"))
+		return
+	}
+
+	// api key 的 scopes 必須是建立者 scopes 的子集，且不能用來管理 api key
+	scopes := c.MustGet(authorizationScopesKey).(roleutil.Scopes)
+	for _, scope := range req.Scopes {
+		if scope == roleutil.ScopeStoreApiKeyRead || scope == roleutil.ScopeStoreApiKeyWrite || !contains(scopes, scope) {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("scope is not allowed, scope=%s", scope)))
+			return
+		}
+	}
+
+	allowedIps := make([]string, 0, len(req.AllowedIps))
+	for _, ip := range req.AllowedIps {
+		if !isValidIPOrCIDR(ip) {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("allowed_ips is invalid, ip=%s", ip)))
+			return
+		}
+		allowedIps = append(allowedIps, ip)
+	}
+
+	expiredAt := sql.NullInt64{}
+	if req.ExpiredAt != nil {
+		if time.Now().After(time.UnixMilli(*req.ExpiredAt)) {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "expired_at should be in the future"))
+			return
+		}
+		expiredAt = sql.NullInt64{Valid: true, Int64: *req.ExpiredAt}
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store not found, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	key, err := genApiKey()
+	if err != nil {
+		logutil.GetLogger().Errorf("gen api key error, err=%s", err)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.CreateApiKeyParams{
+		ID:         uuid.New(),
+		StoreID:    storeID,
+		Name:       *req.Name,
+		KeyHash:    hashApiKey(key),
+		Scopes:     req.Scopes,
+		AllowedIps: allowedIps,
+		ExpiredAt:  expiredAt,
+		CreatedBy:  authPayload.Subject,
+	}
+	apiKey, err := s.store.CreateApiKey(c, arg)
+	if err != nil {
+		logutil.GetLogger().Errorf("create api key error, err=%s, store_id=%s, name=%s", err, storeID, *req.Name)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	// key 只會在建立時回傳一次
+	c.JSON(http.StatusOK, gin.H{
+		"id":  apiKey.ID.String(),
+		"key": key,
+	})
+}
+
+type getStoreApiKeysUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+func (s *Server) getStoreApiKeys(c *gin.Context) {
+	var req getStoreApiKeysUri
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.StoreID == nil || *req.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*req.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store not found, store_id=%s", *req.StoreID)))
+		return
+	}
+
+	apiKeys, err := s.store.GetStoreApiKeys(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store api keys error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	res := make([]gin.H, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		var expiredAt *int64
+		if apiKey.ExpiredAt.Valid {
+			expiredAt = &apiKey.ExpiredAt.Int64
+		}
+		res = append(res, gin.H{
+			"id":          apiKey.ID.String(),
+			"name":        apiKey.Name,
+			"scopes":      apiKey.Scopes,
+			"allowed_ips": apiKey.AllowedIps,
+			"expired_at":  expiredAt,
+			"is_revoked":  apiKey.IsRevoked,
+			"created_by":  apiKey.CreatedBy.String(),
+			"created_at":  apiKey.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": res})
+}
+
+type revokeStoreApiKeyUri struct {
+	StoreID  *string `uri:"store_id"`
+	ApiKeyID *string `uri:"api_key_id"`
+}
+
+func (s *Server) revokeStoreApiKey(c *gin.Context) {
+	var req revokeStoreApiKeyUri
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.StoreID == nil || *req.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	if req.ApiKeyID == nil || *req.ApiKeyID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "api_key_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*req.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeApiKeyNotFoundError, fmt.Sprintf("api key not found, store_id=%s, api_key_id=%s", *req.StoreID, *req.ApiKeyID)))
+		return
+	}
+
+	apiKeyID, err := uuid.Parse(*req.ApiKeyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeApiKeyNotFoundError, fmt.Sprintf("api key not found, store_id=%s, api_key_id=%s", *req.StoreID, *req.ApiKeyID)))
+		return
+	}
+
+	arg1 := db.GetApiKeyParams{
+		StoreID: storeID,
+		ID:      apiKeyID,
+	}
+	if _, err := s.store.GetApiKey(c, arg1); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeApiKeyNotFoundError, fmt.Sprintf("api key not found, store_id=%s, api_key_id=%s", *req.StoreID, *req.ApiKeyID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get api key error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg2 := db.RevokeApiKeyParams{
+		StoreID:   storeID,
+		ID:        apiKeyID,
+		RevokedBy: uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		RevokedAt: sql.NullInt64{Valid: true, Int64: time.Now().UnixMilli()},
+	}
+	if err := s.store.RevokeApiKey(c, arg2); err != nil {
+		logutil.GetLogger().Errorf("revoke api key error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
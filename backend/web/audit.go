@@ -0,0 +1,458 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	auditutil "backend/util/audit"
+	logutil "backend/util/log"
+	roleutil "backend/util/role"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// 不可在 audit 中顯示的欄位
+var auditRedactedFields = []string{"password"}
+
+type getAuditQuery struct {
+	StoreID     *string `form:"store_id"`
+	UserID      *string `form:"user_id"`
+	DeviceID    *string `form:"device_id"`
+	ChangedBy   *string `form:"changed_by"`
+	ChangedType *string `form:"changed_type"`
+	From        *int64  `form:"from"`
+	To          *int64  `form:"to"`
+	Limit       *int32  `form:"limit"`
+}
+
+type auditFilter struct {
+	StoreID     uuid.NullUUID
+	UserID      uuid.NullUUID
+	DeviceID    sql.NullString
+	ChangedBy   uuid.NullUUID
+	ChangedType sql.NullString
+	ChangedFrom sql.NullInt64
+	ChangedTo   sql.NullInt64
+	LimitCount  int32
+}
+
+// bindAuditFilter 解析 audit 的查詢條件，失敗時已回應
+func (s *Server) bindAuditFilter(c *gin.Context) (auditFilter, bool) {
+	var req getAuditQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return auditFilter{}, false
+	}
+
+	f := auditFilter{LimitCount: s.config.MaxAuditEntries}
+
+	for _, v := range []struct {
+		name  string
+		value *string
+		dest  *uuid.NullUUID
+	}{
+		{"store_id", req.StoreID, &f.StoreID},
+		{"user_id", req.UserID, &f.UserID},
+		{"changed_by", req.ChangedBy, &f.ChangedBy},
+	} {
+		if v.value == nil || *v.value == "" {
+			continue
+		}
+		id, err := uuid.Parse(*v.value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("%s is invalid", v.name)))
+			return auditFilter{}, false
+		}
+		*v.dest = uuid.NullUUID{Valid: true, UUID: id}
+	}
+
+	if req.DeviceID != nil && *req.DeviceID != "" {
+		f.DeviceID = sql.NullString{Valid: true, String: *req.DeviceID}
+	}
+
+	if req.ChangedType != nil && *req.ChangedType != "" {
+		f.ChangedType = sql.NullString{Valid: true, String: *req.ChangedType}
+	}
+
+	if req.From != nil {
+		f.ChangedFrom = sql.NullInt64{Valid: true, Int64: *req.From}
+	}
+
+	if req.To != nil {
+		f.ChangedTo = sql.NullInt64{Valid: true, Int64: *req.To}
+	}
+
+	if f.ChangedFrom.Valid && f.ChangedTo.Valid && f.ChangedFrom.Int64 >= f.ChangedTo.Int64 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "from should be less than to"))
+		return auditFilter{}, false
+	}
+
+	if req.Limit != nil {
+		if *req.Limit <= 0 || *req.Limit > s.config.MaxAuditEntries {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError,
+				fmt.Sprintf("limit should be between 1 and %d", s.config.MaxAuditEntries)))
+			return auditFilter{}, false
+		}
+		f.LimitCount = *req.Limit
+	}
+
+	return f, true
+}
+
+// checkAuditStoreFilter 沒有 ScopeStoreAllAccess 的使用者須指定所屬 store group 內的 store，失敗時已回應
+func (s *Server) checkAuditStoreFilter(c *gin.Context, f auditFilter) bool {
+	scopes := c.MustGet(authorizationScopesKey).(roleutil.Scopes)
+	if contains(scopes, roleutil.ScopeStoreAllAccess) {
+		return true
+	}
+
+	if !f.StoreID.Valid {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return false
+	}
+
+	accessible, err := s.isStoreAccessible(c, f.StoreID.UUID)
+	if err != nil {
+		logutil.GetLogger().Errorf("check store accessible error, err=%s, store_id=%s", err, f.StoreID.UUID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return false
+	}
+	if !accessible {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", f.StoreID.UUID)))
+		return false
+	}
+
+	return true
+}
+
+type getStoreAuditUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+// bindStoreAuditFilter 解析 store 內的 audit 查詢條件，store_id 固定為 uri 的 store，失敗時已回應
+func (s *Server) bindStoreAuditFilter(c *gin.Context) (auditFilter, bool) {
+	var reqUri getStoreAuditUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return auditFilter{}, false
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return auditFilter{}, false
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return auditFilter{}, false
+	}
+
+	f, ok := s.bindAuditFilter(c)
+	if !ok {
+		return auditFilter{}, false
+	}
+	f.StoreID = uuid.NullUUID{Valid: true, UUID: storeID}
+
+	return f, true
+}
+
+func newAuditEntry(changedAt int64, changedType string, changedBy uuid.NullUUID, changedUserAgent, changedClientIp sql.NullString) gin.H {
+	return gin.H{
+		"changed_at":         changedAt,
+		"changed_type":       changedType,
+		"changed_by":         changedBy.UUID,
+		"changed_user_agent": changedUserAgent.String,
+		"changed_client_ip":  changedClientIp.String,
+	}
+}
+
+func usersHistoryFields(h db.UsersHistory) []auditutil.Field {
+	return []auditutil.Field{
+		{Name: "phone_number", Value: h.PhoneNumber},
+		{Name: "name", Value: h.Name},
+		{Name: "password", Value: h.Password},
+		{Name: "password_error_count", Value: h.PasswordErrorCount},
+		{Name: "password_changed_at", Value: h.PasswordChangedAt.Int64},
+		{Name: "role_id", Value: h.RoleID},
+		{Name: "state", Value: h.State},
+	}
+}
+
+func storesHistoryFields(h db.StoresHistory) []auditutil.Field {
+	return []auditutil.Field{
+		{Name: "name", Value: h.Name},
+		{Name: "address", Value: h.Address},
+		{Name: "state", Value: h.State},
+		{Name: "password", Value: h.Password.String},
+	}
+}
+
+func storeUsersHistoryFields(h db.StoreUsersHistory) []auditutil.Field {
+	return []auditutil.Field{
+		{Name: "balance", Value: h.Balance},
+		{Name: "points", Value: h.Points},
+		{Name: "balance_earmark", Value: h.BalanceEarmark},
+		{Name: "points_earmark", Value: h.PointsEarmark},
+		{Name: "role_id", Value: h.RoleID},
+		{Name: "state", Value: h.State},
+	}
+}
+
+func storeDevicesHistoryFields(h db.StoreDevicesHistory) []auditutil.Field {
+	return []auditutil.Field{
+		{Name: "name", Value: h.Name.String},
+		{Name: "real_type", Value: h.RealType},
+		{Name: "display_type", Value: h.DisplayType},
+		{Name: "state", Value: h.State},
+		{Name: "hardware_id", Value: h.HardwareID},
+		{Name: "zone_id", Value: nullUUIDValue(h.ZoneID)},
+		{Name: "sort_order", Value: h.SortOrder},
+		{Name: "machine_number", Value: nullStringValue(h.MachineNumber)},
+		{Name: "capacity_kg", Value: nullFloat64Value(h.CapacityKg)},
+	}
+}
+
+func (s *Server) respondUsersHistory(c *gin.Context, f auditFilter) {
+	arg1 := db.GetUsersHistoryParams{
+		UserID:      f.UserID,
+		ChangedBy:   f.ChangedBy,
+		ChangedType: f.ChangedType,
+		ChangedFrom: f.ChangedFrom,
+		ChangedTo:   f.ChangedTo,
+		LimitCount:  f.LimitCount,
+	}
+
+	histories, err := s.store.GetUsersHistory(c, arg1)
+	if err != nil {
+		logutil.GetLogger().Errorf("get users history error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	entries := make([]gin.H, 0, len(histories))
+	for _, h := range histories {
+		arg2 := db.GetPreviousUsersHistoryParams{
+			UserID:           h.UserID,
+			ChangedAt:        h.ChangedAt,
+			HistoryCreatedAt: h.HistoryCreatedAt,
+		}
+
+		var prevFields []auditutil.Field
+		prev, err := s.store.GetPreviousUsersHistory(c, arg2)
+		if err != nil && err != sql.ErrNoRows {
+			logutil.GetLogger().Errorf("get previous users history error, err=%s, arg=%#v", err, arg2)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		if err == nil {
+			prevFields = usersHistoryFields(prev)
+		}
+
+		entry := newAuditEntry(h.ChangedAt, h.ChangedType, h.ChangedBy, h.ChangedUserAgent, h.ChangedClientIp)
+		entry["user_id"] = h.UserID
+		entry["changes"] = auditutil.Diff(prevFields, usersHistoryFields(h), auditRedactedFields...)
+		entries = append(entries, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+func (s *Server) respondStoresHistory(c *gin.Context, f auditFilter) {
+	arg1 := db.GetStoresHistoryParams{
+		StoreID:     f.StoreID,
+		ChangedBy:   f.ChangedBy,
+		ChangedType: f.ChangedType,
+		ChangedFrom: f.ChangedFrom,
+		ChangedTo:   f.ChangedTo,
+		LimitCount:  f.LimitCount,
+	}
+
+	histories, err := s.store.GetStoresHistory(c, arg1)
+	if err != nil {
+		logutil.GetLogger().Errorf("get stores history error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	entries := make([]gin.H, 0, len(histories))
+	for _, h := range histories {
+		arg2 := db.GetPreviousStoresHistoryParams{
+			StoreID:          h.StoreID,
+			ChangedAt:        h.ChangedAt,
+			HistoryCreatedAt: h.HistoryCreatedAt,
+		}
+
+		var prevFields []auditutil.Field
+		prev, err := s.store.GetPreviousStoresHistory(c, arg2)
+		if err != nil && err != sql.ErrNoRows {
+			logutil.GetLogger().Errorf("get previous stores history error, err=%s, arg=%#v", err, arg2)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		if err == nil {
+			prevFields = storesHistoryFields(prev)
+		}
+
+		entry := newAuditEntry(h.ChangedAt, h.ChangedType, h.ChangedBy, h.ChangedUserAgent, h.ChangedClientIp)
+		entry["store_id"] = h.StoreID
+		entry["changes"] = auditutil.Diff(prevFields, storesHistoryFields(h), auditRedactedFields...)
+		entries = append(entries, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+func (s *Server) respondStoreUsersHistory(c *gin.Context, f auditFilter) {
+	arg1 := db.GetStoreUsersHistoryParams{
+		StoreID:     f.StoreID,
+		UserID:      f.UserID,
+		ChangedBy:   f.ChangedBy,
+		ChangedType: f.ChangedType,
+		ChangedFrom: f.ChangedFrom,
+		ChangedTo:   f.ChangedTo,
+		LimitCount:  f.LimitCount,
+	}
+
+	histories, err := s.store.GetStoreUsersHistory(c, arg1)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store users history error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	entries := make([]gin.H, 0, len(histories))
+	for _, h := range histories {
+		arg2 := db.GetPreviousStoreUsersHistoryParams{
+			StoreID:          h.StoreID,
+			UserID:           h.UserID,
+			ChangedAt:        h.ChangedAt,
+			HistoryCreatedAt: h.HistoryCreatedAt,
+		}
+
+		var prevFields []auditutil.Field
+		prev, err := s.store.GetPreviousStoreUsersHistory(c, arg2)
+		if err != nil && err != sql.ErrNoRows {
+			logutil.GetLogger().Errorf("get previous store users history error, err=%s, arg=%#v", err, arg2)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		if err == nil {
+			prevFields = storeUsersHistoryFields(prev)
+		}
+
+		entry := newAuditEntry(h.ChangedAt, h.ChangedType, h.ChangedBy, h.ChangedUserAgent, h.ChangedClientIp)
+		entry["store_id"] = h.StoreID
+		entry["user_id"] = h.UserID
+		entry["changes"] = auditutil.Diff(prevFields, storeUsersHistoryFields(h), auditRedactedFields...)
+		entries = append(entries, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+func (s *Server) respondStoreDevicesHistory(c *gin.Context, f auditFilter) {
+	arg1 := db.GetStoreDevicesHistoryParams{
+		StoreID:     f.StoreID,
+		DeviceID:    f.DeviceID,
+		ChangedBy:   f.ChangedBy,
+		ChangedType: f.ChangedType,
+		ChangedFrom: f.ChangedFrom,
+		ChangedTo:   f.ChangedTo,
+		LimitCount:  f.LimitCount,
+	}
+
+	histories, err := s.store.GetStoreDevicesHistory(c, arg1)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store devices history error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	entries := make([]gin.H, 0, len(histories))
+	for _, h := range histories {
+		arg2 := db.GetPreviousStoreDevicesHistoryParams{
+			StoreID:          h.StoreID,
+			DeviceID:         h.DeviceID,
+			ChangedAt:        h.ChangedAt,
+			HistoryCreatedAt: h.HistoryCreatedAt,
+		}
+
+		var prevFields []auditutil.Field
+		prev, err := s.store.GetPreviousStoreDevicesHistory(c, arg2)
+		if err != nil && err != sql.ErrNoRows {
+			logutil.GetLogger().Errorf("get previous store devices history error, err=%s, arg=%#v", err, arg2)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		if err == nil {
+			prevFields = storeDevicesHistoryFields(prev)
+		}
+
+		entry := newAuditEntry(h.ChangedAt, h.ChangedType, h.ChangedBy, h.ChangedUserAgent, h.ChangedClientIp)
+		entry["store_id"] = h.StoreID
+		entry["device_id"] = h.DeviceID
+		entry["changes"] = auditutil.Diff(prevFields, storeDevicesHistoryFields(h), auditRedactedFields...)
+		entries = append(entries, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+func (s *Server) getUsersAudit(c *gin.Context) {
+	f, ok := s.bindAuditFilter(c)
+	if !ok {
+		return
+	}
+	s.respondUsersHistory(c, f)
+}
+
+func (s *Server) getStoresAudit(c *gin.Context) {
+	f, ok := s.bindAuditFilter(c)
+	if !ok || !s.checkAuditStoreFilter(c, f) {
+		return
+	}
+	s.respondStoresHistory(c, f)
+}
+
+func (s *Server) getStoreUsersAudit(c *gin.Context) {
+	f, ok := s.bindAuditFilter(c)
+	if !ok || !s.checkAuditStoreFilter(c, f) {
+		return
+	}
+	s.respondStoreUsersHistory(c, f)
+}
+
+func (s *Server) getStoreDevicesAudit(c *gin.Context) {
+	f, ok := s.bindAuditFilter(c)
+	if !ok || !s.checkAuditStoreFilter(c, f) {
+		return
+	}
+	s.respondStoreDevicesHistory(c, f)
+}
+
+func (s *Server) getStoreAuditStore(c *gin.Context) {
+	f, ok := s.bindStoreAuditFilter(c)
+	if !ok {
+		return
+	}
+	s.respondStoresHistory(c, f)
+}
+
+func (s *Server) getStoreAuditUsers(c *gin.Context) {
+	f, ok := s.bindStoreAuditFilter(c)
+	if !ok {
+		return
+	}
+	s.respondStoreUsersHistory(c, f)
+}
+
+func (s *Server) getStoreAuditDevices(c *gin.Context) {
+	f, ok := s.bindStoreAuditFilter(c)
+	if !ok {
+		return
+	}
+	s.respondStoreDevicesHistory(c, f)
+}
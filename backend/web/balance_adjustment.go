@@ -0,0 +1,484 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	"backend/token"
+	distlockutil "backend/util/distlock"
+	fsmutil "backend/util/fsm"
+	logutil "backend/util/log"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/looplab/fsm"
+)
+
+func newBalanceAdjustmentResponse(adjustment db.BalanceAdjustment) gin.H {
+	var reviewedBy *string
+	if adjustment.ReviewedBy.Valid {
+		id := adjustment.ReviewedBy.UUID.String()
+		reviewedBy = &id
+	}
+	var reviewedAt *int64
+	if adjustment.ReviewedAt.Valid {
+		reviewedAt = &adjustment.ReviewedAt.Int64
+	}
+	return gin.H{
+		"id":           adjustment.ID.String(),
+		"store_id":     adjustment.StoreID.String(),
+		"user_id":      adjustment.UserID.String(),
+		"amount":       adjustment.Amount,
+		"point_amount": adjustment.PointAmount,
+		"reason_code":  adjustment.ReasonCode,
+		"note":         adjustment.Note,
+		"state":        adjustment.State,
+		"requested_by": adjustment.RequestedBy.String(),
+		"reviewed_by":  reviewedBy,
+		"reviewed_at":  reviewedAt,
+		"created_at":   adjustment.CreatedAt,
+	}
+}
+
+// abs64 以 int64 計算絕對值，避免 math.MinInt32 取負時溢位
+func abs64(v int32) int64 {
+	if v < 0 {
+		return -int64(v)
+	}
+	return int64(v)
+}
+
+// needBalanceAdjustmentApproval 調整的 balance 與 points 絕對值合計達門檻時須由另一位使用者核准，門檻為 0 時不需核准
+func (s *Server) needBalanceAdjustmentApproval(amount, pointAmount int32) bool {
+	threshold := s.config.BalanceAdjustment.ApprovalThreshold
+	return threshold > 0 && abs64(amount)+abs64(pointAmount) >= int64(threshold)
+}
+
+// applyBalanceAdjustment 以 int64 計算調整後的 balance 與 points，扣除後為負數或超過 int32 上限時回應錯誤並回傳 false
+func applyBalanceAdjustment(c *gin.Context, storeUser db.StoreUser, amount, pointAmount int32) (int32, int32, bool) {
+	balance := int64(storeUser.Balance) + int64(amount)
+	points := int64(storeUser.Points) + int64(pointAmount)
+
+	// 扣除時不可使餘額變為負數
+	if amount < 0 && balance < 0 || pointAmount < 0 && points < 0 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeLowBalanceError, "balance or points is not enough"))
+		return 0, 0, false
+	}
+
+	if balance > math.MaxInt32 || points > math.MaxInt32 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "balance or points exceeds the maximum"))
+		return 0, 0, false
+	}
+
+	return int32(balance), int32(points), true
+}
+
+type adjustStoreUserBalanceUri struct {
+	StoreID *string `uri:"store_id"`
+	UserID  *string `uri:"user_id"`
+}
+
+type adjustStoreUserBalanceRequest struct {
+	Amount      *int32  `json:"amount"`
+	PointAmount *int32  `json:"point_amount"`
+	ReasonCode  *string `json:"reason_code"`
+	Note        *string `json:"note"`
+}
+
+func (s *Server) adjustStoreUserBalance(c *gin.Context) {
+	var reqUri adjustStoreUserBalanceUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	if reqUri.UserID == nil || *reqUri.UserID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "user_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+		return
+	}
+
+	userID, err := uuid.Parse(*reqUri.UserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+		return
+	}
+
+	var reqJson adjustStoreUserBalanceRequest
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	var amount, pointAmount int32
+	if reqJson.Amount != nil {
+		amount = *reqJson.Amount
+	}
+	if reqJson.PointAmount != nil {
+		pointAmount = *reqJson.PointAmount
+	}
+
+	if amount == 0 && pointAmount == 0 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "amount and point_amount are both 0"))
+		return
+	}
+
+	maxAmount := int64(s.config.BalanceAdjustment.MaxAmount)
+	if abs64(amount) > maxAmount || abs64(pointAmount) > maxAmount {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError,
+			fmt.Sprintf("amount or point_amount exceeds the maximum, max_amount=%d", maxAmount)))
+		return
+	}
+
+	if reqJson.ReasonCode == nil || *reqJson.ReasonCode == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "reason_code is null or empty"))
+		return
+	}
+
+	if !contains(s.config.BalanceAdjustment.ReasonCodes, *reqJson.ReasonCode) {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("reason_code is invalid, reason_code=%s", *reqJson.ReasonCode)))
+		return
+	}
+
+	if reqJson.Note == nil || *reqJson.Note == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "note is null or empty"))
+		return
+	}
+
+	if len(*reqJson.Note) > s.config.BalanceAdjustment.MaxNoteLength {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError,
+			fmt.Sprintf("note longer than %d characters", s.config.BalanceAdjustment.MaxNoteLength)))
+		return
+	}
+
+	// 不可調整自己的餘額
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	if userID == authPayload.Subject {
+		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, "cannot adjust your own balance"))
+		return
+	}
+
+	m := s.rs.NewMutex(distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+		}
+	}()
+
+	arg1 := db.GetStoreUserParams{
+		StoreID: storeID,
+		UserID:  userID,
+	}
+
+	storeUser, err := s.store.GetStoreUser(c, arg1)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get store user error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	storeUserFSM := fsmutil.NewStoreUserFSM(storeUser.State)
+	if err := storeUserFSM.Event(c, fsmutil.StoreUserEventAdjustBalance); err != nil {
+		switch err.(type) {
+		case fsm.InvalidEventError:
+			c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, fmt.Sprintf("store user state is not active, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+			return
+		case fsm.NoTransitionError:
+		default:
+			logutil.GetLogger().Errorf("store user fsm error, err=%s, init_state=%s, event=%s", err, storeUser.State, fsmutil.StoreUserEventAdjustBalance)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+	}
+
+	balance, points, ok := applyBalanceAdjustment(c, storeUser, amount, pointAmount)
+	if !ok {
+		return
+	}
+
+	needApproval := s.needBalanceAdjustmentApproval(amount, pointAmount)
+	state := fsmutil.BalanceAdjustmentStateApplied
+	if needApproval {
+		state = fsmutil.BalanceAdjustmentStatePending
+	}
+
+	arg2 := db.CreateBalanceAdjustmentWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangeType:       storeUserChangedTypeAdjustBalance,
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		ID:               uuid.New(),
+		StoreID:          storeID,
+		UserID:           userID,
+		Amount:           amount,
+		PointAmount:      pointAmount,
+		ReasonCode:       *reqJson.ReasonCode,
+		Note:             *reqJson.Note,
+		State:            state,
+		RequestedBy:      authPayload.Subject,
+		Apply:            !needApproval,
+		Balance:          balance,
+		Points:           points,
+		BalanceEarmark:   storeUser.BalanceEarmark,
+		PointsEarmark:    storeUser.PointsEarmark,
+	}
+
+	adjustment, err := s.store.CreateBalanceAdjustmentWithLog(c, arg2)
+	if err != nil {
+		logutil.GetLogger().Errorf("create balance adjustment with log error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, newBalanceAdjustmentResponse(adjustment))
+}
+
+type getStoreBalanceAdjustmentsUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+type getStoreBalanceAdjustmentsQuery struct {
+	State *string `form:"state"`
+}
+
+func (s *Server) getStoreBalanceAdjustments(c *gin.Context) {
+	var reqUri getStoreBalanceAdjustmentsUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	var reqQuery getStoreBalanceAdjustmentsQuery
+	if err := c.ShouldBindQuery(&reqQuery); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	arg := db.GetStoreBalanceAdjustmentsParams{
+		StoreID: storeID,
+	}
+	if reqQuery.State != nil && *reqQuery.State != "" {
+		arg.State = sql.NullString{Valid: true, String: *reqQuery.State}
+	}
+
+	adjustments, err := s.store.GetStoreBalanceAdjustments(c, arg)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store balance adjustments error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	res := make([]gin.H, 0, len(adjustments))
+	for _, adjustment := range adjustments {
+		res = append(res, newBalanceAdjustmentResponse(adjustment))
+	}
+	c.JSON(http.StatusOK, gin.H{"balance_adjustments": res})
+}
+
+type reviewBalanceAdjustmentUri struct {
+	StoreID             *string `uri:"store_id"`
+	BalanceAdjustmentID *string `uri:"balance_adjustment_id"`
+}
+
+func (s *Server) approveBalanceAdjustment(c *gin.Context) {
+	s.reviewBalanceAdjustment(c, fsmutil.BalanceAdjustmentEventApprove)
+}
+
+func (s *Server) rejectBalanceAdjustment(c *gin.Context) {
+	s.reviewBalanceAdjustment(c, fsmutil.BalanceAdjustmentEventReject)
+}
+
+func (s *Server) reviewBalanceAdjustment(c *gin.Context, event string) {
+	var reqUri reviewBalanceAdjustmentUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	if reqUri.BalanceAdjustmentID == nil || *reqUri.BalanceAdjustmentID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "balance_adjustment_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	adjustmentID, err := uuid.Parse(*reqUri.BalanceAdjustmentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeBalanceAdjustmentNotFoundError,
+			fmt.Sprintf("balance adjustment not found, balance_adjustment_id=%s", *reqUri.BalanceAdjustmentID)))
+		return
+	}
+
+	adjustment, err := s.store.GetBalanceAdjustment(c, adjustmentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeBalanceAdjustmentNotFoundError,
+				fmt.Sprintf("balance adjustment not found, balance_adjustment_id=%s", *reqUri.BalanceAdjustmentID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get balance adjustment error, err=%s, balance_adjustment_id=%s", err, adjustmentID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if adjustment.StoreID != storeID {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeBalanceAdjustmentNotFoundError,
+			fmt.Sprintf("balance adjustment not found, balance_adjustment_id=%s", *reqUri.BalanceAdjustmentID)))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	// 須由提出者以外的使用者審核
+	if adjustment.RequestedBy == authPayload.Subject {
+		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, "cannot review a balance adjustment requested by yourself"))
+		return
+	}
+
+	// 不可審核調整自己餘額的申請
+	if adjustment.UserID == authPayload.Subject {
+		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, "cannot review a balance adjustment of your own balance"))
+		return
+	}
+
+	adjustmentFSM := fsmutil.NewBalanceAdjustmentFSM(adjustment.State)
+	if err := adjustmentFSM.Event(c, event); err != nil {
+		switch err.(type) {
+		case fsm.InvalidEventError:
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeBalanceAdjustmentReviewedError,
+				fmt.Sprintf("balance adjustment has been reviewed, balance_adjustment_id=%s", *reqUri.BalanceAdjustmentID)))
+			return
+		default:
+			logutil.GetLogger().Errorf("balance adjustment fsm error, err=%s, init_state=%s, event=%s", err, adjustment.State, event)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+	}
+
+	m := s.rs.NewMutex(distlockutil.GetStoreUserIDMutexName(storeID.String(), adjustment.UserID.String()))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), adjustment.UserID.String()))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), adjustment.UserID.String()))
+		}
+	}()
+
+	arg := db.ReviewBalanceAdjustmentWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangeType:       storeUserChangedTypeAdjustBalance,
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		ID:               adjustment.ID,
+		StoreID:          adjustment.StoreID,
+		UserID:           adjustment.UserID,
+		Amount:           adjustment.Amount,
+		PointAmount:      adjustment.PointAmount,
+		FromState:        adjustment.State,
+		State:            adjustmentFSM.Current(),
+		Apply:            event == fsmutil.BalanceAdjustmentEventApprove,
+	}
+
+	if arg.Apply {
+		arg1 := db.GetStoreUserParams{
+			StoreID: storeID,
+			UserID:  adjustment.UserID,
+		}
+
+		storeUser, err := s.store.GetStoreUser(c, arg1)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", storeID, adjustment.UserID)))
+				return
+			}
+			logutil.GetLogger().Errorf("get store user error, err=%s, arg=%#v", err, arg1)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+
+		storeUserFSM := fsmutil.NewStoreUserFSM(storeUser.State)
+		if err := storeUserFSM.Event(c, fsmutil.StoreUserEventAdjustBalance); err != nil {
+			switch err.(type) {
+			case fsm.InvalidEventError:
+				c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, fmt.Sprintf("store user state is not active, store_id=%s, user_id=%s", storeID, adjustment.UserID)))
+				return
+			case fsm.NoTransitionError:
+			default:
+				logutil.GetLogger().Errorf("store user fsm error, err=%s, init_state=%s, event=%s", err, storeUser.State, fsmutil.StoreUserEventAdjustBalance)
+				c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+				return
+			}
+		}
+
+		// 核准時重新檢查，提出後餘額可能已變動
+		balance, points, ok := applyBalanceAdjustment(c, storeUser, adjustment.Amount, adjustment.PointAmount)
+		if !ok {
+			return
+		}
+
+		arg.Balance = balance
+		arg.Points = points
+		arg.BalanceEarmark = storeUser.BalanceEarmark
+		arg.PointsEarmark = storeUser.PointsEarmark
+	}
+
+	if err := s.store.ReviewBalanceAdjustmentWithLog(c, arg); err != nil {
+		if err == db.ErrBalanceAdjustmentReviewed {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeBalanceAdjustmentReviewedError,
+				fmt.Sprintf("balance adjustment has been reviewed, balance_adjustment_id=%s", *reqUri.BalanceAdjustmentID)))
+			return
+		}
+		logutil.GetLogger().Errorf("review balance adjustment with log error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,205 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	"backend/token"
+	distlockutil "backend/util/distlock"
+	fsmutil "backend/util/fsm"
+	logutil "backend/util/log"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redsync/redsync/v4"
+	"github.com/google/uuid"
+	"github.com/looplab/fsm"
+)
+
+type transferStoreUserBalanceUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+type transferStoreUserBalanceRequest struct {
+	ToUserID *string `json:"to_user_id"`
+	Amount   *int32  `json:"amount"`
+}
+
+// transferStoreUserBalance 將自己在商店的 balance 轉給同商店的另一位 store user
+func (s *Server) transferStoreUserBalance(c *gin.Context) {
+	var reqUri transferStoreUserBalanceUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	var reqJson transferStoreUserBalanceRequest
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqJson.ToUserID == nil || *reqJson.ToUserID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "to_user_id is null or empty"))
+		return
+	}
+
+	if reqJson.Amount == nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "amount is null"))
+		return
+	}
+
+	if *reqJson.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "amount is smaller than or equal to 0"))
+		return
+	}
+
+	toUserID, err := uuid.Parse(*reqJson.ToUserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqJson.ToUserID)))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	fromUserID := authPayload.Subject
+
+	if fromUserID == toUserID {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "to_user_id is the same as the user"))
+		return
+	}
+
+	// 依固定順序取得兩位 store user 的 lock，避免同時互轉時 deadlock
+	mutexes := make([]*redsync.Mutex, 0, 2)
+	defer func() {
+		for i := len(mutexes) - 1; i >= 0; i-- {
+			if ok, err := mutexes[i].Unlock(); !ok || err != nil {
+				logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, mutexes[i].Name())
+			}
+		}
+	}()
+	for _, name := range distlockutil.GetSortedStoreUserIDMutexNames(storeID.String(), fromUserID.String(), toUserID.String()) {
+		m := s.rs.NewMutex(name)
+		if err := m.Lock(); err != nil {
+			logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, name)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		mutexes = append(mutexes, m)
+	}
+
+	arg1 := db.GetStoreUserParams{
+		StoreID: storeID,
+		UserID:  fromUserID,
+	}
+
+	fromStoreUser, err := s.store.GetStoreUser(c, arg1)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, fromUserID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get store user error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	arg2 := db.GetStoreUserParams{
+		StoreID: storeID,
+		UserID:  toUserID,
+	}
+
+	toStoreUser, err := s.store.GetStoreUser(c, arg2)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqJson.ToUserID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get store user error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	for _, storeUser := range []db.StoreUser{fromStoreUser, toStoreUser} {
+		storeUserFSM := fsmutil.NewStoreUserFSM(storeUser.State)
+		if err := storeUserFSM.Event(c, fsmutil.StoreUserEventTransferBalance); err != nil {
+			switch err.(type) {
+			case fsm.InvalidEventError:
+				c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, fmt.Sprintf("store user state is not active, store_id=%s, user_id=%s", *reqUri.StoreID, storeUser.UserID)))
+				return
+			case fsm.NoTransitionError:
+			default:
+				logutil.GetLogger().Errorf("store user fsm error, err=%s, init_state=%s, event=%s", err, storeUser.State, fsmutil.StoreUserEventTransferBalance)
+				c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+				return
+			}
+		}
+	}
+
+	if fromStoreUser.Balance < *reqJson.Amount {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeLowBalanceError, fmt.Sprintf("low balance, balance=%d, amount=%d", fromStoreUser.Balance, *reqJson.Amount)))
+		return
+	}
+
+	now := time.Now()
+
+	arg3 := db.GetStoreUserTransferOutSummaryParams{
+		StoreID: storeID,
+		UserID:  uuid.NullUUID{Valid: true, UUID: fromUserID},
+		Type:    db.RecordTypeBalanceTransfer,
+		Ts:      now.Add(-s.config.BalanceTransfer.TimePeriod).UnixMilli(),
+	}
+
+	summary, err := s.store.GetStoreUserTransferOutSummary(c, arg3)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store user transfer out summary error, err=%s, arg=%#v", err, arg3)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if summary.Count >= s.config.BalanceTransfer.MaxCountPerTimePeriod ||
+		summary.Amount+int64(*reqJson.Amount) > s.config.BalanceTransfer.MaxAmountPerTimePeriod {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeBalanceTransferMeetLimitError,
+			fmt.Sprintf("balance transfer limit has been reached, count=%d, amount=%d", summary.Count, summary.Amount)))
+		return
+	}
+
+	arg4 := db.TransferStoreUserBalanceWithLogParams{
+		ChangedAt:        now.UnixMilli(),
+		ChangeType:       storeUserChangedTypeTransfer,
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: fromUserID},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		ID:               uuid.New(),
+		StoreID:          storeID,
+		FromUserID:       fromUserID,
+		ToUserID:         toUserID,
+		Amount:           *reqJson.Amount,
+	}
+
+	if err := s.store.TransferStoreUserBalanceWithLog(c, arg4); err != nil {
+		if err == db.ErrStoreUserBalanceNotEnough {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeLowBalanceError, fmt.Sprintf("low balance, amount=%d", *reqJson.Amount)))
+			return
+		}
+		logutil.GetLogger().Errorf("transfer store user balance with log error, err=%s, arg=%#v", err, arg4)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":     arg4.ID.String(),
+		"amount": arg4.Amount,
+	})
+}
@@ -0,0 +1,545 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	iotsdk "backend/iot-sdk"
+	"backend/token"
+	distlockutil "backend/util/distlock"
+	fsmutil "backend/util/fsm"
+	logutil "backend/util/log"
+	roleutil "backend/util/role"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/looplab/fsm"
+)
+
+// getBrandWalletStoreGroupID 商店未參與品牌錢包時 ok 為 false
+func (s *Server) getBrandWalletStoreGroupID(c *gin.Context, storeID uuid.UUID) (uuid.UUID, bool, error) {
+	brandWalletStore, err := s.store.GetBrandWalletStore(c, storeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.UUID{}, false, nil
+		}
+		return uuid.UUID{}, false, err
+	}
+	return brandWalletStore.StoreGroupID, true, nil
+}
+
+// insertCoinsFromBrandWallet 參與品牌錢包的商店由品牌錢包扣款，回傳 false 表示未處理 (未參與或餘額不足)，由呼叫端改用 store user 扣款
+func (s *Server) insertCoinsFromBrandWallet(c *gin.Context, storeDevice db.StoreDevice, userID uuid.UUID, amount int32) bool {
+	storeID := storeDevice.StoreID
+	deviceID := storeDevice.DeviceID
+
+	storeGroupID, ok, err := s.getBrandWalletStoreGroupID(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get brand wallet store error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return true
+	}
+	if !ok {
+		return false
+	}
+
+	// 非 active 的 store user 交由原本的扣款流程回應錯誤
+	arg0 := db.GetStoreUserParams{
+		StoreID: storeID,
+		UserID:  userID,
+	}
+	storeUser, err := s.store.GetStoreUser(c, arg0)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store user error, err=%s, arg=%#v", err, arg0)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return true
+	}
+	if storeUser.State != fsmutil.StoreUserStateActive {
+		return false
+	}
+
+	// lot 在 transaction 中以 FOR UPDATE 鎖定，不需另外取得 lock
+	arg1 := db.ConsumeBrandWalletParams{
+		ConsumptionID: uuid.New(),
+		StoreGroupID:  storeGroupID,
+		UserID:        userID,
+		StoreID:       storeID,
+		Amount:        amount,
+		Ts:            time.Now().UnixMilli(),
+	}
+	if err := s.store.ConsumeBrandWallet(c, arg1); err != nil {
+		if err == db.ErrBrandWalletBalanceNotEnough {
+			return false
+		}
+		logutil.GetLogger().Errorf("consume brand wallet error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(c, 3*time.Second)
+	defer cancel()
+
+	if err := s.iot.AddPointsToCoinAcceptor(ctx, storeID, storeDevice.HardwareID, amount); err != nil {
+		if err := s.store.RefundBrandWalletConsumption(c, arg1.ConsumptionID); err != nil {
+			logutil.GetLogger().Errorf("refund brand wallet consumption error, err=%s, consumption_id=%s", err, arg1.ConsumptionID)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return true
+		}
+		switch err.(type) {
+		case *iotsdk.DeviceNotFoundError:
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeStoreDeviceNotOnlineError, fmt.Sprintf("store device is not online, store_id=%s, device_id=%s", storeID, deviceID)))
+		case *iotsdk.StoreNotFoundError:
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeStoreNotOnlineError, fmt.Sprintf("store is not online, store_id=%s", storeID)))
+		default:
+			logutil.GetLogger().Errorf("add points to coin acceptor error, err=%s, store_id=%s, device_id=%s", err, storeID, deviceID)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		}
+		return true
+	}
+
+	arg2 := db.CreateRecordParams{
+		CreatedBy:        uuid.NullUUID{Valid: true, UUID: userID},
+		CreatedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		CreatedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		Type:             db.RecordTypeBrandWalletInsertCoins,
+		StoreID:          storeID,
+		RecordID:         sql.NullString{Valid: true, String: arg1.ConsumptionID.String()},
+		UserID:           uuid.NullUUID{Valid: true, UUID: userID},
+		DeviceID:         sql.NullString{Valid: true, String: deviceID},
+		Amount:           amount,
+		PointAmount:      sql.NullInt32{Valid: true, Int32: 0},
+		Ts:               arg1.Ts,
+	}
+
+	if _, err := s.store.CreateRecord(c, arg2); err != nil {
+		logutil.GetLogger().Errorf("create record error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return true
+	}
+
+	c.Status(http.StatusNoContent)
+	return true
+}
+
+type getStoreBrandWalletUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+func (s *Server) getStoreBrandWallet(c *gin.Context) {
+	var reqUri getStoreBrandWalletUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	storeGroupID, ok, err := s.getBrandWalletStoreGroupID(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get brand wallet store error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeBrandWalletNotFoundError, fmt.Sprintf("store has not joined a brand wallet, store_id=%s", storeID)))
+		return
+	}
+
+	storeIDs, err := s.store.GetBrandWalletStoreIDs(c, storeGroupID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get brand wallet store ids error, err=%s, store_group_id=%s", err, storeGroupID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"store_group_id": storeGroupID.String(),
+		"store_ids":      storeIDs,
+	})
+}
+
+type joinBrandWalletUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+type joinBrandWalletRequest struct {
+	StoreGroupID *string `json:"store_group_id"`
+}
+
+// joinBrandWallet 商店加入所屬 store group 的品牌錢包，一間商店只能加入一個品牌錢包
+func (s *Server) joinBrandWallet(c *gin.Context) {
+	var reqUri joinBrandWalletUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	var reqJson joinBrandWalletRequest
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	storeGroup, ok := s.bindStoreGroup(c, reqJson.StoreGroupID)
+	if !ok {
+		return
+	}
+
+	arg1 := db.IsStoreInStoreGroupParams{
+		StoreGroupID: storeGroup.ID,
+		StoreID:      storeID,
+	}
+	inGroup, err := s.store.IsStoreInStoreGroup(c, arg1)
+	if err != nil {
+		logutil.GetLogger().Errorf("is store in store group error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if !inGroup {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreGroupNotFoundError, fmt.Sprintf("store group is not, store_group_id=%s", *reqJson.StoreGroupID)))
+		return
+	}
+
+	m := s.rs.NewMutex(distlockutil.GetStoreIDMutexName(storeID.String()))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreIDMutexName(storeID.String()))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreIDMutexName(storeID.String()))
+		}
+	}()
+
+	if _, ok, err := s.getBrandWalletStoreGroupID(c, storeID); err != nil || ok {
+		if err != nil {
+			logutil.GetLogger().Errorf("get brand wallet store error, err=%s, store_id=%s", err, storeID)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("store has joined a brand wallet, store_id=%s", storeID)))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg2 := db.JoinBrandWalletParams{
+		StoreID:      storeID,
+		StoreGroupID: storeGroup.ID,
+		CreatedBy:    authPayload.Subject,
+	}
+	if _, err := s.store.JoinBrandWallet(c, arg2); err != nil {
+		logutil.GetLogger().Errorf("join brand wallet error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type leaveBrandWalletUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+// leaveBrandWallet 商店退出品牌錢包後，顧客的品牌錢包餘額仍可在其他參與的商店使用
+func (s *Server) leaveBrandWallet(c *gin.Context) {
+	var reqUri leaveBrandWalletUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	rows, err := s.store.LeaveBrandWallet(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("leave brand wallet error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if rows == 0 {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeBrandWalletNotFoundError, fmt.Sprintf("store has not joined a brand wallet, store_id=%s", storeID)))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type assistCustBrandWalletCashTopUpUri struct {
+	StoreID *string `uri:"store_id"`
+	UserID  *string `uri:"user_id"`
+}
+
+type assistCustBrandWalletCashTopUpRequest struct {
+	Amount *int32 `json:"amount"`
+}
+
+func (s *Server) assistCustBrandWalletCashTopUp(c *gin.Context) {
+	var reqUri assistCustBrandWalletCashTopUpUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	if reqUri.UserID == nil || *reqUri.UserID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "user_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+		return
+	}
+
+	userID, err := uuid.Parse(*reqUri.UserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+		return
+	}
+
+	var reqJson assistCustBrandWalletCashTopUpRequest
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqJson.Amount == nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "amount is null"))
+		return
+	}
+
+	if *reqJson.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "amount is smaller than or equal to 0"))
+		return
+	}
+
+	storeGroupID, ok, err := s.getBrandWalletStoreGroupID(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get brand wallet store error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeBrandWalletNotFoundError, fmt.Sprintf("store has not joined a brand wallet, store_id=%s", storeID)))
+		return
+	}
+
+	arg1 := db.GetStoreUserParams{
+		StoreID: storeID,
+		UserID:  userID,
+	}
+
+	storeUser, err := s.store.GetStoreUser(c, arg1)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get store user error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if roleutil.GetRoleByID(storeUser.RoleID).Name != roleutil.RoleCust {
+		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, fmt.Sprintf("store user is not a cust, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+		return
+	}
+
+	storeUserFSM := fsmutil.NewStoreUserFSM(storeUser.State)
+	if err := storeUserFSM.Event(c, fsmutil.StoreUserEventCashTopUp); err != nil {
+		switch err.(type) {
+		case fsm.InvalidEventError:
+			c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, fmt.Sprintf("store user state is not active, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+			return
+		case fsm.NoTransitionError:
+		default:
+			logutil.GetLogger().Errorf("store user fsm error, err=%s, init_state=%s, event=%s", err, storeUser.State, fsmutil.StoreUserEventCashTopUp)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg2 := db.TopUpBrandWalletParams{
+		ID:               uuid.New(),
+		StoreGroupID:     storeGroupID,
+		UserID:           userID,
+		StoreID:          storeID,
+		Amount:           *reqJson.Amount,
+		CreatedBy:        authPayload.Subject,
+		CreatedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		CreatedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		Ts:               time.Now().UnixMilli(),
+	}
+
+	if _, err := s.store.TopUpBrandWallet(c, arg2); err != nil {
+		logutil.GetLogger().Errorf("top up brand wallet error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type getBrandWalletSettlementReportUri struct {
+	StoreGroupID *string `uri:"store_group_id"`
+}
+
+type getBrandWalletSettlementReportQuery struct {
+	From *int64 `form:"from"`
+	To   *int64 `form:"to"`
+}
+
+// getBrandWalletSettlementReport 各商店機台消費了多少在其他商店儲值的品牌錢包金額
+func (s *Server) getBrandWalletSettlementReport(c *gin.Context) {
+	var reqUri getBrandWalletSettlementReportUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	var reqQuery getBrandWalletSettlementReportQuery
+	if err := c.ShouldBindQuery(&reqQuery); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqQuery.From == nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "from is null"))
+		return
+	}
+
+	if reqQuery.To == nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "to is null"))
+		return
+	}
+
+	if *reqQuery.From >= *reqQuery.To {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "from is greater than or equal to to"))
+		return
+	}
+
+	storeGroup, ok := s.bindStoreGroup(c, reqUri.StoreGroupID)
+	if !ok {
+		return
+	}
+
+	accessible, err := s.isStoreGroupAccessible(c, storeGroup.ID)
+	if err != nil {
+		logutil.GetLogger().Errorf("is store group accessible error, err=%s, store_group_id=%s", err, storeGroup.ID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if !accessible {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreGroupNotFoundError, fmt.Sprintf("store group is not, store_group_id=%s", *reqUri.StoreGroupID)))
+		return
+	}
+
+	arg := db.GetBrandWalletSettlementParams{
+		StoreGroupID: storeGroup.ID,
+		FromTs:       *reqQuery.From,
+		ToTs:         *reqQuery.To,
+	}
+	rows, err := s.store.GetBrandWalletSettlement(c, arg)
+	if err != nil {
+		logutil.GetLogger().Errorf("get brand wallet settlement error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	// net 為正表示該商店應向其他商店收取的金額
+	type storeSummary struct {
+		consumed        int64
+		fundedElsewhere int64
+		consumedByOther int64
+	}
+	summaries := make(map[uuid.UUID]*storeSummary)
+	getSummary := func(storeID uuid.UUID) *storeSummary {
+		if _, ok := summaries[storeID]; !ok {
+			summaries[storeID] = &storeSummary{}
+		}
+		return summaries[storeID]
+	}
+
+	flows := make([]gin.H, 0, len(rows))
+	for _, row := range rows {
+		getSummary(row.StoreID).consumed += row.Amount
+		if row.StoreID != row.FundedStoreID {
+			getSummary(row.StoreID).fundedElsewhere += row.Amount
+			getSummary(row.FundedStoreID).consumedByOther += row.Amount
+			flows = append(flows, gin.H{
+				"store_id":        row.StoreID.String(),
+				"funded_store_id": row.FundedStoreID.String(),
+				"amount":          row.Amount,
+			})
+		}
+	}
+
+	storeIDs := make([]uuid.UUID, 0, len(summaries))
+	for storeID := range summaries {
+		storeIDs = append(storeIDs, storeID)
+	}
+	sort.Slice(storeIDs, func(i, j int) bool { return storeIDs[i].String() < storeIDs[j].String() })
+
+	stores := make([]gin.H, 0, len(summaries))
+	for _, storeID := range storeIDs {
+		summary := summaries[storeID]
+		stores = append(stores, gin.H{
+			"store_id":           storeID.String(),
+			"consumed":           summary.consumed,
+			"funded_elsewhere":   summary.fundedElsewhere,
+			"consumed_elsewhere": summary.consumedByOther,
+			"net":                summary.fundedElsewhere - summary.consumedByOther,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"store_group_id": storeGroup.ID.String(),
+		"from":           *reqQuery.From,
+		"to":             *reqQuery.To,
+		"flows":          flows,
+		"stores":         stores,
+	})
+}
@@ -0,0 +1,407 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	"backend/token"
+	distlockutil "backend/util/distlock"
+	logutil "backend/util/log"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// getStoreDefaultCreditLimit 商店未設定時預設信用額度為 0，即不可欠款
+func (s *Server) getStoreDefaultCreditLimit(c *gin.Context, storeID uuid.UUID) (int32, error) {
+	storeCreditLimit, err := s.store.GetStoreCreditLimit(c, storeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return storeCreditLimit.DefaultCreditLimit, nil
+}
+
+// getEffectiveCreditLimit 優先使用 store user 的信用額度，未設定時使用商店預設值
+func (s *Server) getEffectiveCreditLimit(c *gin.Context, storeID, userID uuid.UUID) (int32, error) {
+	arg := db.GetStoreUserCreditLimitParams{
+		StoreID: storeID,
+		UserID:  userID,
+	}
+	storeUserCreditLimit, err := s.store.GetStoreUserCreditLimit(c, arg)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if err == nil && storeUserCreditLimit.CreditLimit.Valid {
+		return storeUserCreditLimit.CreditLimit.Int32, nil
+	}
+	return s.getStoreDefaultCreditLimit(c, storeID)
+}
+
+type getStoreCreditLimitUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+func (s *Server) getStoreCreditLimit(c *gin.Context) {
+	var reqUri getStoreCreditLimitUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	defaultCreditLimit, err := s.getStoreDefaultCreditLimit(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store credit limit error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"default_credit_limit": defaultCreditLimit,
+	})
+}
+
+type updateStoreCreditLimitUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+type updateStoreCreditLimitRequest struct {
+	DefaultCreditLimit *int32 `json:"default_credit_limit"`
+}
+
+func (s *Server) updateStoreCreditLimit(c *gin.Context) {
+	var reqUri updateStoreCreditLimitUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	var reqJson updateStoreCreditLimitRequest
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqJson.DefaultCreditLimit == nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "default_credit_limit is null"))
+		return
+	}
+
+	if *reqJson.DefaultCreditLimit < 0 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "default_credit_limit is smaller than 0"))
+		return
+	}
+
+	if *reqJson.DefaultCreditLimit > s.config.MaxCreditLimit {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("default_credit_limit is greater than %d", s.config.MaxCreditLimit)))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.SetStoreCreditLimitWithLogParams{
+		ChangedAt:          time.Now().UnixMilli(),
+		ChangeType:         storeChangedTypeCreditLimit,
+		ChangedBy:          uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent:   sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:    sql.NullString{Valid: true, String: c.ClientIP()},
+		StoreID:            storeID,
+		DefaultCreditLimit: *reqJson.DefaultCreditLimit,
+		UpdatedBy:          authPayload.Subject,
+	}
+	if _, err := s.store.SetStoreCreditLimitWithLog(c, arg); err != nil {
+		logutil.GetLogger().Errorf("set store credit limit with log error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type getStoreUserCreditLimitUri struct {
+	StoreID *string `uri:"store_id"`
+	UserID  *string `uri:"user_id"`
+}
+
+func (s *Server) getStoreUserCreditLimit(c *gin.Context) {
+	var reqUri getStoreUserCreditLimitUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	if reqUri.UserID == nil || *reqUri.UserID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "user_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+		return
+	}
+
+	userID, err := uuid.Parse(*reqUri.UserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+		return
+	}
+
+	arg1 := db.GetStoreUserParams{
+		StoreID: storeID,
+		UserID:  userID,
+	}
+
+	if _, err := s.store.GetStoreUser(c, arg1); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get store user error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	arg2 := db.GetStoreUserCreditLimitParams{
+		StoreID: storeID,
+		UserID:  userID,
+	}
+
+	var creditLimit *int32
+	storeUserCreditLimit, err := s.store.GetStoreUserCreditLimit(c, arg2)
+	if err != nil && err != sql.ErrNoRows {
+		logutil.GetLogger().Errorf("get store user credit limit error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if err == nil && storeUserCreditLimit.CreditLimit.Valid {
+		creditLimit = &storeUserCreditLimit.CreditLimit.Int32
+	}
+
+	defaultCreditLimit, err := s.getStoreDefaultCreditLimit(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store credit limit error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	effectiveCreditLimit := defaultCreditLimit
+	if creditLimit != nil {
+		effectiveCreditLimit = *creditLimit
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"credit_limit":           creditLimit,
+		"default_credit_limit":   defaultCreditLimit,
+		"effective_credit_limit": effectiveCreditLimit,
+	})
+}
+
+type updateStoreUserCreditLimitUri struct {
+	StoreID *string `uri:"store_id"`
+	UserID  *string `uri:"user_id"`
+}
+
+type updateStoreUserCreditLimitRequest struct {
+	CreditLimit *int32 `json:"credit_limit"`
+	UseDefault  *bool  `json:"use_default"`
+}
+
+func (s *Server) updateStoreUserCreditLimit(c *gin.Context) {
+	var reqUri updateStoreUserCreditLimitUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	if reqUri.UserID == nil || *reqUri.UserID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "user_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+		return
+	}
+
+	userID, err := uuid.Parse(*reqUri.UserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+		return
+	}
+
+	var reqJson updateStoreUserCreditLimitRequest
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	// use_default 為 true 時清除個別設定，改用商店預設值
+	var creditLimit sql.NullInt32
+	if reqJson.UseDefault == nil || !*reqJson.UseDefault {
+		if reqJson.CreditLimit == nil {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "credit_limit is null"))
+			return
+		}
+
+		if *reqJson.CreditLimit < 0 {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "credit_limit is smaller than 0"))
+			return
+		}
+
+		if *reqJson.CreditLimit > s.config.MaxCreditLimit {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("credit_limit is greater than %d", s.config.MaxCreditLimit)))
+			return
+		}
+
+		creditLimit = sql.NullInt32{Valid: true, Int32: *reqJson.CreditLimit}
+	}
+
+	m := s.rs.NewMutex(distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+		}
+	}()
+
+	arg1 := db.GetStoreUserParams{
+		StoreID: storeID,
+		UserID:  userID,
+	}
+
+	if _, err := s.store.GetStoreUser(c, arg1); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get store user error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg2 := db.SetStoreUserCreditLimitWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangeType:       storeUserChangedTypeCreditLimit,
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		StoreID:          storeID,
+		UserID:           userID,
+		CreditLimit:      creditLimit,
+	}
+	if _, err := s.store.SetStoreUserCreditLimitWithLog(c, arg2); err != nil {
+		logutil.GetLogger().Errorf("set store user credit limit with log error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type getStoreDebtReportUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+// getStoreDebtReport 列出目前 balance 為負數的顧客
+func (s *Server) getStoreDebtReport(c *gin.Context) {
+	var reqUri getStoreDebtReportUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	defaultCreditLimit, err := s.getStoreDefaultCreditLimit(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store credit limit error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	debtors, err := s.store.GetStoreDebtors(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store debtors error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	var totalDebt int64
+	resp := make([]gin.H, 0, len(debtors))
+	for _, debtor := range debtors {
+		creditLimit := defaultCreditLimit
+		if debtor.CreditLimit.Valid {
+			creditLimit = debtor.CreditLimit.Int32
+		}
+		totalDebt += int64(-debtor.Balance)
+		resp = append(resp, gin.H{
+			"user_id":         debtor.UserID.String(),
+			"phone_number":    debtor.PhoneNumber,
+			"name":            debtor.Name,
+			"balance":         debtor.Balance,
+			"points":          debtor.Points,
+			"balance_earmark": debtor.BalanceEarmark,
+			"points_earmark":  debtor.PointsEarmark,
+			"debt":            -debtor.Balance,
+			"credit_limit":    creditLimit,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total_debt": totalDebt,
+		"debtors":    resp,
+	})
+}
@@ -0,0 +1,313 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	"backend/token"
+	distlockutil "backend/util/distlock"
+	logutil "backend/util/log"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	deviceDisplayTypeChangedTypeCreate     string = "create"
+	deviceDisplayTypeChangedTypeUpdateInfo string = "update_info"
+	deviceDisplayTypeChangedTypeDelete     string = "delete"
+)
+
+var deviceDisplayTypeCodeRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// deviceDisplayTypeProgram 機台類型的預設程式，points 為啟動所需點數
+type deviceDisplayTypeProgram struct {
+	Name            string `json:"name"`
+	Points          int32  `json:"points"`
+	DurationMinutes int32  `json:"duration_minutes"`
+}
+
+func newDeviceDisplayTypeResponse(displayType db.DeviceDisplayType) gin.H {
+	return gin.H{
+		"code":                      displayType.Code,
+		"names":                     displayType.Names,
+		"icon_key":                  displayType.IconKey,
+		"default_programs":          displayType.DefaultPrograms,
+		"consumes_points_over_time": displayType.ConsumesPointsOverTime,
+		"is_built_in":               displayType.IsBuiltIn,
+		"created_at":                displayType.CreatedAt,
+	}
+}
+
+type deviceDisplayTypeInfoRequest struct {
+	Names                  map[string]string          `json:"names"`
+	IconKey                *string                    `json:"icon_key"`
+	DefaultPrograms        []deviceDisplayTypeProgram `json:"default_programs"`
+	ConsumesPointsOverTime *bool                      `json:"consumes_points_over_time"`
+}
+
+// validate 檢查並轉成 db 欄位，失敗時已回應
+func (req deviceDisplayTypeInfoRequest) validate(c *gin.Context) (names json.RawMessage, programs json.RawMessage, ok bool) {
+	if len(req.Names) == 0 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "names is null or empty"))
+		return nil, nil, false
+	}
+	for locale, name := range req.Names {
+		if locale == "" || name == "" {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("names contains empty locale or name, locale=%s", locale)))
+			return nil, nil, false
+		}
+	}
+
+	if req.IconKey == nil || *req.IconKey == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "icon_key is null or empty"))
+		return nil, nil, false
+	}
+
+	if req.ConsumesPointsOverTime == nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "consumes_points_over_time is null"))
+		return nil, nil, false
+	}
+
+	if req.DefaultPrograms == nil {
+		req.DefaultPrograms = []deviceDisplayTypeProgram{}
+	}
+	for _, program := range req.DefaultPrograms {
+		if program.Name == "" {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "default_programs contains empty name"))
+			return nil, nil, false
+		}
+		if program.Points <= 0 || program.DurationMinutes <= 0 {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError,
+				fmt.Sprintf("default program points and duration_minutes should be greater than 0, name=%s", program.Name)))
+			return nil, nil, false
+		}
+	}
+
+	names, _ = json.Marshal(req.Names)
+	programs, _ = json.Marshal(req.DefaultPrograms)
+	return names, programs, true
+}
+
+func (s *Server) getDeviceDisplayTypes(c *gin.Context) {
+	displayTypes, err := s.store.GetDeviceDisplayTypes(c)
+	if err != nil {
+		logutil.GetLogger().Errorf("get device display types error, err=%s", err)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	res := make([]gin.H, 0, len(displayTypes))
+	for _, displayType := range displayTypes {
+		res = append(res, newDeviceDisplayTypeResponse(displayType))
+	}
+	c.JSON(http.StatusOK, gin.H{"display_types": res})
+}
+
+type createDeviceDisplayTypeRequest struct {
+	Code *string `json:"code"`
+	deviceDisplayTypeInfoRequest
+}
+
+func (s *Server) createDeviceDisplayType(c *gin.Context) {
+	var req createDeviceDisplayTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.Code == nil || *req.Code == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "code is null or empty"))
+		return
+	}
+
+	if !deviceDisplayTypeCodeRegexp.MatchString(*req.Code) {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("code is invalid, code=%s", *req.Code)))
+		return
+	}
+
+	names, programs, ok := req.validate(c)
+	if !ok {
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.CreateDeviceDisplayTypeWithLogParams{
+		ChangedAt:              time.Now().UnixMilli(),
+		ChangeType:             deviceDisplayTypeChangedTypeCreate,
+		ChangedBy:              uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent:       sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:        sql.NullString{Valid: true, String: c.ClientIP()},
+		Code:                   *req.Code,
+		Names:                  names,
+		IconKey:                *req.IconKey,
+		DefaultPrograms:        programs,
+		ConsumesPointsOverTime: *req.ConsumesPointsOverTime,
+	}
+	displayType, err := s.store.CreateDeviceDisplayTypeWithLog(c, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeDeviceDisplayTypeRegisteredError, fmt.Sprintf("device display type exists, code=%s", *req.Code)))
+			return
+		}
+		logutil.GetLogger().Errorf("create device display type with log error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, newDeviceDisplayTypeResponse(displayType))
+}
+
+type updateDeviceDisplayTypeUri struct {
+	Code *string `uri:"code"`
+}
+
+func (s *Server) updateDeviceDisplayType(c *gin.Context) {
+	var reqUri updateDeviceDisplayTypeUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	var req deviceDisplayTypeInfoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.Code == nil || *reqUri.Code == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "code is null or empty"))
+		return
+	}
+
+	names, programs, ok := req.validate(c)
+	if !ok {
+		return
+	}
+
+	m := s.rs.NewMutex(distlockutil.GetDeviceDisplayTypeCodeMutexName(*reqUri.Code))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetDeviceDisplayTypeCodeMutexName(*reqUri.Code))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetDeviceDisplayTypeCodeMutexName(*reqUri.Code))
+		}
+	}()
+
+	displayType, err := s.store.GetDeviceDisplayType(c, *reqUri.Code)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeDeviceDisplayTypeNotFoundError, fmt.Sprintf("device display type not found, code=%s", *reqUri.Code)))
+			return
+		}
+		logutil.GetLogger().Errorf("get device display type error, err=%s, code=%s", err, *reqUri.Code)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.SetDeviceDisplayTypeInfoWithLogParams{
+		ChangedAt:              time.Now().UnixMilli(),
+		ChangeType:             deviceDisplayTypeChangedTypeUpdateInfo,
+		ChangedBy:              uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent:       sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:        sql.NullString{Valid: true, String: c.ClientIP()},
+		Code:                   displayType.Code,
+		Names:                  names,
+		IconKey:                *req.IconKey,
+		DefaultPrograms:        programs,
+		ConsumesPointsOverTime: *req.ConsumesPointsOverTime,
+	}
+	if err := s.store.SetDeviceDisplayTypeInfoWithLog(c, arg); err != nil {
+		logutil.GetLogger().Errorf("set device display type info with log error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type deleteDeviceDisplayTypeUri struct {
+	Code *string `uri:"code"`
+}
+
+func (s *Server) deleteDeviceDisplayType(c *gin.Context) {
+	var req deleteDeviceDisplayTypeUri
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.Code == nil || *req.Code == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "code is null or empty"))
+		return
+	}
+
+	m := s.rs.NewMutex(distlockutil.GetDeviceDisplayTypeCodeMutexName(*req.Code))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetDeviceDisplayTypeCodeMutexName(*req.Code))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetDeviceDisplayTypeCodeMutexName(*req.Code))
+		}
+	}()
+
+	displayType, err := s.store.GetDeviceDisplayType(c, *req.Code)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeDeviceDisplayTypeNotFoundError, fmt.Sprintf("device display type not found, code=%s", *req.Code)))
+			return
+		}
+		logutil.GetLogger().Errorf("get device display type error, err=%s, code=%s", err, *req.Code)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	// 內建的 display type 為新機台的預設值，不可刪除
+	if displayType.IsBuiltIn {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("built-in device display type cannot be deleted, code=%s", displayType.Code)))
+		return
+	}
+
+	// 仍有機台使用的 display type 不可刪除
+	count, err := s.store.CountStoreDevicesByDisplayType(c, displayType.Code)
+	if err != nil {
+		logutil.GetLogger().Errorf("count store devices by display type error, err=%s, code=%s", err, displayType.Code)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeDeviceDisplayTypeInUseError, fmt.Sprintf("device display type is in use, code=%s, count=%d", displayType.Code, count)))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.DeleteDeviceDisplayTypeWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangeType:       deviceDisplayTypeChangedTypeDelete,
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		Code:             displayType.Code,
+	}
+	if err := s.store.DeleteDeviceDisplayTypeWithLog(c, arg); err != nil {
+		logutil.GetLogger().Errorf("delete device display type with log error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
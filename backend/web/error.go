@@ -17,10 +17,16 @@ const (
 	codeForbiddenError                             string = "ForbiddenError"
 	codeSendCheckPhoneNumberOwnerMsgMeetLimitError string = "SendCheckPhoneNumberOwnerMsgMeetLimitError"
 	codeSendResetPasswordMsgMeetLimitError         string = "SendResetPasswordMsgMeetLimitError"
+	codeSendChangePhoneNumberMsgMeetLimitError     string = "SendChangePhoneNumberMsgMeetLimitError"
+	codeSendLinkExternalIdentityMsgMeetLimitError  string = "SendLinkExternalIdentityMsgMeetLimitError"
 	codePhoneNumberRegisteredError                 string = "PhoneNumberRegisteredError"
 	codePhoneNumberNotRegisterError                string = "PhoneNumberNotRegisterError"
 	codeWrongVerCodeError                          string = "WrongVerCodeError"
 	codeWeakPasswordError                          string = "WeakPasswordError"
+	codePasswordTooShortError                      string = "PasswordTooShortError"
+	codePasswordMissingCharClassError              string = "PasswordMissingCharClassError"
+	codeCommonPasswordError                        string = "CommonPasswordError"
+	codePasswordReusedError                        string = "PasswordReusedError"
 	codeNewPasswordIsOldPasswordError              string = "NewPasswordIsOldPasswordError"
 	codeWrongOldPasswordError                      string = "WrongOldPasswordError"
 	codePhoneNumberOrPasswordError                 string = "PhoneNumberOrPasswordError"
@@ -29,10 +35,39 @@ const (
 	codeStoreUserRegisteredError                   string = "StoreUserRegisteredError"
 	codeStoreUserNotRegisterError                  string = "StoreUserNotRegisterError"
 	codeLowBalanceError                            string = "LowBalanceError"
+	codeInvalidOidcStateError                      string = "InvalidOidcStateError"
+	codeOidcAuthFailedError                        string = "OidcAuthFailedError"
+	codeInvalidLinkTokenError                      string = "InvalidLinkTokenError"
+	codeExternalIdentityLinkedError                string = "ExternalIdentityLinkedError"
+	codeRoleInUseError                             string = "RoleInUseError"
+	codeInvalidJoinCodeError                       string = "InvalidJoinCodeError"
+	codeBalanceAdjustmentReviewedError             string = "BalanceAdjustmentReviewedError"
+	codeBalanceTransferMeetLimitError              string = "BalanceTransferMeetLimitError"
+	codeFamilyWalletSpendingCapError               string = "FamilyWalletSpendingCapError"
+	codeFamilyWalletMemberRegisteredError          string = "FamilyWalletMemberRegisteredError"
+	codeStoreDeviceUnderMaintenanceError           string = "StoreDeviceUnderMaintenanceError"
+	codeStoreDeviceNotActiveError                  string = "StoreDeviceNotActiveError"
+	codeStoreDeviceHardwareInUseError              string = "StoreDeviceHardwareInUseError"
+	codeStoreDeviceRegisteredError                 string = "StoreDeviceRegisteredError"
+	codeDeviceDisplayTypeRegisteredError           string = "DeviceDisplayTypeRegisteredError"
+	codeDeviceDisplayTypeInUseError                string = "DeviceDisplayTypeInUseError"
 
-	codeStoreNotFoundError       string = "StoreNotFoundError"
-	codeStoreUserNotFoundError   string = "StoreUserNotFoundError"
-	codeStoreDeviceNotFoundError string = "StoreDeviceNotFoundError"
+	codeStoreNotFoundError              string = "StoreNotFoundError"
+	codeStoreUserNotFoundError          string = "StoreUserNotFoundError"
+	codeStoreDeviceNotFoundError        string = "StoreDeviceNotFoundError"
+	codeApiKeyNotFoundError             string = "ApiKeyNotFoundError"
+	codeOidcProviderNotFoundError       string = "OidcProviderNotFoundError"
+	codeRoleNotFoundError               string = "RoleNotFoundError"
+	codeStoreJoinCodeNotFoundError      string = "StoreJoinCodeNotFoundError"
+	codeStoreInvitationNotFoundError    string = "StoreInvitationNotFoundError"
+	codeStoreGroupNotFoundError         string = "StoreGroupNotFoundError"
+	codeUserNotFoundError               string = "UserNotFoundError"
+	codeBalanceAdjustmentNotFoundError  string = "BalanceAdjustmentNotFoundError"
+	codeFamilyWalletNotFoundError       string = "FamilyWalletNotFoundError"
+	codeFamilyWalletMemberNotFoundError string = "FamilyWalletMemberNotFoundError"
+	codeBrandWalletNotFoundError        string = "BrandWalletNotFoundError"
+	codeStoreDeviceZoneNotFoundError    string = "StoreDeviceZoneNotFoundError"
+	codeDeviceDisplayTypeNotFoundError  string = "DeviceDisplayTypeNotFoundError"
 
 	codeStoreDeviceNotOnlineError string = "StoreDeviceNotOnlineError"
 	codeStoreNotOnlineError       string = "StoreNotOnlineError"
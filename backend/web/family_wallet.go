@@ -0,0 +1,683 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	"backend/token"
+	distlockutil "backend/util/distlock"
+	fsmutil "backend/util/fsm"
+	logutil "backend/util/log"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func newSpendingCapResponse(spendingCap sql.NullInt32) *int32 {
+	if !spendingCap.Valid {
+		return nil
+	}
+	return &spendingCap.Int32
+}
+
+// getOwnFamilyWallet 取得使用者在商店擁有的家庭錢包，不存在或發生錯誤時會直接回應
+func (s *Server) getOwnFamilyWallet(c *gin.Context, storeID, userID uuid.UUID) (db.FamilyWallet, bool) {
+	arg := db.GetFamilyWalletByOwnerParams{
+		StoreID:     storeID,
+		OwnerUserID: userID,
+	}
+	familyWallet, err := s.store.GetFamilyWalletByOwner(c, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeFamilyWalletNotFoundError, fmt.Sprintf("family wallet not found, store_id=%s, owner_user_id=%s", storeID, userID)))
+			return familyWallet, false
+		}
+		logutil.GetLogger().Errorf("get family wallet by owner error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return familyWallet, false
+	}
+	return familyWallet, true
+}
+
+// sumFamilyWalletMemberSpendings 成員在 spending cap 計算期間內已花費的金額
+func (s *Server) sumFamilyWalletMemberSpendings(c *gin.Context, familyWalletID, userID uuid.UUID) (int64, error) {
+	return s.store.SumFamilyWalletMemberSpendings(c, db.SumFamilyWalletMemberSpendingsParams{
+		FamilyWalletID: familyWalletID,
+		UserID:         userID,
+		Ts:             time.Now().Add(-s.config.FamilyWallet.SpendingCapPeriod).UnixMilli(),
+	})
+}
+
+type getFamilyWalletUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+func (s *Server) getFamilyWallet(c *gin.Context) {
+	var reqUri getFamilyWalletUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	userID := authPayload.Subject
+
+	// 成員只能看到自己的額度與花費
+	arg1 := db.GetFamilyWalletMemberParams{
+		StoreID: storeID,
+		UserID:  userID,
+	}
+	member, err := s.store.GetFamilyWalletMember(c, arg1)
+	if err != nil && err != sql.ErrNoRows {
+		logutil.GetLogger().Errorf("get family wallet member error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if err == nil {
+		familyWallet, err := s.store.GetFamilyWallet(c, member.FamilyWalletID)
+		if err != nil {
+			logutil.GetLogger().Errorf("get family wallet error, err=%s, family_wallet_id=%s", err, member.FamilyWalletID)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+
+		spent, err := s.sumFamilyWalletMemberSpendings(c, member.FamilyWalletID, userID)
+		if err != nil {
+			logutil.GetLogger().Errorf("sum family wallet member spendings error, err=%s, family_wallet_id=%s, user_id=%s", err, member.FamilyWalletID, userID)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"id":            familyWallet.ID.String(),
+			"owner_user_id": familyWallet.OwnerUserID.String(),
+			"is_owner":      false,
+			"accepted":      member.AcceptedAt.Valid,
+			"spending_cap":  newSpendingCapResponse(member.SpendingCap),
+			"spent":         spent,
+			"created_at":    familyWallet.CreatedAt,
+		})
+		return
+	}
+
+	familyWallet, ok := s.getOwnFamilyWallet(c, storeID, userID)
+	if !ok {
+		return
+	}
+
+	members, err := s.store.GetFamilyWalletMembers(c, familyWallet.ID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get family wallet members error, err=%s, family_wallet_id=%s", err, familyWallet.ID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	resp := make([]gin.H, 0, len(members))
+	for _, member := range members {
+		spent, err := s.sumFamilyWalletMemberSpendings(c, familyWallet.ID, member.UserID)
+		if err != nil {
+			logutil.GetLogger().Errorf("sum family wallet member spendings error, err=%s, family_wallet_id=%s, user_id=%s", err, familyWallet.ID, member.UserID)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		resp = append(resp, gin.H{
+			"user_id":      member.UserID.String(),
+			"phone_number": member.PhoneNumber,
+			"name":         member.Name,
+			"accepted":     member.AcceptedAt.Valid,
+			"spending_cap": newSpendingCapResponse(member.SpendingCap),
+			"spent":        spent,
+			"created_at":   member.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":            familyWallet.ID.String(),
+		"owner_user_id": familyWallet.OwnerUserID.String(),
+		"is_owner":      true,
+		"members":       resp,
+		"created_at":    familyWallet.CreatedAt,
+	})
+}
+
+type createFamilyWalletUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+func (s *Server) createFamilyWallet(c *gin.Context) {
+	var reqUri createFamilyWalletUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	userID := authPayload.Subject
+
+	m := s.rs.NewMutex(distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+		}
+	}()
+
+	// 已是其他家庭錢包成員的 store user 不可再建立錢包
+	arg1 := db.GetFamilyWalletMemberParams{
+		StoreID: storeID,
+		UserID:  userID,
+	}
+	if _, err := s.store.GetFamilyWalletMember(c, arg1); err != sql.ErrNoRows {
+		if err == nil {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeFamilyWalletMemberRegisteredError, fmt.Sprintf("store user is a family wallet member, store_id=%s, user_id=%s", storeID, userID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get family wallet member error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	arg2 := db.GetFamilyWalletByOwnerParams{
+		StoreID:     storeID,
+		OwnerUserID: userID,
+	}
+	familyWallet, err := s.store.GetFamilyWalletByOwner(c, arg2)
+	if err == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"id": familyWallet.ID.String(),
+		})
+		return
+	}
+	if err != sql.ErrNoRows {
+		logutil.GetLogger().Errorf("get family wallet by owner error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	arg3 := db.CreateFamilyWalletParams{
+		ID:          uuid.New(),
+		StoreID:     storeID,
+		OwnerUserID: userID,
+	}
+	if _, err := s.store.CreateFamilyWallet(c, arg3); err != nil {
+		logutil.GetLogger().Errorf("create family wallet error, err=%s, arg=%#v", err, arg3)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id": arg3.ID.String(),
+	})
+}
+
+type addFamilyWalletMemberUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+type addFamilyWalletMemberRequest struct {
+	PhoneNumber *string `json:"phone_number"`
+	SpendingCap *int32  `json:"spending_cap"`
+}
+
+// addFamilyWalletMember 邀請成員加入家庭錢包，成員接受邀請前仍由自己的 store user 扣款
+func (s *Server) addFamilyWalletMember(c *gin.Context) {
+	var reqUri addFamilyWalletMemberUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	var reqJson addFamilyWalletMemberRequest
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqJson.PhoneNumber == nil || *reqJson.PhoneNumber == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "phone_number is null or empty"))
+		return
+	}
+
+	// spending_cap 為 null 時不限制
+	var spendingCap sql.NullInt32
+	if reqJson.SpendingCap != nil {
+		if *reqJson.SpendingCap < 0 {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "spending_cap is smaller than 0"))
+			return
+		}
+		spendingCap = sql.NullInt32{Valid: true, Int32: *reqJson.SpendingCap}
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	userID := authPayload.Subject
+
+	// 與 insert-coins 使用同一個 lock，避免加入成員時錢包同時被使用
+	m := s.rs.NewMutex(distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+		}
+	}()
+
+	familyWallet, ok := s.getOwnFamilyWallet(c, storeID, userID)
+	if !ok {
+		return
+	}
+
+	user, err := s.store.GetUserByPhoneNumber(c, *reqJson.PhoneNumber)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codePhoneNumberNotRegisterError, fmt.Sprintf("phone number is not registered, phone_number=%s", *reqJson.PhoneNumber)))
+			return
+		}
+		logutil.GetLogger().Errorf("get user by phone number error, err=%s, phone_number=%s", err, *reqJson.PhoneNumber)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if user.ID == userID {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "owner cannot be a family wallet member"))
+		return
+	}
+
+	arg1 := db.GetStoreUserParams{
+		StoreID: storeID,
+		UserID:  user.ID,
+	}
+	storeUser, err := s.store.GetStoreUser(c, arg1)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", storeID, user.ID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get store user error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if storeUser.State != fsmutil.StoreUserStateActive {
+		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, fmt.Sprintf("store user state is not active, store_id=%s, user_id=%s", storeID, user.ID)))
+		return
+	}
+
+	// 擁有錢包的 store user 不可成為其他錢包的成員
+	arg2 := db.GetFamilyWalletByOwnerParams{
+		StoreID:     storeID,
+		OwnerUserID: user.ID,
+	}
+	if _, err := s.store.GetFamilyWalletByOwner(c, arg2); err != sql.ErrNoRows {
+		if err == nil {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeFamilyWalletMemberRegisteredError, fmt.Sprintf("store user owns a family wallet, store_id=%s, user_id=%s", storeID, user.ID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get family wallet by owner error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	arg3 := db.GetFamilyWalletMemberParams{
+		StoreID: storeID,
+		UserID:  user.ID,
+	}
+	if _, err := s.store.GetFamilyWalletMember(c, arg3); err != sql.ErrNoRows {
+		if err == nil {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeFamilyWalletMemberRegisteredError, fmt.Sprintf("store user is a family wallet member, store_id=%s, user_id=%s", storeID, user.ID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get family wallet member error, err=%s, arg=%#v", err, arg3)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	count, err := s.store.CountFamilyWalletMembers(c, familyWallet.ID)
+	if err != nil {
+		logutil.GetLogger().Errorf("count family wallet members error, err=%s, family_wallet_id=%s", err, familyWallet.ID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if count >= int64(s.config.FamilyWallet.MaxMembers) {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("family wallet members more than %d", s.config.FamilyWallet.MaxMembers)))
+		return
+	}
+
+	arg4 := db.AddFamilyWalletMemberParams{
+		FamilyWalletID: familyWallet.ID,
+		StoreID:        storeID,
+		UserID:         user.ID,
+		SpendingCap:    spendingCap,
+		CreatedBy:      userID,
+	}
+	if _, err := s.store.AddFamilyWalletMember(c, arg4); err != nil {
+		logutil.GetLogger().Errorf("add family wallet member error, err=%s, arg=%#v", err, arg4)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id": user.ID.String(),
+	})
+}
+
+type acceptFamilyWalletUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+// acceptFamilyWallet 受邀成員接受邀請後才會由錢包擁有者扣款，拒絕邀請或退出使用 removeFamilyWalletMember
+func (s *Server) acceptFamilyWallet(c *gin.Context) {
+	var reqUri acceptFamilyWalletUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.AcceptFamilyWalletMemberParams{
+		StoreID:    storeID,
+		UserID:     authPayload.Subject,
+		AcceptedAt: sql.NullInt64{Valid: true, Int64: time.Now().UnixMilli()},
+	}
+	rows, err := s.store.AcceptFamilyWalletMember(c, arg)
+	if err != nil {
+		logutil.GetLogger().Errorf("accept family wallet member error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if rows == 0 {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeFamilyWalletMemberNotFoundError, fmt.Sprintf("pending family wallet invitation not found, store_id=%s, user_id=%s", storeID, authPayload.Subject)))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type familyWalletMemberUri struct {
+	StoreID *string `uri:"store_id"`
+	UserID  *string `uri:"user_id"`
+}
+
+func bindFamilyWalletMemberUri(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	var reqUri familyWalletMemberUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+
+	if reqUri.UserID == nil || *reqUri.UserID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "user_id is null or empty"))
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeFamilyWalletMemberNotFoundError, fmt.Sprintf("family wallet member not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+
+	userID, err := uuid.Parse(*reqUri.UserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeFamilyWalletMemberNotFoundError, fmt.Sprintf("family wallet member not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+
+	return storeID, userID, true
+}
+
+type updateFamilyWalletMemberSpendingCapRequest struct {
+	SpendingCap *int32 `json:"spending_cap"`
+	Unlimited   *bool  `json:"unlimited"`
+}
+
+func (s *Server) updateFamilyWalletMemberSpendingCap(c *gin.Context) {
+	storeID, memberUserID, ok := bindFamilyWalletMemberUri(c)
+	if !ok {
+		return
+	}
+
+	var reqJson updateFamilyWalletMemberSpendingCapRequest
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	// unlimited 為 true 時取消成員的花費上限
+	var spendingCap sql.NullInt32
+	if reqJson.Unlimited == nil || !*reqJson.Unlimited {
+		if reqJson.SpendingCap == nil {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "spending_cap is null"))
+			return
+		}
+
+		if *reqJson.SpendingCap < 0 {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "spending_cap is smaller than 0"))
+			return
+		}
+
+		spendingCap = sql.NullInt32{Valid: true, Int32: *reqJson.SpendingCap}
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	familyWallet, ok := s.getOwnFamilyWallet(c, storeID, authPayload.Subject)
+	if !ok {
+		return
+	}
+
+	arg := db.SetFamilyWalletMemberSpendingCapParams{
+		FamilyWalletID: familyWallet.ID,
+		UserID:         memberUserID,
+		SpendingCap:    spendingCap,
+	}
+	rows, err := s.store.SetFamilyWalletMemberSpendingCap(c, arg)
+	if err != nil {
+		logutil.GetLogger().Errorf("set family wallet member spending cap error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if rows == 0 {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeFamilyWalletMemberNotFoundError, fmt.Sprintf("family wallet member not found, store_id=%s, user_id=%s", storeID, memberUserID)))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// removeFamilyWalletMember 錢包擁有者可移除成員，成員也可自行拒絕邀請或退出
+func (s *Server) removeFamilyWalletMember(c *gin.Context) {
+	storeID, memberUserID, ok := bindFamilyWalletMemberUri(c)
+	if !ok {
+		return
+	}
+
+	arg1 := db.GetFamilyWalletMemberParams{
+		StoreID: storeID,
+		UserID:  memberUserID,
+	}
+	member, err := s.store.GetFamilyWalletMember(c, arg1)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeFamilyWalletMemberNotFoundError, fmt.Sprintf("family wallet member not found, store_id=%s, user_id=%s", storeID, memberUserID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get family wallet member error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	familyWallet, err := s.store.GetFamilyWallet(c, member.FamilyWalletID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get family wallet error, err=%s, family_wallet_id=%s", err, member.FamilyWalletID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Subject != familyWallet.OwnerUserID && authPayload.Subject != memberUserID {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeFamilyWalletMemberNotFoundError, fmt.Sprintf("family wallet member not found, store_id=%s, user_id=%s", storeID, memberUserID)))
+		return
+	}
+
+	arg2 := db.RemoveFamilyWalletMemberParams{
+		FamilyWalletID: familyWallet.ID,
+		UserID:         memberUserID,
+	}
+	if _, err := s.store.RemoveFamilyWalletMember(c, arg2); err != nil {
+		logutil.GetLogger().Errorf("remove family wallet member error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type getFamilyWalletSpendingsUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+type getFamilyWalletSpendingsQuery struct {
+	From *int64 `form:"from"`
+	To   *int64 `form:"to"`
+}
+
+// getFamilyWalletSpendings 錢包擁有者查看成員的花費，預設為最近一個 spending cap 計算期間
+func (s *Server) getFamilyWalletSpendings(c *gin.Context) {
+	var reqUri getFamilyWalletSpendingsUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	var reqQuery getFamilyWalletSpendingsQuery
+	if err := c.ShouldBindQuery(&reqQuery); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	to := time.Now().UnixMilli()
+	if reqQuery.To != nil {
+		to = *reqQuery.To
+	}
+	from := to - s.config.FamilyWallet.SpendingCapPeriod.Milliseconds()
+	if reqQuery.From != nil {
+		from = *reqQuery.From
+	}
+
+	if from >= to {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "from is greater than or equal to to"))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	familyWallet, ok := s.getOwnFamilyWallet(c, storeID, authPayload.Subject)
+	if !ok {
+		return
+	}
+
+	arg := db.GetFamilyWalletSpendingsParams{
+		FamilyWalletID: familyWallet.ID,
+		FromTs:         from,
+		ToTs:           to,
+	}
+	spendings, err := s.store.GetFamilyWalletSpendings(c, arg)
+	if err != nil {
+		logutil.GetLogger().Errorf("get family wallet spendings error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	totals := make(map[string]int64)
+	resp := make([]gin.H, 0, len(spendings))
+	for _, spending := range spendings {
+		totals[spending.UserID.String()] += int64(spending.Amount + spending.PointAmount)
+		resp = append(resp, gin.H{
+			"user_id":      spending.UserID.String(),
+			"user_name":    spending.UserName.String,
+			"device_id":    spending.DeviceID,
+			"amount":       spending.Amount,
+			"point_amount": spending.PointAmount,
+			"ts":           spending.Ts,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":      from,
+		"to":        to,
+		"totals":    totals,
+		"spendings": resp,
+	})
+}
@@ -18,11 +18,14 @@ import (
 const (
 	authorizationHeaderKey  = "authorization"
 	authorizationTypeBearer = "bearer"
+	authorizationTypeApiKey = "apikey"
 	authorizationPayloadKey = "authorization_payload"
 	authorizationScopesKey  = "authorization_scopes"
+	authorizationApiKeyKey  = "authorization_api_key"
 )
 
-func authMiddleware(tokenMaker token.Maker, checkToken func(ctx context.Context, tokenID uuid.UUID) bool) gin.HandlerFunc {
+func authMiddleware(tokenMaker token.Maker, checkToken func(ctx context.Context, tokenID uuid.UUID) bool,
+	checkApiKey func(ctx context.Context, key string, clientIP string) (db.ApiKey, bool)) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authorizationHeader := c.GetHeader(authorizationHeaderKey)
 
@@ -38,6 +41,25 @@ func authMiddleware(tokenMaker token.Maker, checkToken func(ctx context.Context,
 		}
 
 		authorizationType := strings.ToLower(fields[0])
+		if authorizationType == authorizationTypeApiKey {
+			apiKey, ok := checkApiKey(c, fields[1], c.ClientIP())
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, messageForbiddenError))
+				return
+			}
+
+			// api key 以建立者的身分操作，實際可用的 scopes 由 storeUserScopesMiddleware 決定
+			c.Set(authorizationPayloadKey, &token.Payload{
+				ID:        apiKey.ID,
+				Subject:   apiKey.CreatedBy,
+				IssuedAt:  apiKey.CreatedAt,
+				ExpiredAt: apiKey.ExpiredAt.Int64,
+			})
+			c.Set(authorizationApiKeyKey, apiKey)
+			c.Next()
+			return
+		}
+
 		if authorizationType != authorizationTypeBearer {
 			c.AbortWithStatusJSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, messageForbiddenError))
 			return
@@ -60,8 +82,14 @@ func authMiddleware(tokenMaker token.Maker, checkToken func(ctx context.Context,
 	}
 }
 
-func userScopesMiddleware(s db.IStore) gin.HandlerFunc {
+func userScopesMiddleware(s db.IStore, roles *roleCache) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// api key 只能用於 store user 的 api
+		if _, ok := c.Get(authorizationApiKeyKey); ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, messageForbiddenError))
+			return
+		}
+
 		authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
 
 		user, err := s.GetUser(c, authPayload.Subject)
@@ -75,7 +103,18 @@ func userScopesMiddleware(s db.IStore) gin.HandlerFunc {
 			return
 		}
 
-		scopes := roleutil.GetRoleByID(user.RoleID).UserScopes
+		role, ok, err := roles.get(c, user.RoleID)
+		if err != nil {
+			logutil.GetLogger().Errorf("get role error, err=%s, role_id=%d", err, user.RoleID)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+
+		// 限定 store 的 role 沒有 user scopes
+		scopes := roleutil.Scopes{}
+		if ok && !role.StoreID.Valid {
+			scopes = role.UserScopes
+		}
 		c.Set(authorizationScopesKey, scopes)
 		c.Next()
 	}
@@ -85,7 +124,7 @@ type storeUserScopesMiddlewareUri struct {
 	StoreID *string `uri:"store_id"`
 }
 
-func storeUserScopesMiddleware(s db.IStore) gin.HandlerFunc {
+func storeUserScopesMiddleware(s db.IStore, roles *roleCache) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req storeUserScopesMiddlewareUri
 		if err := c.ShouldBindUri(&req); err != nil {
@@ -104,6 +143,17 @@ func storeUserScopesMiddleware(s db.IStore) gin.HandlerFunc {
 			return
 		}
 
+		var apiKey *db.ApiKey
+		if v, ok := c.Get(authorizationApiKeyKey); ok {
+			k := v.(db.ApiKey)
+			apiKey = &k
+		}
+
+		if apiKey != nil && apiKey.StoreID != storeID {
+			c.AbortWithStatusJSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, "api key is not for this store"))
+			return
+		}
+
 		authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
 
 		arg := db.GetStoreUserParams{
@@ -127,12 +177,89 @@ func storeUserScopesMiddleware(s db.IStore) gin.HandlerFunc {
 			return
 		}
 
-		scopes := roleutil.GetRoleByID(storeUser.RoleID).StoreUserScopes
+		// 可管理 store 但沒有 ScopeStoreAllAccess 的使用者 (hq) 只能存取所屬 store group 的 store
+		user, err := s.GetUser(c, authPayload.Subject)
+		if err != nil {
+			logutil.GetLogger().Errorf("get user error, err=%s, user_id=%s", err, authPayload.Subject)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		userRole, ok, err := roles.get(c, user.RoleID)
+		if err != nil {
+			logutil.GetLogger().Errorf("get role error, err=%s, role_id=%d", err, user.RoleID)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		if ok && !userRole.StoreID.Valid && isStoreGroupLimited(userRole.UserScopes) {
+			arg := db.IsStoreInUserStoreGroupsParams{
+				UserID:  authPayload.Subject,
+				StoreID: storeID,
+			}
+			accessible, err := s.IsStoreInUserStoreGroups(c, arg)
+			if err != nil {
+				logutil.GetLogger().Errorf("is store in user store groups error, err=%s, arg=%#v", err, arg)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+				return
+			}
+			if !accessible {
+				c.AbortWithStatusJSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, "the store is not in the user's store groups"))
+				return
+			}
+		}
+
+		role, ok, err := roles.get(c, storeUser.RoleID)
+		if err != nil {
+			logutil.GetLogger().Errorf("get role error, err=%s, role_id=%d", err, storeUser.RoleID)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+
+		// role 已被刪除或屬於其他 store 時，沒有任何 scope
+		scopes := roleutil.Scopes{}
+		if ok && (!role.StoreID.Valid || role.StoreID.UUID == storeID) {
+			scopes = role.StoreUserScopes
+		}
+
+		override, err := s.GetStoreUserScopeOverride(c, db.GetStoreUserScopeOverrideParams{
+			StoreID: storeID,
+			UserID:  authPayload.Subject,
+		})
+		if err != nil && err != sql.ErrNoRows {
+			logutil.GetLogger().Errorf("get store user scope override error, err=%s, store_id=%s, user_id=%s", err, storeID, authPayload.Subject)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		if err == nil {
+			scopes = mergeScopes(scopes, override.GrantedScopes, override.DeniedScopes)
+		}
+
+		// api key 的 scopes 不可超過建立者目前的 scopes
+		if apiKey != nil {
+			apiKeyScopes := make(roleutil.Scopes, 0, len(apiKey.Scopes))
+			for _, scope := range apiKey.Scopes {
+				if contains(scopes, scope) {
+					apiKeyScopes = append(apiKeyScopes, scope)
+				}
+			}
+			scopes = apiKeyScopes
+		}
+
 		c.Set(authorizationScopesKey, scopes)
 		c.Next()
 	}
 }
 
+// mergeScopes 回傳 (scopes ∪ granted) − denied，deny 優先於 grant
+func mergeScopes(scopes, granted, denied roleutil.Scopes) roleutil.Scopes {
+	result := make(roleutil.Scopes, 0, len(scopes)+len(granted))
+	for _, scope := range append(append(roleutil.Scopes{}, scopes...), granted...) {
+		if !contains(denied, scope) && !contains(result, scope) {
+			result = append(result, scope)
+		}
+	}
+	return result
+}
+
 func checkScopesMiddleware(requiredScopesList ...roleutil.Scopes) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authScopes := c.MustGet(authorizationScopesKey).(roleutil.Scopes)
@@ -3,4 +3,6 @@ package web
 const (
 	verCodeTypeCheckPhoneNumberOwner = "check_phone_number_owner"
 	verCodeTypeResetPassword         = "reset_password"
+	verCodeTypeChangePhoneNumber     = "change_phone_number"
+	verCodeTypeLinkExternalIdentity  = "link_external_identity"
 )
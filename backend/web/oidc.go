@@ -0,0 +1,498 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	distlockutil "backend/util/distlock"
+	fsmutil "backend/util/fsm"
+	logutil "backend/util/log"
+	oidcutil "backend/util/oidc"
+	randomutil "backend/util/random"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type getOidcAuthURLUri struct {
+	Provider *string `uri:"provider"`
+}
+
+func (s *Server) getOidcAuthURL(c *gin.Context) {
+	var req getOidcAuthURLUri
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.Provider == nil || *req.Provider == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "provider is null or empty"))
+		return
+	}
+
+	provider, ok := s.oidcProviders[*req.Provider]
+	if !ok {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeOidcProviderNotFoundError, fmt.Sprintf("oidc provider not found, provider=%s", *req.Provider)))
+		return
+	}
+
+	state, err := oidcutil.GenState()
+	if err != nil {
+		logutil.GetLogger().Errorf("gen state error, err=%s", err)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	nonce, err := oidcutil.GenState()
+	if err != nil {
+		logutil.GetLogger().Errorf("gen nonce error, err=%s", err)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	codeVerifier, err := oidcutil.GenCodeVerifier()
+	if err != nil {
+		logutil.GetLogger().Errorf("gen code verifier error, err=%s", err)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(c, state, nonce, codeVerifier)
+	if err != nil {
+		logutil.GetLogger().Errorf("get auth code url error, err=%s, provider=%s", err, *req.Provider)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	arg := db.CreateOidcAuthRequestParams{
+		State:        state,
+		Provider:     *req.Provider,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		ExpiredAt:    time.Now().Add(s.config.Oidc.StateLiveTime).UnixMilli(),
+	}
+	if _, err := s.store.CreateOidcAuthRequest(c, arg); err != nil {
+		logutil.GetLogger().Errorf("create oidc auth request error, err=%s, provider=%s", err, *req.Provider)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"auth_url": authURL,
+		"state":    state,
+	})
+}
+
+type loginUserByOidcUri struct {
+	Provider *string `uri:"provider"`
+}
+
+type loginUserByOidcRequest struct {
+	Code  *string `json:"code"`
+	State *string `json:"state"`
+}
+
+func (s *Server) loginUserByOidc(c *gin.Context) {
+	var reqUri loginUserByOidcUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	var req loginUserByOidcRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.Provider == nil || *reqUri.Provider == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "provider is null or empty"))
+		return
+	}
+
+	if req.Code == nil || *req.Code == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "code is null or empty"))
+		return
+	}
+
+	if req.State == nil || *req.State == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "state is null or empty"))
+		return
+	}
+
+	provider, ok := s.oidcProviders[*reqUri.Provider]
+	if !ok {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeOidcProviderNotFoundError, fmt.Sprintf("oidc provider not found, provider=%s", *reqUri.Provider)))
+		return
+	}
+
+	m := s.rs.NewMutex(distlockutil.GetOidcStateMutexName(*req.State))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetOidcStateMutexName(*req.State))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetOidcStateMutexName(*req.State))
+		}
+	}()
+
+	// 檢查 state 是否合法，每個 state 只能使用一次
+	authRequest, err := s.store.GetOidcAuthRequest(c, *req.State)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidOidcStateError, "state is incorrect"))
+			return
+		}
+		logutil.GetLogger().Errorf("get oidc auth request error, err=%s, state=%s", err, *req.State)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if authRequest.Provider != *reqUri.Provider || authRequest.IsUsed || time.Now().After(time.UnixMilli(authRequest.ExpiredAt)) {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidOidcStateError, "state is incorrect"))
+		return
+	}
+
+	rawIDToken, err := provider.Exchange(c, *req.Code, authRequest.CodeVerifier)
+	if err != nil {
+		logutil.GetLogger().Warnf("exchange authorization code error, err=%s, provider=%s", err, *reqUri.Provider)
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeOidcAuthFailedError, "failed to sign in with the provider"))
+		return
+	}
+
+	claims, err := provider.VerifyIDToken(c, rawIDToken, authRequest.Nonce)
+	if err != nil {
+		logutil.GetLogger().Warnf("verify id token error, err=%s, provider=%s", err, *reqUri.Provider)
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeOidcAuthFailedError, "failed to sign in with the provider"))
+		return
+	}
+
+	arg1 := db.GetUserIdentityParams{
+		Provider: *reqUri.Provider,
+		Subject:  claims.Subject,
+	}
+	identity, err := s.store.GetUserIdentity(c, arg1)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logutil.GetLogger().Errorf("get user identity error, err=%s, arg=%#v", err, arg1)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+
+		// 尚未綁定，需以手機驗證碼證明為既有使用者後綁定
+		linkToken, err := oidcutil.GenState()
+		if err != nil {
+			logutil.GetLogger().Errorf("gen link token error, err=%s", err)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+
+		arg2 := db.SetOidcAuthRequestVerifiedParams{
+			State:         authRequest.State,
+			Subject:       sql.NullString{Valid: true, String: claims.Subject},
+			LinkToken:     sql.NullString{Valid: true, String: linkToken},
+			LinkExpiredAt: sql.NullInt64{Valid: true, Int64: time.Now().Add(s.config.Oidc.LinkLiveTime).UnixMilli()},
+		}
+		if err := s.store.SetOidcAuthRequestVerified(c, arg2); err != nil {
+			logutil.GetLogger().Errorf("set oidc auth request verified error, err=%s, state=%s", err, authRequest.State)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"need_link":  true,
+			"link_token": linkToken,
+		})
+		return
+	}
+
+	arg3 := db.SetOidcAuthRequestVerifiedParams{
+		State:   authRequest.State,
+		Subject: sql.NullString{Valid: true, String: claims.Subject},
+	}
+	if err := s.store.SetOidcAuthRequestVerified(c, arg3); err != nil {
+		logutil.GetLogger().Errorf("set oidc auth request verified error, err=%s, state=%s", err, authRequest.State)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	user, err := s.store.GetUser(c, identity.UserID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get user error, err=%s, user_id=%s", err, identity.UserID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if user.State != fsmutil.UserStateActive {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeAccountLockedError,
+			fmt.Sprintf("account has reached %d incorrect password attempts, please reset password", s.config.MaxPasswordAttempts)))
+		return
+	}
+
+	accessToken, refreshToken, err := s.createUserTokens(c, user.ID)
+	if err != nil {
+		logutil.GetLogger().Errorf("create user tokens error, err=%s, user_id=%s", err, user.ID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"need_link":     false,
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"token_type":    authorizationTypeBearer,
+	})
+}
+
+type sendLinkExternalIdentityMsgRequest struct {
+	PhoneNumber *string `json:"phone_number"`
+}
+
+func (s *Server) sendLinkExternalIdentityMsg(c *gin.Context) {
+	// TODO: rate limit
+	var req sendLinkExternalIdentityMsgRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.PhoneNumber == nil || *req.PhoneNumber == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "phone_number is null or empty"))
+		return
+	}
+
+	if len(*req.PhoneNumber) != 10 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "phone_number is not 10 characters"))
+		return
+	}
+
+	if !strings.HasPrefix(*req.PhoneNumber, "09") {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "phone_number should start from 09"))
+		return
+	}
+
+	m := s.rs.NewMutex(distlockutil.GetUserPhoneNumberMutexName(*req.PhoneNumber))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetUserPhoneNumberMutexName(*req.PhoneNumber))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetUserPhoneNumberMutexName(*req.PhoneNumber))
+		}
+	}()
+
+	if _, err := s.store.GetUserByPhoneNumber(c, *req.PhoneNumber); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codePhoneNumberNotRegisterError,
+				fmt.Sprintf("phone number is not registered as a member yet, phone_number=%s", *req.PhoneNumber)))
+			return
+		}
+		logutil.GetLogger().Errorf("get user by phone number error, err=%s, phone_number=%s", err, *req.PhoneNumber)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	arg1 := db.GetVerCodesByTypeAndPhoneNumberParams{
+		Type:        verCodeTypeLinkExternalIdentity,
+		PhoneNumber: *req.PhoneNumber,
+		FromTs:      time.Now().Add(-s.config.VerCode.LinkExternalIdentity.TimePeriod).UnixMilli(),
+	}
+	codes, err := s.store.GetVerCodesByTypeAndPhoneNumber(c, arg1)
+	if err != nil {
+		logutil.GetLogger().Errorf("get ver code by phone number and type error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if len(codes) >= s.config.VerCode.LinkExternalIdentity.MaxMsgPerTimePeriod {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeSendLinkExternalIdentityMsgMeetLimitError,
+			fmt.Sprintf("account linking SMS limit has been reached, phone_number=%s", *req.PhoneNumber)))
+		return
+	}
+
+	newCode := randomutil.RandomNumString(s.config.VerCode.LinkExternalIdentity.Length)
+
+	// TODO: 呼叫簡訊業者 API 寄出 ver code
+
+	arg2 := db.CreateVerCodeParams{
+		ID:          uuid.New(),
+		PhoneNumber: *req.PhoneNumber,
+		Code:        newCode,
+		Type:        verCodeTypeLinkExternalIdentity,
+		RequestID:   "", // TODO: 簡訊業者 API 回傳的 RequestID
+		ExpiredAt:   time.Now().Add(s.config.VerCode.LinkExternalIdentity.LiveTime).UnixMilli(),
+	}
+
+	if _, err = s.store.CreateVerCode(c, arg2); err != nil {
+		logutil.GetLogger().Errorf("create ver code error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type linkUserExternalIdentityParams struct {
+	LinkToken   *string `json:"link_token"`
+	PhoneNumber *string `json:"phone_number"`
+	VerCode     *string `json:"ver_code"`
+}
+
+func (s *Server) linkUserExternalIdentity(c *gin.Context) {
+	var req linkUserExternalIdentityParams
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.LinkToken == nil || *req.LinkToken == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "link_token is null or empty"))
+		return
+	}
+
+	if req.PhoneNumber == nil || *req.PhoneNumber == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "phone_number is null or empty"))
+		return
+	}
+
+	if req.VerCode == nil || *req.VerCode == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "ver_code is null or empty"))
+		return
+	}
+
+	m := s.rs.NewMutex(distlockutil.GetUserPhoneNumberMutexName(*req.PhoneNumber))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetUserPhoneNumberMutexName(*req.PhoneNumber))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetUserPhoneNumberMutexName(*req.PhoneNumber))
+		}
+	}()
+
+	// 檢查 link token 是否合法
+	authRequest, err := s.store.GetOidcAuthRequestByLinkToken(c, sql.NullString{Valid: true, String: *req.LinkToken})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidLinkTokenError, "link token is incorrect"))
+			return
+		}
+		logutil.GetLogger().Errorf("get oidc auth request by link token error, err=%s", err)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if authRequest.IsLinked || !authRequest.Subject.Valid || !authRequest.LinkExpiredAt.Valid ||
+		time.Now().After(time.UnixMilli(authRequest.LinkExpiredAt.Int64)) {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidLinkTokenError, "link token is incorrect"))
+		return
+	}
+
+	user, err := s.store.GetUserByPhoneNumber(c, *req.PhoneNumber)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codePhoneNumberNotRegisterError,
+				fmt.Sprintf("phone number is not registered as a member yet, phone_number=%s", *req.PhoneNumber)))
+			return
+		}
+		logutil.GetLogger().Errorf("get user by phone number error, err=%s, phone_number=%s", err, *req.PhoneNumber)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	// 檢查 ver code 是否合法
+	arg1 := db.GetVerCodesByTypeAndPhoneNumberAndCodeParams{
+		Type:        verCodeTypeLinkExternalIdentity,
+		PhoneNumber: *req.PhoneNumber,
+		Code:        *req.VerCode,
+	}
+
+	codes, err := s.store.GetVerCodesByTypeAndPhoneNumberAndCode(c, arg1)
+	if err != nil {
+		logutil.GetLogger().Errorf("get ver codes by type, phone number and code error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	var validCode *db.VerCode
+	for _, code := range codes {
+		if isVerCodeValid(code) {
+			validCode = &code
+			break
+		}
+	}
+
+	if validCode == nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeWrongVerCodeError, "verification code is incorrect"))
+		return
+	}
+
+	// 每個使用者在同一個 provider 只能綁定一個外部身分
+	arg2 := db.GetUserIdentityByUserIDParams{
+		UserID:   user.ID,
+		Provider: authRequest.Provider,
+	}
+	if _, err := s.store.GetUserIdentityByUserID(c, arg2); err != nil {
+		if err != sql.ErrNoRows {
+			logutil.GetLogger().Errorf("get user identity by user id error, err=%s, arg=%#v", err, arg2)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+	} else {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeExternalIdentityLinkedError,
+			fmt.Sprintf("user has already linked an identity of this provider, provider=%s", authRequest.Provider)))
+		return
+	}
+
+	// block 合法的 ver code
+	if err := s.store.BlockVerCodes(c, validCode.ID); err != nil {
+		logutil.GetLogger().Errorf("block ver code error, err=%s, id=%s", err, validCode.ID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	arg3 := db.LinkUserIdentityWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangeType:       userChangedTypeLinkExternalIdentity,
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: user.ID},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		UserID:           user.ID,
+		Provider:         authRequest.Provider,
+		Subject:          authRequest.Subject.String,
+		State:            authRequest.State,
+	}
+	if _, err := s.store.LinkUserIdentityWithLog(c, arg3); err != nil {
+		logutil.GetLogger().Errorf("link user identity with log error, err=%s, arg=%#v", err, arg3)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if user.State != fsmutil.UserStateActive {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeAccountLockedError,
+			fmt.Sprintf("account has reached %d incorrect password attempts, please reset password", s.config.MaxPasswordAttempts)))
+		return
+	}
+
+	accessToken, refreshToken, err := s.createUserTokens(c, user.ID)
+	if err != nil {
+		logutil.GetLogger().Errorf("create user tokens error, err=%s, user_id=%s", err, user.ID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"token_type":    authorizationTypeBearer,
+	})
+}
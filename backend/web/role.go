@@ -0,0 +1,457 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	"backend/token"
+	distlockutil "backend/util/distlock"
+	logutil "backend/util/log"
+	roleutil "backend/util/role"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	roleChangedTypeCreate     string = "create"
+	roleChangedTypeUpdateInfo string = "update_info"
+	roleChangedTypeDelete     string = "delete"
+)
+
+// manageableRoleName 自訂 role 在 enable/deactive/change-to 的權限檢查上視同 mgr
+func manageableRoleName(roleID int16) string {
+	if roleutil.IsBuiltInRoleID(roleID) {
+		return roleutil.GetRoleByID(roleID).Name
+	}
+	return roleutil.RoleMgr
+}
+
+// checkRoleScopes 檢查 scopes 是否合法，且不可超過操作者 role 的 scopes
+func (s *Server) checkRoleScopes(c *gin.Context, userScopes, storeUserScopes []string) (string, bool, error) {
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := s.store.GetUser(c, authPayload.Subject)
+	if err != nil {
+		return "", false, err
+	}
+	role, _, err := s.roles.get(c, user.RoleID)
+	if err != nil {
+		return "", false, err
+	}
+
+	for _, scope := range userScopes {
+		if !contains(roleutil.UserScopes, scope) || !contains(role.UserScopes, scope) {
+			return fmt.Sprintf("user scope is not allowed, scope=%s", scope), false, nil
+		}
+	}
+	for _, scope := range storeUserScopes {
+		if !contains(roleutil.StoreUserScopes, scope) || !contains(role.StoreUserScopes, scope) {
+			return fmt.Sprintf("store user scope is not allowed, scope=%s", scope), false, nil
+		}
+	}
+	return "", true, nil
+}
+
+func newRoleResponse(role db.Role) gin.H {
+	var storeID *string
+	if role.StoreID.Valid {
+		id := role.StoreID.UUID.String()
+		storeID = &id
+	}
+	return gin.H{
+		"id":                role.ID,
+		"name":              role.Name,
+		"store_id":          storeID,
+		"user_scopes":       role.UserScopes,
+		"store_user_scopes": role.StoreUserScopes,
+		"is_built_in":       role.IsBuiltIn,
+		"created_at":        role.CreatedAt,
+	}
+}
+
+type getRolesQuery struct {
+	StoreID *string `form:"store_id"`
+}
+
+func (s *Server) getRoles(c *gin.Context) {
+	var req getRolesQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	var roles []db.Role
+	if req.StoreID != nil && *req.StoreID != "" {
+		storeID, err := uuid.Parse(*req.StoreID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store not found, store_id=%s", *req.StoreID)))
+			return
+		}
+		// 回傳全域 role 與該 store 的 role
+		roles, err = s.store.GetRolesByStoreID(c, uuid.NullUUID{Valid: true, UUID: storeID})
+		if err != nil {
+			logutil.GetLogger().Errorf("get roles by store id error, err=%s, store_id=%s", err, storeID)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+	} else {
+		var err error
+		roles, err = s.store.GetRoles(c)
+		if err != nil {
+			logutil.GetLogger().Errorf("get roles error, err=%s", err)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+	}
+
+	res := make([]gin.H, 0, len(roles))
+	for _, role := range roles {
+		res = append(res, newRoleResponse(role))
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": res})
+}
+
+type createRoleRequest struct {
+	Name            *string  `json:"name"`
+	StoreID         *string  `json:"store_id"`
+	UserScopes      []string `json:"user_scopes"`
+	StoreUserScopes []string `json:"store_user_scopes"`
+}
+
+func (s *Server) createRole(c *gin.Context) {
+	var req createRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.Name == nil || *req.Name == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "name is null or empty"))
+		return
+	}
+
+	if roleutil.GetRoleByName(*req.Name).ID != 0 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "name is a built-in role name"))
+		return
+	}
+
+	if req.UserScopes == nil {
+		req.UserScopes = []string{}
+	}
+	if req.StoreUserScopes == nil {
+		req.StoreUserScopes = []string{}
+	}
+
+	storeID := uuid.NullUUID{}
+	if req.StoreID != nil && *req.StoreID != "" {
+		id, err := uuid.Parse(*req.StoreID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store not found, store_id=%s", *req.StoreID)))
+			return
+		}
+
+		if _, err := s.store.GetStore(c, id); err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store not found, store_id=%s", *req.StoreID)))
+				return
+			}
+			logutil.GetLogger().Errorf("get store error, err=%s, store_id=%s", err, id)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+
+		// 只能為所屬 store group 的 store 建立 role
+		accessible, err := s.isStoreAccessible(c, id)
+		if err != nil {
+			logutil.GetLogger().Errorf("check store accessible error, err=%s, store_id=%s", err, id)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		if !accessible {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store not found, store_id=%s", *req.StoreID)))
+			return
+		}
+
+		// 限定 store 的 role 只能有 store user scopes
+		if len(req.UserScopes) > 0 {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "user_scopes should be empty for a store role"))
+			return
+		}
+		storeID = uuid.NullUUID{Valid: true, UUID: id}
+	} else if !contains(c.MustGet(authorizationScopesKey).(roleutil.Scopes), roleutil.ScopeStoreAllAccess) {
+		// 全域 role 會套用到所有 store，只有可存取所有 store 的使用者能建立
+		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, "store_id is required without store all access"))
+		return
+	}
+
+	message, ok, err := s.checkRoleScopes(c, req.UserScopes, req.StoreUserScopes)
+	if err != nil {
+		logutil.GetLogger().Errorf("check role scopes error, err=%s", err)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, message))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.CreateRoleWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangeType:       roleChangedTypeCreate,
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		Name:             *req.Name,
+		StoreID:          storeID,
+		UserScopes:       req.UserScopes,
+		StoreUserScopes:  req.StoreUserScopes,
+	}
+	role, err := s.store.CreateRoleWithLog(c, arg)
+	if err != nil {
+		logutil.GetLogger().Errorf("create role with log error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, newRoleResponse(role))
+}
+
+type updateRoleUri struct {
+	RoleID *string `uri:"role_id"`
+}
+
+type updateRoleRequest struct {
+	Name            *string  `json:"name"`
+	UserScopes      []string `json:"user_scopes"`
+	StoreUserScopes []string `json:"store_user_scopes"`
+}
+
+func (s *Server) updateRole(c *gin.Context) {
+	var reqUri updateRoleUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	var req updateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.RoleID == nil || *reqUri.RoleID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "role_id is null or empty"))
+		return
+	}
+
+	if req.Name == nil || *req.Name == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "name is null or empty"))
+		return
+	}
+
+	if roleutil.GetRoleByName(*req.Name).ID != 0 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "name is a built-in role name"))
+		return
+	}
+
+	if req.UserScopes == nil {
+		req.UserScopes = []string{}
+	}
+	if req.StoreUserScopes == nil {
+		req.StoreUserScopes = []string{}
+	}
+
+	roleID, err := strconv.ParseInt(*reqUri.RoleID, 10, 16)
+	if err != nil || roleutil.IsBuiltInRoleID(int16(roleID)) {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeRoleNotFoundError, fmt.Sprintf("role not found, role_id=%s", *reqUri.RoleID)))
+		return
+	}
+
+	m := s.rs.NewMutex(distlockutil.GetRoleIDMutexName(*reqUri.RoleID))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetRoleIDMutexName(*reqUri.RoleID))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetRoleIDMutexName(*reqUri.RoleID))
+		}
+	}()
+
+	role, err := s.store.GetRole(c, int16(roleID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeRoleNotFoundError, fmt.Sprintf("role not found, role_id=%s", *reqUri.RoleID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get role error, err=%s, role_id=%d", err, roleID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if role.IsBuiltIn {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeRoleNotFoundError, fmt.Sprintf("role not found, role_id=%s", *reqUri.RoleID)))
+		return
+	}
+
+	if role.StoreID.Valid {
+		accessible, err := s.isStoreAccessible(c, role.StoreID.UUID)
+		if err != nil {
+			logutil.GetLogger().Errorf("check store accessible error, err=%s, store_id=%s", err, role.StoreID.UUID)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		if !accessible {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeRoleNotFoundError, fmt.Sprintf("role not found, role_id=%s", *reqUri.RoleID)))
+			return
+		}
+	} else if !contains(c.MustGet(authorizationScopesKey).(roleutil.Scopes), roleutil.ScopeStoreAllAccess) {
+		// 全域 role 會套用到所有 store，只有可存取所有 store 的使用者能修改
+		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, messageForbiddenError))
+		return
+	}
+
+	if role.StoreID.Valid && len(req.UserScopes) > 0 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "user_scopes should be empty for a store role"))
+		return
+	}
+
+	message, ok, err := s.checkRoleScopes(c, req.UserScopes, req.StoreUserScopes)
+	if err != nil {
+		logutil.GetLogger().Errorf("check role scopes error, err=%s", err)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, message))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.SetRoleNameAndScopesWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangeType:       roleChangedTypeUpdateInfo,
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		ID:               role.ID,
+		Name:             *req.Name,
+		UserScopes:       req.UserScopes,
+		StoreUserScopes:  req.StoreUserScopes,
+	}
+	if err := s.store.SetRoleNameAndScopesWithLog(c, arg); err != nil {
+		logutil.GetLogger().Errorf("set role name and scopes with log error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	s.roles.invalidate(c, role.ID)
+
+	c.Status(http.StatusNoContent)
+}
+
+type deleteRoleUri struct {
+	RoleID *string `uri:"role_id"`
+}
+
+func (s *Server) deleteRole(c *gin.Context) {
+	var req deleteRoleUri
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.RoleID == nil || *req.RoleID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "role_id is null or empty"))
+		return
+	}
+
+	roleID, err := strconv.ParseInt(*req.RoleID, 10, 16)
+	if err != nil || roleutil.IsBuiltInRoleID(int16(roleID)) {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeRoleNotFoundError, fmt.Sprintf("role not found, role_id=%s", *req.RoleID)))
+		return
+	}
+
+	m := s.rs.NewMutex(distlockutil.GetRoleIDMutexName(*req.RoleID))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetRoleIDMutexName(*req.RoleID))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetRoleIDMutexName(*req.RoleID))
+		}
+	}()
+
+	role, err := s.store.GetRole(c, int16(roleID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeRoleNotFoundError, fmt.Sprintf("role not found, role_id=%s", *req.RoleID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get role error, err=%s, role_id=%d", err, roleID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if role.IsBuiltIn {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeRoleNotFoundError, fmt.Sprintf("role not found, role_id=%s", *req.RoleID)))
+		return
+	}
+
+	if role.StoreID.Valid {
+		accessible, err := s.isStoreAccessible(c, role.StoreID.UUID)
+		if err != nil {
+			logutil.GetLogger().Errorf("check store accessible error, err=%s, store_id=%s", err, role.StoreID.UUID)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		if !accessible {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeRoleNotFoundError, fmt.Sprintf("role not found, role_id=%s", *req.RoleID)))
+			return
+		}
+	} else if !contains(c.MustGet(authorizationScopesKey).(roleutil.Scopes), roleutil.ScopeStoreAllAccess) {
+		// 全域 role 會套用到所有 store，只有可存取所有 store 的使用者能修改
+		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, messageForbiddenError))
+		return
+	}
+
+	// 仍有 store user 使用的 role 不可刪除
+	count, err := s.store.CountStoreUsersByRoleID(c, role.ID)
+	if err != nil {
+		logutil.GetLogger().Errorf("count store users by role id error, err=%s, role_id=%d", err, role.ID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeRoleInUseError, fmt.Sprintf("role is in use, role_id=%d, count=%d", role.ID, count)))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.DeleteRoleWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangeType:       roleChangedTypeDelete,
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		ID:               role.ID,
+	}
+	if err := s.store.DeleteRoleWithLog(c, arg); err != nil {
+		logutil.GetLogger().Errorf("delete role with log error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	s.roles.invalidate(c, role.ID)
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,106 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	logutil "backend/util/log"
+	"context"
+	"database/sql"
+	"strconv"
+	"sync"
+	"time"
+
+	goredislib "github.com/redis/go-redis/v9"
+)
+
+// roleCacheInvalidateChannel 修改或刪除 role 後透過此 channel 通知所有 web 清除快取
+const roleCacheInvalidateChannel = "web:role-cache:invalidate"
+
+type roleCacheEntry struct {
+	role      db.Role
+	found     bool
+	expiredAt time.Time
+}
+
+// roleCache 快取 db 中的 role，避免每個 request 都查詢 roles table。
+// 清除快取會透過 redis pub/sub 通知其他 web，訂閱中斷期間遺漏的通知最晚在 ttl 後生效
+type roleCache struct {
+	store       db.IStore
+	redisClient *goredislib.Client
+	ttl         time.Duration
+
+	mu      sync.RWMutex
+	entries map[int16]roleCacheEntry
+}
+
+func newRoleCache(store db.IStore, rc *goredislib.Client, ttl time.Duration) *roleCache {
+	return &roleCache{
+		store:       store,
+		redisClient: rc,
+		ttl:         ttl,
+		entries:     make(map[int16]roleCacheEntry),
+	}
+}
+
+// subscribe 接收其他 web 的清除通知，ctx 結束時停止
+func (rc *roleCache) subscribe(ctx context.Context) {
+	pubsub := rc.redisClient.Subscribe(ctx, roleCacheInvalidateChannel)
+	defer pubsub.Close()
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			id, err := strconv.ParseInt(msg.Payload, 10, 16)
+			if err != nil {
+				logutil.GetLogger().Errorf("parse role id error, err=%s, payload=%s", err, msg.Payload)
+				continue
+			}
+			rc.delete(int16(id))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// get 取得 role，role 不存在時回傳 false
+func (rc *roleCache) get(ctx context.Context, id int16) (db.Role, bool, error) {
+	rc.mu.RLock()
+	entry, ok := rc.entries[id]
+	rc.mu.RUnlock()
+	if ok && time.Now().Before(entry.expiredAt) {
+		return entry.role, entry.found, nil
+	}
+
+	role, err := rc.store.GetRole(ctx, id)
+	if err != nil && err != sql.ErrNoRows {
+		return db.Role{}, false, err
+	}
+
+	entry = roleCacheEntry{
+		role:      role,
+		found:     err == nil,
+		expiredAt: time.Now().Add(rc.ttl),
+	}
+	rc.mu.Lock()
+	rc.entries[id] = entry
+	rc.mu.Unlock()
+
+	return entry.role, entry.found, nil
+}
+
+// invalidate 清除本機快取並通知其他 web
+func (rc *roleCache) invalidate(ctx context.Context, id int16) {
+	rc.delete(id)
+	if err := rc.redisClient.Publish(ctx, roleCacheInvalidateChannel, strconv.Itoa(int(id))).Err(); err != nil {
+		logutil.GetLogger().Errorf("publish role cache invalidation error, err=%s, role_id=%d", err, id)
+	}
+}
+
+func (rc *roleCache) delete(id int16) {
+	rc.mu.Lock()
+	delete(rc.entries, id)
+	rc.mu.Unlock()
+}
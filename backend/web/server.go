@@ -6,6 +6,7 @@ import (
 	"backend/token"
 	configutil "backend/util/config"
 	logutil "backend/util/log"
+	oidcutil "backend/util/oidc"
 	roleutil "backend/util/role"
 	"context"
 	"database/sql"
@@ -16,6 +17,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-redsync/redsync/v4"
 	"github.com/google/uuid"
+	goredislib "github.com/redis/go-redis/v9"
 )
 
 type Server struct {
@@ -28,24 +30,58 @@ type Server struct {
 	rs         *redsync.Redsync
 	tokenMaker token.Maker
 	iot        iotsdk.IoT
+	roles      *roleCache
+	stopRoles  context.CancelFunc
+
+	oidcProviders map[string]*oidcutil.Provider
 }
 
-func New(config configutil.Config, store db.IStore, rs *redsync.Redsync, tokenMaker token.Maker, iot iotsdk.IoT) (*Server, error) {
+func New(config configutil.Config, store db.IStore, rs *redsync.Redsync, rc *goredislib.Client, tokenMaker token.Maker, iot iotsdk.IoT) (*Server, error) {
 	server := &Server{
 		config:     config,
 		store:      store,
 		rs:         rs,
 		tokenMaker: tokenMaker,
 		iot:        iot,
+		roles:      newRoleCache(store, rc, config.RoleCacheTTL),
+
+		oidcProviders: make(map[string]*oidcutil.Provider),
+	}
+
+	for name, p := range config.Oidc.Providers {
+		// 未設定 client id 的 provider 視為未啟用
+		if p.ClientID == "" {
+			continue
+		}
+		server.oidcProviders[name] = oidcutil.NewProvider(oidcutil.ProviderConfig{
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+			SigningAlgs:  p.SigningAlgs,
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	server.stopRoles = cancel
+	go server.roles.subscribe(ctx)
+
+	if err := server.setupRouter(); err != nil {
+		return nil, err
 	}
-	server.setupRouter()
 	return server, nil
 }
 
-func (s *Server) setupRouter() {
+func (s *Server) setupRouter() error {
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.Default()
 
+	// 只信任設定的 proxy 所帶的 X-Forwarded-For，未設定時以連線來源為 client ip，避免偽造 ip 繞過 api key 的 ip 限制
+	if err := router.SetTrustedProxies(s.config.Web.TrustedProxies); err != nil {
+		return err
+	}
+
 	router.Use(cors.New(cors.Config{
 		AllowAllOrigins: true,
 		AllowMethods:    []string{"GET", "POST"},
@@ -62,10 +98,14 @@ func (s *Server) setupRouter() {
 	v1Router.POST("/users/login", s.loginUser)
 	v1Router.POST("/users/renew-access-token", s.renewAccessToken)
 	v1Router.POST("/users/.reset-password", s.resetUserPassword)
+	v1Router.GET("/users/oidc/:provider/auth-url", s.getOidcAuthURL)
+	v1Router.POST("/users/oidc/:provider/login", s.loginUserByOidc)
+	v1Router.POST("/users/oidc/send-link-msg", s.sendLinkExternalIdentityMsg)
+	v1Router.POST("/users/oidc/.link", s.linkUserExternalIdentity)
 
 	v1UserAuthRoutes := v1Router.Group("/").Use(
-		authMiddleware(s.tokenMaker, s.checkToken),
-		userScopesMiddleware(s.store),
+		authMiddleware(s.tokenMaker, s.checkToken, s.checkApiKey),
+		userScopesMiddleware(s.store, s.roles),
 	)
 
 	v1UserAuthRoutes.GET("/users/info", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeUserDataRead}), s.getUserInfo)
@@ -73,6 +113,8 @@ func (s *Server) setupRouter() {
 	v1UserAuthRoutes.GET("/users/scopes", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeUserDataRead}), s.getUserScopes)
 	v1UserAuthRoutes.POST("/users/update-self-info", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeUserDataWrite}), s.updateUserSelfInfo)
 	v1UserAuthRoutes.POST("/users/.change-password", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeUserDataWrite}), s.changeUserPassword)
+	v1UserAuthRoutes.POST("/users/send-change-phone-number-msg", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeUserDataWrite}), s.sendChangePhoneNumberMsg)
+	v1UserAuthRoutes.POST("/users/.change-phone-number", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeUserDataWrite}), s.changeUserPhoneNumber)
 
 	v1UserAuthRoutes.POST("/stores/.create", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreCreate}), s.createStore)
 	v1UserAuthRoutes.GET("/stores", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreRead}), s.getStores)
@@ -81,19 +123,51 @@ func (s *Server) setupRouter() {
 	v1UserAuthRoutes.POST("/stores/:store_id/.deactive", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeactive}), s.deactiveStore)
 	v1UserAuthRoutes.POST("/stores/:store_id/update-info", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreWrite}), s.updateStoreInfo)
 	v1UserAuthRoutes.POST("/stores/:store_id/gen-password", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStorePasswordWrite}), s.genStorePassword)
+	v1UserAuthRoutes.GET("/stores/:store_id/edge", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreEdgeRead}), s.getStoreEdge)
+	v1UserAuthRoutes.POST("/stores/:store_id/devices/:device_id/.transfer", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceTransfer}), s.transferStoreDevice)
+
+	v1UserAuthRoutes.GET("/store-groups", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreGroupRead}), s.getStoreGroups)
+	v1UserAuthRoutes.POST("/store-groups/.create", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreGroupWrite}), s.createStoreGroup)
+	v1UserAuthRoutes.GET("/store-groups/:store_group_id", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreGroupRead}), s.getStoreGroup)
+	v1UserAuthRoutes.POST("/store-groups/:store_group_id/stores/.add", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreGroupWrite}), s.addStoreToStoreGroup)
+	v1UserAuthRoutes.POST("/store-groups/:store_group_id/stores/.remove", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreGroupWrite}), s.removeStoreFromStoreGroup)
+	v1UserAuthRoutes.POST("/store-groups/:store_group_id/users/.add", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreGroupWrite}), s.addUserToStoreGroup)
+	v1UserAuthRoutes.POST("/store-groups/:store_group_id/users/.remove", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreGroupWrite}), s.removeUserFromStoreGroup)
+	v1UserAuthRoutes.GET("/store-groups/:store_group_id/reports/brand-wallet-settlement", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreReportRead}), s.getBrandWalletSettlementReport)
+
+	v1UserAuthRoutes.GET("/audit/users", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeAuditUserRead}), s.getUsersAudit)
+	v1UserAuthRoutes.GET("/audit/stores", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeAuditStoreRead}), s.getStoresAudit)
+	v1UserAuthRoutes.GET("/audit/store-users", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeAuditStoreRead}), s.getStoreUsersAudit)
+	v1UserAuthRoutes.GET("/audit/store-devices", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeAuditStoreRead}), s.getStoreDevicesAudit)
+
+	v1UserAuthRoutes.GET("/roles", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeRoleRead}), s.getRoles)
+	v1UserAuthRoutes.POST("/roles/.create", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeRoleWrite}), s.createRole)
+	v1UserAuthRoutes.POST("/roles/:role_id/update-info", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeRoleWrite}), s.updateRole)
+	v1UserAuthRoutes.POST("/roles/:role_id/.delete", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeRoleWrite}), s.deleteRole)
+	v1UserAuthRoutes.GET("/device-display-types", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreRead}), s.getDeviceDisplayTypes)
+	v1UserAuthRoutes.POST("/device-display-types/.create", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeDeviceDisplayTypeWrite}), s.createDeviceDisplayType)
+	v1UserAuthRoutes.POST("/device-display-types/:code/update-info", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeDeviceDisplayTypeWrite}), s.updateDeviceDisplayType)
+	v1UserAuthRoutes.POST("/device-display-types/:code/.delete", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeDeviceDisplayTypeWrite}), s.deleteDeviceDisplayType)
 
 	v1UserAuthRoutes.POST("/stores/:store_id/users/.register", checkScopesMiddleware(
 		roleutil.Scopes{roleutil.ScopeStoreUserAdminRegister},
 		roleutil.Scopes{roleutil.ScopeStoreUserHqRegister},
-		roleutil.Scopes{roleutil.ScopeStoreUserCustRegister},
 	), s.registerStoreUser)
+	v1UserAuthRoutes.POST("/stores/.join", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserCustRegister}), s.joinStoreByCode)
+	v1UserAuthRoutes.GET("/users/store-invitations", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeUserDataRead}), s.getUserStoreInvitations)
+	v1UserAuthRoutes.POST("/users/store-invitations/:store_id/.accept", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeUserDataWrite}), s.acceptStoreInvitation)
+	v1UserAuthRoutes.POST("/users/store-invitations/:store_id/.decline", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeUserDataWrite}), s.declineStoreInvitation)
 
 	v1StoreUserAuthRoutes := v1Router.Group("/").Use(
-		authMiddleware(s.tokenMaker, s.checkToken),
-		storeUserScopesMiddleware(s.store),
+		authMiddleware(s.tokenMaker, s.checkToken, s.checkApiKey),
+		storeUserScopesMiddleware(s.store, s.roles),
 	)
 
 	v1StoreUserAuthRoutes.GET("/stores/:store_id/users/scopes", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserDataRead}), s.getStoreUserScopes)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/users/.invite", checkScopesMiddleware(
+		roleutil.Scopes{roleutil.ScopeStoreUserOwnerEnable},
+		roleutil.Scopes{roleutil.ScopeStoreUserMgrEnable},
+	), s.inviteStoreUser)
 	v1StoreUserAuthRoutes.POST("/stores/:store_id/users/:user_id/.enable", checkScopesMiddleware(
 		roleutil.Scopes{roleutil.ScopeStoreUserOwnerEnable},
 		roleutil.Scopes{roleutil.ScopeStoreUserMgrEnable},
@@ -117,6 +191,9 @@ func (s *Server) setupRouter() {
 		roleutil.Scopes{roleutil.ScopeStoreUserCustEnable, roleutil.ScopeStoreUserOwnerDeactive},
 		roleutil.Scopes{roleutil.ScopeStoreUserCustEnable, roleutil.ScopeStoreUserMgrDeactive},
 	), s.changeStoreUserToCust)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/users/:user_id/change-role", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserRoleWrite}), s.changeStoreUserRole)
+	v1StoreUserAuthRoutes.GET("/stores/:store_id/users/:user_id/scope-overrides", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserRead}), s.getStoreUserScopeOverride)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/users/:user_id/update-scope-overrides", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserScopeWrite}), s.updateStoreUserScopeOverride)
 	v1StoreUserAuthRoutes.GET("/stores/:store_id/users/:user_id/balance", checkScopesMiddleware(
 		roleutil.Scopes{roleutil.ScopeStoreUserRecordsReadSelf},
 		roleutil.Scopes{roleutil.ScopeStoreUserRecordsReadOthers},
@@ -125,10 +202,43 @@ func (s *Server) setupRouter() {
 		roleutil.Scopes{roleutil.ScopeStoreUserRecordsReadSelf},
 		roleutil.Scopes{roleutil.ScopeStoreUserRecordsReadOthers},
 	), s.getStoreUserRecords)
+	v1StoreUserAuthRoutes.GET("/stores/:store_id/users/:user_id/wallet-at", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserWalletHistoryRead}), s.getStoreUserWalletAt)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/users/:user_id/adjust-balance", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserBalanceAdjust}), s.adjustStoreUserBalance)
+	v1StoreUserAuthRoutes.GET("/stores/:store_id/users/:user_id/credit-limit", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserRead}), s.getStoreUserCreditLimit)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/users/:user_id/update-credit-limit", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreCreditLimitWrite}), s.updateStoreUserCreditLimit)
 	v1StoreUserAuthRoutes.GET("/stores/:store_id/users", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserRead}), s.getStoreUsers)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/transfer-balance", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserBalanceTransfer}), s.transferStoreUserBalance)
+	v1StoreUserAuthRoutes.GET("/stores/:store_id/family-wallet", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserFamilyWallet}), s.getFamilyWallet)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/family-wallet/.create", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserFamilyWallet}), s.createFamilyWallet)
+	v1StoreUserAuthRoutes.GET("/stores/:store_id/family-wallet/spendings", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserFamilyWallet}), s.getFamilyWalletSpendings)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/family-wallet/.accept", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserFamilyWallet}), s.acceptFamilyWallet)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/family-wallet/members/.add", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserFamilyWallet}), s.addFamilyWalletMember)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/family-wallet/members/:user_id/update-spending-cap", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserFamilyWallet}), s.updateFamilyWalletMemberSpendingCap)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/family-wallet/members/:user_id/.remove", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserFamilyWallet}), s.removeFamilyWalletMember)
+	v1StoreUserAuthRoutes.GET("/stores/:store_id/credit-limit", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserRead}), s.getStoreCreditLimit)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/update-credit-limit", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreCreditLimitWrite}), s.updateStoreCreditLimit)
+	v1StoreUserAuthRoutes.GET("/stores/:store_id/reports/debts", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDebtReportRead}), s.getStoreDebtReport)
+	v1StoreUserAuthRoutes.GET("/stores/:store_id/reports/device-uptime", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceRead}), s.getStoreDeviceUptimeReport)
+	v1StoreUserAuthRoutes.GET("/stores/:store_id/brand-wallet", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserDataRead}), s.getStoreBrandWallet)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/brand-wallet/.join", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreBrandWalletWrite}), s.joinBrandWallet)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/brand-wallet/.leave", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreBrandWalletWrite}), s.leaveBrandWallet)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/users/:user_id/brand-wallet/cash-top-up", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserCustCashTopUp}), s.assistCustBrandWalletCashTopUp)
 
 	v1StoreUserAuthRoutes.GET("/stores/:store_id/devices", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceRead}), s.getStoreDevices)
 	v1StoreUserAuthRoutes.GET("/stores/:store_id/devices/:device_id/records", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceRecordsRead}), s.getStoreDeviceRecords)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/devices/:device_id/.approve", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceStateWrite}), s.approveStoreDevice)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/devices/:device_id/.start-maintenance", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceWrite}), s.startStoreDeviceMaintenance)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/devices/:device_id/.finish-maintenance", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceWrite}), s.finishStoreDeviceMaintenance)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/devices/:device_id/.disable", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceStateWrite}), s.disableStoreDevice)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/devices/:device_id/.enable", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceStateWrite}), s.enableStoreDevice)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/devices/:device_id/.decommission", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceStateWrite}), s.decommissionStoreDevice)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/devices/:device_id/.replace", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceStateWrite}), s.replaceStoreDevice)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/devices/:device_id/update-layout", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceWrite}), s.updateStoreDeviceLayout)
+	v1StoreUserAuthRoutes.GET("/stores/:store_id/device-zones", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceRead}), s.getStoreDeviceZones)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/device-zones/.create", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceWrite}), s.createStoreDeviceZone)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/device-zones/:zone_id/update", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceWrite}), s.updateStoreDeviceZone)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/device-zones/:zone_id/.delete", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceWrite}), s.deleteStoreDeviceZone)
+	v1StoreUserAuthRoutes.GET("/stores/:store_id/device-misplacements", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceRead}), s.getStoreDeviceMisplacements)
 	v1StoreUserAuthRoutes.GET("/stores/:store_id/coin-acceptors/:device_id/info", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceRead}), s.getStoreCoinAcceptorInfo)
 	v1StoreUserAuthRoutes.GET("/stores/:store_id/coin-acceptors/:device_id/status", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceRead}), s.getStoreCoinAcceptorStatus)
 	v1StoreUserAuthRoutes.POST("/stores/:store_id/coin-acceptors/:device_id/blink", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreDeviceBlink}), s.blinkStoreCoinAcceptor)
@@ -138,7 +248,27 @@ func (s *Server) setupRouter() {
 		roleutil.Scopes{roleutil.ScopeStoreDeviceInsertCoinsWithNegativeBalance},
 	), s.insertCoinsToStoreCoinAcceptor)
 
+	v1StoreUserAuthRoutes.GET("/stores/:store_id/api-keys", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreApiKeyRead}), s.getStoreApiKeys)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/api-keys/.create", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreApiKeyWrite}), s.createStoreApiKey)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/api-keys/:api_key_id/.revoke", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreApiKeyWrite}), s.revokeStoreApiKey)
+
+	v1StoreUserAuthRoutes.GET("/stores/:store_id/join-codes", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreJoinCodeRead}), s.getStoreJoinCodes)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/join-codes/.create", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreJoinCodeWrite}), s.createStoreJoinCode)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/join-codes/:join_code_id/.revoke", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreJoinCodeWrite}), s.revokeStoreJoinCode)
+
+	v1StoreUserAuthRoutes.GET("/stores/:store_id/balance-adjustments", checkScopesMiddleware(
+		roleutil.Scopes{roleutil.ScopeStoreUserBalanceAdjust},
+		roleutil.Scopes{roleutil.ScopeStoreUserBalanceAdjustApprove},
+	), s.getStoreBalanceAdjustments)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/balance-adjustments/:balance_adjustment_id/.approve", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserBalanceAdjustApprove}), s.approveBalanceAdjustment)
+	v1StoreUserAuthRoutes.POST("/stores/:store_id/balance-adjustments/:balance_adjustment_id/.reject", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreUserBalanceAdjustApprove}), s.rejectBalanceAdjustment)
+
+	v1StoreUserAuthRoutes.GET("/stores/:store_id/audit/store", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreAuditRead}), s.getStoreAuditStore)
+	v1StoreUserAuthRoutes.GET("/stores/:store_id/audit/users", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreAuditRead}), s.getStoreAuditUsers)
+	v1StoreUserAuthRoutes.GET("/stores/:store_id/audit/devices", checkScopesMiddleware(roleutil.Scopes{roleutil.ScopeStoreAuditRead}), s.getStoreAuditDevices)
+
 	s.router = router
+	return nil
 }
 
 func (s *Server) Start(address string) error {
@@ -153,6 +283,8 @@ func (s *Server) Start(address string) error {
 }
 
 func (s *Server) Shutdown() error {
+	s.stopRoles()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	return s.srv.Shutdown(ctx)
@@ -180,5 +312,42 @@ func (s *Server) checkToken(c context.Context, id uuid.UUID) bool {
 		return false
 	}
 
+	// 撤銷時間之前簽發的 token 一律無效，避免與撤銷同時簽發的 token 漏掉 block
+	user, err := s.store.GetUser(c, dbToken.UserID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logutil.GetLogger().Errorf("get user error, err=%s, user_id=%s", err, dbToken.UserID)
+		}
+		return false
+	}
+	if user.TokensRevokedAt.Valid && dbToken.IssuedAt <= user.TokensRevokedAt.Int64 {
+		return false
+	}
+
 	return true
 }
+
+func (s *Server) checkApiKey(c context.Context, key string, clientIP string) (db.ApiKey, bool) {
+	apiKey, err := s.store.GetApiKeyByKeyHash(c, hashApiKey(key))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.ApiKey{}, false
+		}
+		logutil.GetLogger().Errorf("get api key by key hash error, err=%s", err)
+		return db.ApiKey{}, false
+	}
+
+	if apiKey.IsRevoked {
+		return db.ApiKey{}, false
+	}
+
+	if apiKey.ExpiredAt.Valid && time.Now().After(time.UnixMilli(apiKey.ExpiredAt.Int64)) {
+		return db.ApiKey{}, false
+	}
+
+	if len(apiKey.AllowedIps) > 0 && !isClientIPAllowed(apiKey.AllowedIps, clientIP) {
+		return db.ApiKey{}, false
+	}
+
+	return apiKey, true
+}
@@ -2,12 +2,14 @@ package web
 
 import (
 	db "backend/db/sqlc"
+	iotsdk "backend/iot-sdk"
 	"backend/token"
 	distlockutil "backend/util/distlock"
 	fsmutil "backend/util/fsm"
 	logutil "backend/util/log"
 	passwordutil "backend/util/password"
 	randomutil "backend/util/random"
+	roleutil "backend/util/role"
 	"database/sql"
 	"fmt"
 	"net/http"
@@ -24,11 +26,13 @@ const (
 	storeChangedTypeDeactive    string = "deactive"
 	storeChangedTypeUpdateInfo  string = "update_info"
 	storeChangedTypeGenPassword string = "gen_password"
+	storeChangedTypeCreditLimit string = "update_credit_limit"
 )
 
 type createStoreRequest struct {
-	Name    *string `json:"name"`
-	Address *string `json:"address"`
+	Name         *string `json:"name"`
+	Address      *string `json:"address"`
+	StoreGroupID *string `json:"store_group_id"`
 }
 
 func (s *Server) createStore(c *gin.Context) {
@@ -71,6 +75,37 @@ func (s *Server) createStore(c *gin.Context) {
 	}
 
 	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	scopes := c.MustGet(authorizationScopesKey).(roleutil.Scopes)
+
+	// 沒有 ScopeStoreAllAccess 的使用者須將新 store 加入自己所屬的 store group，否則建立後無法存取
+	storeGroupID := uuid.NullUUID{}
+	if req.StoreGroupID != nil && *req.StoreGroupID != "" {
+		storeGroup, ok := s.bindStoreGroup(c, req.StoreGroupID)
+		if !ok {
+			return
+		}
+
+		if !contains(scopes, roleutil.ScopeStoreAllAccess) {
+			arg := db.IsUserInStoreGroupParams{
+				StoreGroupID: storeGroup.ID,
+				UserID:       authPayload.Subject,
+			}
+			inGroup, err := s.store.IsUserInStoreGroup(c, arg)
+			if err != nil {
+				logutil.GetLogger().Errorf("is user in store group error, err=%s, arg=%#v", err, arg)
+				c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+				return
+			}
+			if !inGroup {
+				c.JSON(http.StatusNotFound, newErrorResponse(codeStoreGroupNotFoundError, fmt.Sprintf("store group is not, store_group_id=%s", *req.StoreGroupID)))
+				return
+			}
+		}
+		storeGroupID = uuid.NullUUID{Valid: true, UUID: storeGroup.ID}
+	} else if !contains(scopes, roleutil.ScopeStoreAllAccess) {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_group_id is null or empty"))
+		return
+	}
 
 	arg := db.CreateStoreWithLogParams{
 		ChangedAt:        time.Now().UnixMilli(),
@@ -82,6 +117,7 @@ func (s *Server) createStore(c *gin.Context) {
 		Name:             *req.Name,
 		Address:          *req.Address,
 		State:            fsmutil.InitStoreState,
+		StoreGroupID:     storeGroupID,
 	}
 
 	store, err := s.store.CreateStoreWithLog(c, arg)
@@ -100,25 +136,67 @@ func (s *Server) createStore(c *gin.Context) {
 }
 
 func (s *Server) getStores(c *gin.Context) {
-	stores, err := s.store.GetStores(c)
+	scopes := c.MustGet(authorizationScopesKey).(roleutil.Scopes)
+
+	var stores []db.Store
+	var err error
+	if !isStoreGroupLimited(scopes) {
+		stores, err = s.store.GetStores(c)
+		if err != nil {
+			logutil.GetLogger().Errorf("get stores error, err=%s", err)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+	} else {
+		authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+		stores, err = s.store.GetStoresByStoreGroupUserID(c, authPayload.Subject)
+		if err != nil {
+			logutil.GetLogger().Errorf("get stores by store group user id error, err=%s, user_id=%s", err, authPayload.Subject)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+	}
+
+	storeIDs := make([]uuid.UUID, 0, len(stores))
+	for _, store := range stores {
+		storeIDs = append(storeIDs, store.ID)
+	}
+	// 無法讀取連線狀態時視為未連線，不影響商店列表
+	conns, err := s.iot.GetStoreConnections(c, storeIDs)
 	if err != nil {
-		logutil.GetLogger().Errorf("get stores error, err=%s", err)
-		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
-		return
+		logutil.GetLogger().Errorf("get store connections error, err=%s", err)
+		conns = map[uuid.UUID]iotsdk.StoreConnection{}
 	}
 
 	res := make([]gin.H, 0, len(stores))
 	for _, store := range stores {
+		conn, online := conns[store.ID]
 		res = append(res, gin.H{
-			"id":      store.ID.String(),
-			"name":    store.Name,
-			"address": store.Address,
-			"state":   store.State,
+			"id":         store.ID.String(),
+			"name":       store.Name,
+			"address":    store.Address,
+			"state":      store.State,
+			"online":     online,
+			"connection": storeConnectionJSON(c, conn, online),
 		})
 	}
 	c.JSON(http.StatusOK, gin.H{"stores": res})
 }
 
+// storeConnectionJSON edge 未連線或沒有 ScopeStoreEdgeRead 時回傳 nil，避免 edge 的 ip 與版本外流
+func storeConnectionJSON(c *gin.Context, conn iotsdk.StoreConnection, online bool) gin.H {
+	scopes := c.MustGet(authorizationScopesKey).(roleutil.Scopes)
+	if !online || !contains(scopes, roleutil.ScopeStoreEdgeRead) {
+		return nil
+	}
+	return gin.H{
+		"connected_at": conn.ConnectedAt,
+		"edge_version": conn.EdgeVersion,
+		"client_ip":    conn.ClientIp,
+	}
+}
+
 type getStoreUri struct {
 	StoreID *string `uri:"store_id"`
 }
@@ -141,6 +219,17 @@ func (s *Server) getStore(c *gin.Context) {
 		return
 	}
 
+	accessible, err := s.isStoreAccessible(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("check store accessible error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if !accessible {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *req.StoreID)))
+		return
+	}
+
 	store, err := s.store.GetStore(c, storeID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -152,11 +241,20 @@ func (s *Server) getStore(c *gin.Context) {
 		return
 	}
 
+	conns, err := s.iot.GetStoreConnections(c, []uuid.UUID{storeID})
+	if err != nil {
+		logutil.GetLogger().Errorf("get store connections error, err=%s, store_id=%s", err, storeID)
+		conns = map[uuid.UUID]iotsdk.StoreConnection{}
+	}
+	conn, online := conns[storeID]
+
 	c.JSON(http.StatusOK, gin.H{
-		"id":      store.ID.String(),
-		"name":    store.Name,
-		"address": store.Address,
-		"state":   store.State,
+		"id":         store.ID.String(),
+		"name":       store.Name,
+		"address":    store.Address,
+		"state":      store.State,
+		"online":     online,
+		"connection": storeConnectionJSON(c, conn, online),
 	})
 }
 
@@ -182,6 +280,17 @@ func (s *Server) enableStore(c *gin.Context) {
 		return
 	}
 
+	accessible, err := s.isStoreAccessible(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("check store accessible error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if !accessible {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *req.StoreID)))
+		return
+	}
+
 	m := s.rs.NewMutex(distlockutil.GetStoreIDMutexName(*req.StoreID))
 	if err := m.Lock(); err != nil {
 		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreIDMutexName(*req.StoreID))
@@ -260,6 +369,17 @@ func (s *Server) deactiveStore(c *gin.Context) {
 		return
 	}
 
+	accessible, err := s.isStoreAccessible(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("check store accessible error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if !accessible {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *req.StoreID)))
+		return
+	}
+
 	m := s.rs.NewMutex(distlockutil.GetStoreIDMutexName(*req.StoreID))
 	if err := m.Lock(); err != nil {
 		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreIDMutexName(*req.StoreID))
@@ -381,6 +501,17 @@ func (s *Server) updateStoreInfo(c *gin.Context) {
 		return
 	}
 
+	accessible, err := s.isStoreAccessible(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("check store accessible error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if !accessible {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
 	store, err := s.store.GetStore(c, storeID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -441,6 +572,17 @@ func (s *Server) genStorePassword(c *gin.Context) {
 		return
 	}
 
+	accessible, err := s.isStoreAccessible(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("check store accessible error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if !accessible {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
 	password := randomutil.RandomAlphaNumString(int(s.config.StorePasswordLength))
 	passwordHashed, err := passwordutil.HashPassword(password)
 	if err != nil {
@@ -5,12 +5,14 @@ import (
 	iotsdk "backend/iot-sdk"
 	"backend/token"
 	distlockutil "backend/util/distlock"
+	fsmutil "backend/util/fsm"
 	logutil "backend/util/log"
 	roleutil "backend/util/role"
 	"context"
 	"database/sql"
 	"fmt"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -21,6 +23,12 @@ type getStoreDevicesUri struct {
 	StoreID *string `uri:"store_id"`
 }
 
+type getStoreDevicesQuery struct {
+	State         *string `form:"state"`
+	IncludeStatus *bool   `form:"include_status"`
+	MaxAge        *int64  `form:"max_age"`
+}
+
 func (s *Server) getStoreDevices(c *gin.Context) {
 	var req getStoreDevicesUri
 	if err := c.ShouldBindUri(&req); err != nil {
@@ -39,23 +47,161 @@ func (s *Server) getStoreDevices(c *gin.Context) {
 		return
 	}
 
-	storeDevices, err := s.store.GetStoreDevices(c, storeID)
+	var reqQuery getStoreDevicesQuery
+	if err := c.ShouldBindQuery(&reqQuery); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqQuery.State != nil && !contains(fsmutil.StoreDeviceStates, *reqQuery.State) {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("state is invalid, state=%s", *reqQuery.State)))
+		return
+	}
+
+	if reqQuery.MaxAge != nil && *reqQuery.MaxAge < 0 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "max_age is smaller than 0"))
+		return
+	}
+
+	arg := db.GetStoreDevicesParams{
+		StoreID: storeID,
+	}
+	if reqQuery.State != nil {
+		arg.State = sql.NullString{Valid: true, String: *reqQuery.State}
+	}
+
+	storeDevices, err := s.store.GetStoreDevices(c, arg)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store devices error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	zones, err := s.store.GetStoreDeviceZones(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store device zones error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	// 依區域排序（未分區的排最後），同區域內依機台排序，最後依名稱
+	zoneRanks := make(map[uuid.UUID]int, len(zones))
+	for i, zone := range zones {
+		zoneRanks[zone.ID] = i
+	}
+	zoneRank := func(storeDevice db.StoreDevice) int {
+		if rank, ok := zoneRanks[storeDevice.ZoneID.UUID]; storeDevice.ZoneID.Valid && ok {
+			return rank
+		}
+		return len(zones)
+	}
+	sort.SliceStable(storeDevices, func(i, j int) bool {
+		ri, rj := zoneRank(storeDevices[i]), zoneRank(storeDevices[j])
+		if ri != rj {
+			return ri < rj
+		}
+		if storeDevices[i].SortOrder != storeDevices[j].SortOrder {
+			return storeDevices[i].SortOrder < storeDevices[j].SortOrder
+		}
+		return storeDevices[i].Name < storeDevices[j].Name
+	})
+
+	sessions, err := s.store.GetLatestStoreDeviceSessions(c, storeID)
 	if err != nil {
-		logutil.GetLogger().Errorf("get store devices error, err=%s, store_id=%s", err, storeID)
+		logutil.GetLogger().Errorf("get latest store device sessions error, err=%s, store_id=%s", err, storeID)
 		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
 		return
 	}
+	sessionMap := make(map[string]db.StoreDeviceSession, len(sessions))
+	for _, session := range sessions {
+		sessionMap[session.DeviceID] = session
+	}
+	now := time.Now().UnixMilli()
+
+	// 以一次 rpc 取得所有機台的即時狀態，商店未連線或部分機台查詢失敗時仍回傳列表
+	includeStatus := reqQuery.IncludeStatus != nil && *reqQuery.IncludeStatus
+	statuses := map[string]*iotsdk.CoinAcceptorStatus{}
+	if includeStatus {
+		hardwareIDs := make([]string, 0, len(storeDevices))
+		for _, storeDevice := range storeDevices {
+			if storeDevice.State != fsmutil.StoreDeviceStateArchived && storeDevice.State != fsmutil.StoreDeviceStateDecommissioned {
+				hardwareIDs = append(hardwareIDs, storeDevice.HardwareID)
+			}
+		}
+		if len(hardwareIDs) > 0 {
+			ctx, cancel := context.WithTimeout(c, 3*time.Second)
+			defer cancel()
+
+			if reqQuery.MaxAge != nil {
+				statuses, err = s.iot.GetCachedCoinAcceptorStatuses(ctx, storeID, hardwareIDs, time.Duration(*reqQuery.MaxAge)*time.Millisecond)
+			} else {
+				statuses, err = s.iot.GetCoinAcceptorStatuses(ctx, storeID, hardwareIDs)
+			}
+			if err != nil {
+				if _, ok := err.(*iotsdk.StoreNotFoundError); !ok {
+					logutil.GetLogger().Errorf("get coin acceptor statuses error, err=%s, store_id=%s", err, storeID)
+				}
+				// 保留已取得的快取狀態
+				if statuses == nil {
+					statuses = map[string]*iotsdk.CoinAcceptorStatus{}
+				}
+			}
+		}
+	}
 
+	zoneDeviceIDs := make(map[uuid.UUID][]string, len(zones))
 	devices := make([]gin.H, 0, len(storeDevices))
 	for _, storeDevice := range storeDevices {
-		devices = append(devices, gin.H{
-			"id":           storeDevice.DeviceID,
-			"name":         storeDevice.Name,
-			"real_type":    storeDevice.RealType,
-			"display_type": storeDevice.DisplayType,
-		})
-	}
-	c.JSON(http.StatusOK, gin.H{"devices": devices})
+		if storeDevice.ZoneID.Valid {
+			zoneDeviceIDs[storeDevice.ZoneID.UUID] = append(zoneDeviceIDs[storeDevice.ZoneID.UUID], storeDevice.DeviceID)
+		}
+		// 最近一次 session 尚未結束且未超過 timeout 即為連線中
+		online := false
+		var lastSeenAt *int64
+		if session, ok := sessionMap[storeDevice.DeviceID]; ok {
+			_, ended := storeDeviceSessionEndedAt(session, now, s.config.StoreDeviceSessionTimeout)
+			online = !ended
+			lastSeenAt = &session.LastSeenAt
+		}
+		device := gin.H{
+			"id":             storeDevice.DeviceID,
+			"name":           storeDevice.Name,
+			"real_type":      storeDevice.RealType,
+			"display_type":   storeDevice.DisplayType,
+			"state":          storeDevice.State,
+			"hardware_id":    storeDevice.HardwareID,
+			"zone_id":        nullUUIDValue(storeDevice.ZoneID),
+			"sort_order":     storeDevice.SortOrder,
+			"machine_number": nullStringValue(storeDevice.MachineNumber),
+			"capacity_kg":    nullFloat64Value(storeDevice.CapacityKg),
+			"online":         online,
+			"last_seen_at":   lastSeenAt,
+		}
+		if includeStatus {
+			device["status"] = nil
+			if status, ok := statuses[storeDevice.HardwareID]; ok {
+				device["status"] = gin.H{
+					"points":     status.Points,
+					"state":      status.State,
+					"ts":         status.Ts,
+					"updated_at": status.UpdatedAt,
+				}
+			}
+		}
+		devices = append(devices, device)
+	}
+
+	zoneResponses := make([]gin.H, 0, len(zones))
+	for _, zone := range zones {
+		deviceIDs := zoneDeviceIDs[zone.ID]
+		if deviceIDs == nil {
+			deviceIDs = []string{}
+		}
+		zoneResponse := newStoreDeviceZoneResponse(zone)
+		zoneResponse["device_ids"] = deviceIDs
+		zoneResponses = append(zoneResponses, zoneResponse)
+	}
+	c.JSON(http.StatusOK, gin.H{"devices": devices, "zones": zoneResponses})
 }
 
 type getStoreCoinAcceptorInfoUri struct {
@@ -103,9 +249,15 @@ func (s *Server) getStoreCoinAcceptorInfo(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"name":         storeDevice.Name,
-		"real_type":    storeDevice.RealType,
-		"display_type": storeDevice.DisplayType,
+		"name":           storeDevice.Name,
+		"real_type":      storeDevice.RealType,
+		"display_type":   storeDevice.DisplayType,
+		"state":          storeDevice.State,
+		"hardware_id":    storeDevice.HardwareID,
+		"zone_id":        nullUUIDValue(storeDevice.ZoneID),
+		"sort_order":     storeDevice.SortOrder,
+		"machine_number": nullStringValue(storeDevice.MachineNumber),
+		"capacity_kg":    nullFloat64Value(storeDevice.CapacityKg),
 	})
 }
 
@@ -158,8 +310,27 @@ func (s *Server) updateStoreCoinAcceptorInfo(c *gin.Context) {
 		return
 	}
 
-	if *reqJson.DisplayType != db.StoreDeviceDisplayTypeWasher && *reqJson.DisplayType != db.StoreDeviceDisplayTypeDryer {
-		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("display type is invalid, display_type=%s", *reqJson.DisplayType)))
+	// 與刪除 display type 使用同一個鎖，避免檢查後 display type 被刪除
+	m := s.rs.NewMutex(distlockutil.GetDeviceDisplayTypeCodeMutexName(*reqJson.DisplayType))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetDeviceDisplayTypeCodeMutexName(*reqJson.DisplayType))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetDeviceDisplayTypeCodeMutexName(*reqJson.DisplayType))
+		}
+	}()
+
+	// display type 需已登錄於 device_display_types
+	if _, err := s.store.GetDeviceDisplayType(c, *reqJson.DisplayType); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("display type is invalid, display_type=%s", *reqJson.DisplayType)))
+			return
+		}
+		logutil.GetLogger().Errorf("get device display type error, err=%s, code=%s", err, *reqJson.DisplayType)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
 		return
 	}
 
@@ -212,6 +383,10 @@ type getStoreCoinAcceptorStatusUri struct {
 	DeviceID *string `uri:"device_id"`
 }
 
+type getStoreCoinAcceptorStatusQuery struct {
+	MaxAge *int64 `form:"max_age"`
+}
+
 func (s *Server) getStoreCoinAcceptorStatus(c *gin.Context) {
 	var req getStoreCoinAcceptorStatusUri
 	if err := c.ShouldBindUri(&req); err != nil {
@@ -235,12 +410,24 @@ func (s *Server) getStoreCoinAcceptorStatus(c *gin.Context) {
 		return
 	}
 
+	var reqQuery getStoreCoinAcceptorStatusQuery
+	if err := c.ShouldBindQuery(&reqQuery); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqQuery.MaxAge != nil && *reqQuery.MaxAge < 0 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "max_age is smaller than 0"))
+		return
+	}
+
 	arg := db.GetStoreDeviceParams{
 		StoreID:  storeID,
 		DeviceID: *req.DeviceID,
 	}
 
-	if _, err := s.store.GetStoreDevice(c, arg); err != nil {
+	storeDevice, err := s.store.GetStoreDevice(c, arg)
+	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreDeviceNotFoundError, fmt.Sprintf("store device not found, store_id=%s, device_id=%s", *req.StoreID, *req.DeviceID)))
 			return
@@ -253,7 +440,13 @@ func (s *Server) getStoreCoinAcceptorStatus(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c, time.Second)
 	defer cancel()
 
-	status, err := s.iot.GetCoinAcceptorStatus(ctx, storeID, *req.DeviceID)
+	// 未指定 max_age 時一律即時查詢投幣器
+	var status *iotsdk.CoinAcceptorStatus
+	if reqQuery.MaxAge != nil {
+		status, err = s.iot.GetCachedCoinAcceptorStatus(ctx, storeID, storeDevice.HardwareID, time.Duration(*reqQuery.MaxAge)*time.Millisecond)
+	} else {
+		status, err = s.iot.GetCoinAcceptorStatus(ctx, storeID, storeDevice.HardwareID)
+	}
 	if err != nil {
 		switch err.(type) {
 		case *iotsdk.DeviceNotFoundError:
@@ -268,9 +461,10 @@ func (s *Server) getStoreCoinAcceptorStatus(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"points": status.Points,
-		"state":  status.State,
-		"ts":     status.Ts,
+		"points":     status.Points,
+		"state":      status.State,
+		"ts":         status.Ts,
+		"updated_at": status.UpdatedAt,
 	})
 }
 
@@ -307,7 +501,8 @@ func (s *Server) blinkStoreCoinAcceptor(c *gin.Context) {
 		DeviceID: *req.DeviceID,
 	}
 
-	if _, err := s.store.GetStoreDevice(c, arg); err != nil {
+	storeDevice, err := s.store.GetStoreDevice(c, arg)
+	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreDeviceNotFoundError, fmt.Sprintf("store device not found, store_id=%s, device_id=%s", *req.StoreID, *req.DeviceID)))
 			return
@@ -320,7 +515,7 @@ func (s *Server) blinkStoreCoinAcceptor(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c, time.Second)
 	defer cancel()
 
-	if err := s.iot.BlinkCoinAcceptor(ctx, storeID, *req.DeviceID); err != nil {
+	if err := s.iot.BlinkCoinAcceptor(ctx, storeID, storeDevice.HardwareID); err != nil {
 		switch err.(type) {
 		case *iotsdk.DeviceNotFoundError:
 			c.JSON(http.StatusBadRequest, newErrorResponse(codeStoreDeviceNotOnlineError, fmt.Sprintf("store device is not online, store_id=%s, device_id=%s", *req.StoreID, *req.DeviceID)))
@@ -343,6 +538,8 @@ type insertCoinToStoreCoinAcceptorUri struct {
 
 type insertCoinToStoreCoinAcceptorRequest struct {
 	Amount *int32 `json:"amount"`
+	// UseOwnBalance 家庭錢包成員可選擇由自己的 store user 扣款
+	UseOwnBalance *bool `json:"use_own_balance"`
 }
 
 func (s *Server) insertCoinsToStoreCoinAcceptor(c *gin.Context) {
@@ -391,7 +588,8 @@ func (s *Server) insertCoinsToStoreCoinAcceptor(c *gin.Context) {
 		DeviceID: *reqUri.DeviceID,
 	}
 
-	if _, err := s.store.GetStoreDevice(c, arg1); err != nil {
+	storeDevice, err := s.store.GetStoreDevice(c, arg1)
+	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreDeviceNotFoundError, fmt.Sprintf("store device not found, store_id=%s, device_id=%s", *reqUri.StoreID, *reqUri.DeviceID)))
 			return
@@ -401,27 +599,72 @@ func (s *Server) insertCoinsToStoreCoinAcceptor(c *gin.Context) {
 		return
 	}
 
+	switch storeDevice.State {
+	case fsmutil.StoreDeviceStateActive:
+	case fsmutil.StoreDeviceStateMaintenance:
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeStoreDeviceUnderMaintenanceError, fmt.Sprintf("store device is under maintenance, store_id=%s, device_id=%s", *reqUri.StoreID, *reqUri.DeviceID)))
+		return
+	default:
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeStoreDeviceNotActiveError, fmt.Sprintf("store device is not active, store_id=%s, device_id=%s, state=%s", *reqUri.StoreID, *reqUri.DeviceID, storeDevice.State)))
+		return
+	}
+
 	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
 	userID := authPayload.Subject
+
+	// 參與品牌錢包的商店優先由品牌錢包扣款，餘額不足時改由 store user 扣款
+	if s.insertCoinsFromBrandWallet(c, storeDevice, userID, *reqJson.Amount) {
+		return
+	}
+
 	var balanceEarmarkAmount, pointsEarmarkAmount int32
+	var familyWalletSpendingID uuid.UUID
+
+	// 已接受邀請的家庭錢包成員改由錢包擁有者的 store user 扣款，use_own_balance 為 true 時仍由自己扣款，
+	// record 的 user_id 仍為實際操作的 user
+	payerID := userID
+	var familyWalletMember db.FamilyWalletMember
+	isFamilyWalletMember := false
+	if reqJson.UseOwnBalance == nil || !*reqJson.UseOwnBalance {
+		arg4 := db.GetFamilyWalletMemberParams{
+			StoreID: storeID,
+			UserID:  userID,
+		}
+		familyWalletMember, err = s.store.GetFamilyWalletMember(c, arg4)
+		if err != nil && err != sql.ErrNoRows {
+			logutil.GetLogger().Errorf("get family wallet member error, err=%s, arg=%#v", err, arg4)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		isFamilyWalletMember = err == nil && familyWalletMember.AcceptedAt.Valid
+	}
+	if isFamilyWalletMember {
+		familyWallet, err := s.store.GetFamilyWallet(c, familyWalletMember.FamilyWalletID)
+		if err != nil {
+			logutil.GetLogger().Errorf("get family wallet error, err=%s, family_wallet_id=%s", err, familyWalletMember.FamilyWalletID)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		payerID = familyWallet.OwnerUserID
+	}
 
 	{
-		m := s.rs.NewMutex(distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+		m := s.rs.NewMutex(distlockutil.GetStoreUserIDMutexName(storeID.String(), payerID.String()))
 		if err := m.Lock(); err != nil {
-			logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+			logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), payerID.String()))
 			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
 			return
 		}
 
 		unlock := func() {
 			if ok, err := m.Unlock(); !ok || err != nil {
-				logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+				logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), payerID.String()))
 			}
 		}
 
 		arg2 := db.GetStoreUserParams{
 			StoreID: storeID,
-			UserID:  userID,
+			UserID:  payerID,
 		}
 
 		storeUser, err := s.store.GetStoreUser(c, arg2)
@@ -432,10 +675,51 @@ func (s *Server) insertCoinsToStoreCoinAcceptor(c *gin.Context) {
 			return
 		}
 
+		if isFamilyWalletMember {
+			if storeUser.State != fsmutil.StoreUserStateActive {
+				c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, fmt.Sprintf("family wallet owner is not active, store_id=%s, user_id=%s", storeID, payerID)))
+				unlock()
+				return
+			}
+
+			if familyWalletMember.SpendingCap.Valid {
+				arg5 := db.SumFamilyWalletMemberSpendingsParams{
+					FamilyWalletID: familyWalletMember.FamilyWalletID,
+					UserID:         userID,
+					Ts:             time.Now().Add(-s.config.FamilyWallet.SpendingCapPeriod).UnixMilli(),
+				}
+				spent, err := s.store.SumFamilyWalletMemberSpendings(c, arg5)
+				if err != nil {
+					logutil.GetLogger().Errorf("sum family wallet member spendings error, err=%s, arg=%#v", err, arg5)
+					c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+					unlock()
+					return
+				}
+
+				if spent+int64(*reqJson.Amount) > int64(familyWalletMember.SpendingCap.Int32) {
+					c.JSON(http.StatusBadRequest, newErrorResponse(codeFamilyWalletSpendingCapError,
+						fmt.Sprintf("family wallet spending cap has been reached, spending_cap=%d, spent=%d, amount=%d", familyWalletMember.SpendingCap.Int32, spent, *reqJson.Amount)))
+					unlock()
+					return
+				}
+			}
+		}
+
+		// 負餘額扣款的權限只適用於操作者自己的 store user，由家庭錢包 owner 扣款時一律檢查額度
 		scopes := c.MustGet(authorizationScopesKey).(roleutil.Scopes)
-		if !contains(scopes, roleutil.ScopeStoreDeviceInsertCoinsWithNegativeBalance) {
-			if storeUser.Balance+storeUser.Points < *reqJson.Amount {
-				c.JSON(http.StatusBadRequest, newErrorResponse(codeLowBalanceError, fmt.Sprintf("low balance, balance=%d, points=%d, amount=%d", storeUser.Balance, storeUser.Points, *reqJson.Amount)))
+		if payerID != userID || !contains(scopes, roleutil.ScopeStoreDeviceInsertCoinsWithNegativeBalance) {
+			creditLimit, err := s.getEffectiveCreditLimit(c, storeID, payerID)
+			if err != nil {
+				logutil.GetLogger().Errorf("get effective credit limit error, err=%s, store_id=%s, user_id=%s", err, storeID, payerID)
+				c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+				unlock()
+				return
+			}
+
+			// balance 與 points 已扣除 earmark，可用額度為兩者加上信用額度，以 int64 計算避免溢位
+			if int64(storeUser.Balance)+int64(storeUser.Points)+int64(creditLimit) < int64(*reqJson.Amount) {
+				c.JSON(http.StatusBadRequest, newErrorResponse(codeLowBalanceError,
+					fmt.Sprintf("low balance, balance=%d, points=%d, credit_limit=%d, amount=%d", storeUser.Balance, storeUser.Points, creditLimit, *reqJson.Amount)))
 				unlock()
 				return
 			}
@@ -460,14 +744,36 @@ func (s *Server) insertCoinsToStoreCoinAcceptor(c *gin.Context) {
 			ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
 			ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
 			StoreID:          storeID,
-			UserID:           userID,
+			UserID:           payerID,
 			Balance:          storeUser.Balance - balanceEarmarkAmount,
 			Points:           storeUser.Points - pointsEarmarkAmount,
 			BalanceEarmark:   storeUser.BalanceEarmark + balanceEarmarkAmount,
 			PointsEarmark:    storeUser.PointsEarmark + pointsEarmarkAmount,
 		}
 
-		if err := s.store.SetStoreUserBalanceWithLog(c, arg3); err != nil {
+		if isFamilyWalletMember {
+			// 花費與 earmark 一起在鎖內寫入，iot 失敗時再刪除
+			familyWalletSpendingID = uuid.New()
+			arg6 := db.SetStoreUserBalanceWithFamilyWalletSpendingWithLogParams{
+				SetStoreUserBalanceWithLogParams: arg3,
+				Spending: db.CreateFamilyWalletSpendingParams{
+					ID:             familyWalletSpendingID,
+					FamilyWalletID: familyWalletMember.FamilyWalletID,
+					StoreID:        storeID,
+					UserID:         userID,
+					DeviceID:       *reqUri.DeviceID,
+					Amount:         balanceEarmarkAmount,
+					PointAmount:    pointsEarmarkAmount,
+					Ts:             arg3.ChangedAt,
+				},
+			}
+			if err := s.store.SetStoreUserBalanceWithFamilyWalletSpendingWithLog(c, arg6); err != nil {
+				logutil.GetLogger().Errorf("set store user balance with family wallet spending with log error, err=%s, arg=%#v", err, arg6)
+				c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+				unlock()
+				return
+			}
+		} else if err := s.store.SetStoreUserBalanceWithLog(c, arg3); err != nil {
 			logutil.GetLogger().Errorf("set store user balance with log error, err=%s, arg=%#v", err, arg3)
 			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
 			unlock()
@@ -480,24 +786,24 @@ func (s *Server) insertCoinsToStoreCoinAcceptor(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c, 3*time.Second)
 	defer cancel()
 
-	if err := s.iot.AddPointsToCoinAcceptor(ctx, storeID, *reqUri.DeviceID, *reqJson.Amount); err != nil {
+	if err := s.iot.AddPointsToCoinAcceptor(ctx, storeID, storeDevice.HardwareID, *reqJson.Amount); err != nil {
 		{
-			m := s.rs.NewMutex(distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+			m := s.rs.NewMutex(distlockutil.GetStoreUserIDMutexName(storeID.String(), payerID.String()))
 			if err := m.Lock(); err != nil {
-				logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+				logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), payerID.String()))
 				c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
 				return
 			}
 
 			defer func() {
 				if ok, err := m.Unlock(); !ok || err != nil {
-					logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+					logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), payerID.String()))
 				}
 			}()
 
 			arg2 := db.GetStoreUserParams{
 				StoreID: storeID,
-				UserID:  userID,
+				UserID:  payerID,
 			}
 
 			storeUser, err := s.store.GetStoreUser(c, arg2)
@@ -514,7 +820,7 @@ func (s *Server) insertCoinsToStoreCoinAcceptor(c *gin.Context) {
 				ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
 				ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
 				StoreID:          storeID,
-				UserID:           userID,
+				UserID:           payerID,
 				Balance:          storeUser.Balance + balanceEarmarkAmount,
 				Points:           storeUser.Points + pointsEarmarkAmount,
 				BalanceEarmark:   storeUser.BalanceEarmark - balanceEarmarkAmount,
@@ -526,6 +832,14 @@ func (s *Server) insertCoinsToStoreCoinAcceptor(c *gin.Context) {
 				c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
 				return
 			}
+
+			if isFamilyWalletMember {
+				if err := s.store.DeleteFamilyWalletSpending(c, familyWalletSpendingID); err != nil {
+					logutil.GetLogger().Errorf("delete family wallet spending error, err=%s, id=%s", err, familyWalletSpendingID)
+					c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+					return
+				}
+			}
 		}
 		switch err.(type) {
 		case *iotsdk.DeviceNotFoundError:
@@ -540,22 +854,22 @@ func (s *Server) insertCoinsToStoreCoinAcceptor(c *gin.Context) {
 	}
 
 	{
-		m := s.rs.NewMutex(distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+		m := s.rs.NewMutex(distlockutil.GetStoreUserIDMutexName(storeID.String(), payerID.String()))
 		if err := m.Lock(); err != nil {
-			logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+			logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), payerID.String()))
 			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
 			return
 		}
 
 		defer func() {
 			if ok, err := m.Unlock(); !ok || err != nil {
-				logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+				logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), payerID.String()))
 			}
 		}()
 
 		arg2 := db.GetStoreUserParams{
 			StoreID: storeID,
-			UserID:  userID,
+			UserID:  payerID,
 		}
 
 		storeUser, err := s.store.GetStoreUser(c, arg2)
@@ -572,7 +886,7 @@ func (s *Server) insertCoinsToStoreCoinAcceptor(c *gin.Context) {
 			ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
 			ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
 			StoreID:          storeID,
-			UserID:           userID,
+			UserID:           payerID,
 			Balance:          storeUser.Balance,
 			Points:           storeUser.Points,
 			BalanceEarmark:   storeUser.BalanceEarmark - balanceEarmarkAmount,
@@ -672,7 +986,7 @@ func (s *Server) getStoreDeviceRecords(c *gin.Context) {
 				"amount": record.Amount,
 				"ts":     record.Ts,
 			})
-		case db.RecordTypeCoinAcceptorRemoteInsertCoins:
+		case db.RecordTypeCoinAcceptorRemoteInsertCoins, db.RecordTypeBrandWalletInsertCoins:
 			records = append(records, gin.H{
 				"type":         record.Type,
 				"user_id":      record.UserID,
@@ -0,0 +1,158 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	"backend/token"
+	distlockutil "backend/util/distlock"
+	fsmutil "backend/util/fsm"
+	logutil "backend/util/log"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redsync/redsync/v4"
+	"github.com/google/uuid"
+)
+
+type replaceStoreDeviceUri struct {
+	StoreID  *string `uri:"store_id"`
+	DeviceID *string `uri:"device_id"`
+}
+
+type replaceStoreDeviceRequest struct {
+	NewDeviceID *string `json:"new_device_id"`
+}
+
+// replaceStoreDevice 更換機台的投幣器板子後，將新板子的 device_id 對應到原本的機台，
+// 機台名稱、類型與紀錄皆保留，edge 與 IoT 改以新的硬體 ID 溝通
+func (s *Server) replaceStoreDevice(c *gin.Context) {
+	var reqUri replaceStoreDeviceUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	if reqUri.DeviceID == nil || *reqUri.DeviceID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "device_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreDeviceNotFoundError, fmt.Sprintf("store device not found, store_id=%s, device_id=%s", *reqUri.StoreID, *reqUri.DeviceID)))
+		return
+	}
+
+	var reqJson replaceStoreDeviceRequest
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqJson.NewDeviceID == nil || *reqJson.NewDeviceID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "new_device_id is null or empty"))
+		return
+	}
+
+	// 同時鎖定原本的機台與新硬體註冊時建立的待核准機台
+	mutexes := make([]*redsync.Mutex, 0, 2)
+	defer func() {
+		for i := len(mutexes) - 1; i >= 0; i-- {
+			if ok, err := mutexes[i].Unlock(); !ok || err != nil {
+				logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, mutexes[i].Name())
+			}
+		}
+	}()
+	for _, name := range distlockutil.GetSortedStoreDeviceIDMutexNames(storeID.String(), *reqUri.DeviceID, *reqJson.NewDeviceID) {
+		m := s.rs.NewMutex(name)
+		if err := m.Lock(); err != nil {
+			logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, name)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		mutexes = append(mutexes, m)
+	}
+
+	arg1 := db.GetStoreDeviceParams{
+		StoreID:  storeID,
+		DeviceID: *reqUri.DeviceID,
+	}
+
+	storeDevice, err := s.store.GetStoreDevice(c, arg1)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreDeviceNotFoundError, fmt.Sprintf("store device not found, store_id=%s, device_id=%s", *reqUri.StoreID, *reqUri.DeviceID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get store device error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if storeDevice.State == fsmutil.StoreDeviceStatePendingApproval || storeDevice.State == fsmutil.StoreDeviceStateDecommissioned || storeDevice.State == fsmutil.StoreDeviceStateArchived {
+		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, fmt.Sprintf("store device cannot be replaced, store_id=%s, device_id=%s, state=%s", *reqUri.StoreID, *reqUri.DeviceID, storeDevice.State)))
+		return
+	}
+
+	if storeDevice.HardwareID == *reqJson.NewDeviceID {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	arg2 := db.GetStoreDeviceRetiredHardwareIDParams{
+		StoreID:    storeID,
+		HardwareID: *reqJson.NewDeviceID,
+	}
+	if _, err := s.store.GetStoreDeviceRetiredHardwareID(c, arg2); err == nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeStoreDeviceHardwareInUseError, fmt.Sprintf("new device id is retired, store_id=%s, new_device_id=%s", *reqUri.StoreID, *reqJson.NewDeviceID)))
+		return
+	} else if err != sql.ErrNoRows {
+		logutil.GetLogger().Errorf("get store device retired hardware id error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	// 新硬體若已註冊，只能是尚未核准、也未被其他機台使用的機台
+	arg3 := db.GetStoreDeviceByHardwareIDParams{
+		StoreID:    storeID,
+		HardwareID: *reqJson.NewDeviceID,
+	}
+	newStoreDevice, err := s.store.GetStoreDeviceByHardwareID(c, arg3)
+	if err != nil && err != sql.ErrNoRows {
+		logutil.GetLogger().Errorf("get store device by hardware id error, err=%s, arg=%#v", err, arg3)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if err == nil && (newStoreDevice.DeviceID != *reqJson.NewDeviceID || newStoreDevice.State != fsmutil.StoreDeviceStatePendingApproval) {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeStoreDeviceHardwareInUseError, fmt.Sprintf("new device id is in use, store_id=%s, new_device_id=%s", *reqUri.StoreID, *reqJson.NewDeviceID)))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg4 := db.ReplaceStoreDeviceHardwareWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangedBy:        authPayload.Subject,
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		StoreID:          storeID,
+		DeviceID:         storeDevice.DeviceID,
+		OldHardwareID:    storeDevice.HardwareID,
+		NewHardwareID:    *reqJson.NewDeviceID,
+	}
+
+	if err := s.store.ReplaceStoreDeviceHardwareWithLog(c, arg4); err != nil {
+		logutil.GetLogger().Errorf("replace store device hardware with log error, err=%s, arg=%#v", err, arg4)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,135 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	"backend/token"
+	distlockutil "backend/util/distlock"
+	fsmutil "backend/util/fsm"
+	logutil "backend/util/log"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/looplab/fsm"
+)
+
+type transitStoreDeviceStateUri struct {
+	StoreID  *string `uri:"store_id"`
+	DeviceID *string `uri:"device_id"`
+}
+
+// transitStoreDeviceState 依 event 變更 store device 的 state 並寫入 store_devices_history
+func (s *Server) transitStoreDeviceState(c *gin.Context, event string, changedType string) {
+	var reqUri transitStoreDeviceStateUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	if reqUri.DeviceID == nil || *reqUri.DeviceID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "device_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreDeviceNotFoundError, fmt.Sprintf("store device not found, store_id=%s, device_id=%s", *reqUri.StoreID, *reqUri.DeviceID)))
+		return
+	}
+
+	m := s.rs.NewMutex(distlockutil.GetStoreDeviceIDMutexName(storeID.String(), *reqUri.DeviceID))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreDeviceIDMutexName(storeID.String(), *reqUri.DeviceID))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreDeviceIDMutexName(storeID.String(), *reqUri.DeviceID))
+		}
+	}()
+
+	arg1 := db.GetStoreDeviceParams{
+		StoreID:  storeID,
+		DeviceID: *reqUri.DeviceID,
+	}
+
+	storeDevice, err := s.store.GetStoreDevice(c, arg1)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreDeviceNotFoundError, fmt.Sprintf("store device not found, store_id=%s, device_id=%s", *reqUri.StoreID, *reqUri.DeviceID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get store device error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	storeDeviceFSM := fsmutil.NewStoreDeviceFSM(storeDevice.State)
+	if err := storeDeviceFSM.Event(c, event); err != nil {
+		switch err.(type) {
+		case fsm.InvalidEventError:
+			c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, fmt.Sprintf("store device state cannot %s, store_id=%s, device_id=%s, state=%s", event, *reqUri.StoreID, *reqUri.DeviceID, storeDevice.State)))
+			return
+		default:
+			logutil.GetLogger().Errorf("store device fsm error, err=%s, init_state=%s, event=%s", err, storeDevice.State, event)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg2 := db.SetStoreDeviceStateWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangeType:       changedType,
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		StoreID:          storeID,
+		DeviceID:         *reqUri.DeviceID,
+		State:            storeDeviceFSM.Current(),
+	}
+
+	if err := s.store.SetStoreDeviceStateWithLog(c, arg2); err != nil {
+		logutil.GetLogger().Errorf("set store device state with log error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// approveStoreDevice 核准 edge 註冊的新機台
+func (s *Server) approveStoreDevice(c *gin.Context) {
+	s.transitStoreDeviceState(c, fsmutil.StoreDeviceEventApprove, db.StoreDeviceChangedTypeApprove)
+}
+
+func (s *Server) startStoreDeviceMaintenance(c *gin.Context) {
+	s.transitStoreDeviceState(c, fsmutil.StoreDeviceEventStartMaintenance, db.StoreDeviceChangedTypeStartMaintenance)
+}
+
+func (s *Server) finishStoreDeviceMaintenance(c *gin.Context) {
+	s.transitStoreDeviceState(c, fsmutil.StoreDeviceEventFinishMaintenance, db.StoreDeviceChangedTypeFinishMaintenance)
+}
+
+func (s *Server) disableStoreDevice(c *gin.Context) {
+	s.transitStoreDeviceState(c, fsmutil.StoreDeviceEventDisable, db.StoreDeviceChangedTypeDisable)
+}
+
+func (s *Server) enableStoreDevice(c *gin.Context) {
+	s.transitStoreDeviceState(c, fsmutil.StoreDeviceEventEnable, db.StoreDeviceChangedTypeEnable)
+}
+
+// decommissionStoreDevice 除役後無法再變更 state，edge 重新註冊同一機台也不會恢復
+func (s *Server) decommissionStoreDevice(c *gin.Context) {
+	s.transitStoreDeviceState(c, fsmutil.StoreDeviceEventDecommission, db.StoreDeviceChangedTypeDecommission)
+}
@@ -0,0 +1,236 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	"backend/token"
+	distlockutil "backend/util/distlock"
+	fsmutil "backend/util/fsm"
+	logutil "backend/util/log"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redsync/redsync/v4"
+	"github.com/google/uuid"
+	"github.com/looplab/fsm"
+)
+
+type transferStoreDeviceUri struct {
+	StoreID  *string `uri:"store_id"`
+	DeviceID *string `uri:"device_id"`
+}
+
+type transferStoreDeviceRequest struct {
+	TargetStoreID *string `json:"target_store_id"`
+}
+
+// transferStoreDevice 將機台移到其他分店，來源商店的機台封存，紀錄仍保留在發生時的商店
+func (s *Server) transferStoreDevice(c *gin.Context) {
+	var reqUri transferStoreDeviceUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	if reqUri.DeviceID == nil || *reqUri.DeviceID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "device_id is null or empty"))
+		return
+	}
+
+	var reqJson transferStoreDeviceRequest
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqJson.TargetStoreID == nil || *reqJson.TargetStoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "target_store_id is null or empty"))
+		return
+	}
+
+	sourceStoreID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	targetStoreID, err := uuid.Parse(*reqJson.TargetStoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqJson.TargetStoreID)))
+		return
+	}
+
+	if sourceStoreID == targetStoreID {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "target_store_id is the same as store_id"))
+		return
+	}
+
+	for _, storeID := range []uuid.UUID{sourceStoreID, targetStoreID} {
+		accessible, err := s.isStoreAccessible(c, storeID)
+		if err != nil {
+			logutil.GetLogger().Errorf("check store accessible error, err=%s, store_id=%s", err, storeID)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		if !accessible {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", storeID)))
+			return
+		}
+	}
+
+	if _, err := s.store.GetStore(c, targetStoreID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", targetStoreID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get store error, err=%s, store_id=%s", err, targetStoreID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	// 依固定順序鎖定來源與目標商店的機台，避免互相移轉時 deadlock
+	mutexNames := []string{
+		distlockutil.GetStoreDeviceIDMutexName(sourceStoreID.String(), *reqUri.DeviceID),
+		distlockutil.GetStoreDeviceIDMutexName(targetStoreID.String(), *reqUri.DeviceID),
+	}
+	sort.Strings(mutexNames)
+
+	mutexes := make([]*redsync.Mutex, 0, len(mutexNames))
+	defer func() {
+		for i := len(mutexes) - 1; i >= 0; i-- {
+			if ok, err := mutexes[i].Unlock(); !ok || err != nil {
+				logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, mutexes[i].Name())
+			}
+		}
+	}()
+	for _, name := range mutexNames {
+		m := s.rs.NewMutex(name)
+		if err := m.Lock(); err != nil {
+			logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, name)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		mutexes = append(mutexes, m)
+	}
+
+	arg1 := db.GetStoreDeviceParams{
+		StoreID:  sourceStoreID,
+		DeviceID: *reqUri.DeviceID,
+	}
+
+	storeDevice, err := s.store.GetStoreDevice(c, arg1)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreDeviceNotFoundError, fmt.Sprintf("store device not found, store_id=%s, device_id=%s", *reqUri.StoreID, *reqUri.DeviceID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get store device error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	storeDeviceFSM := fsmutil.NewStoreDeviceFSM(storeDevice.State)
+	if err := storeDeviceFSM.Event(c, fsmutil.StoreDeviceEventTransferOut); err != nil {
+		switch err.(type) {
+		case fsm.InvalidEventError:
+			c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, fmt.Sprintf("store device cannot be transferred, store_id=%s, device_id=%s, state=%s", *reqUri.StoreID, *reqUri.DeviceID, storeDevice.State)))
+			return
+		default:
+			logutil.GetLogger().Errorf("store device fsm error, err=%s, init_state=%s, event=%s", err, storeDevice.State, fsmutil.StoreDeviceEventTransferOut)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+	}
+
+	// 目標商店不能已有其他機台使用相同的硬體
+	arg2 := db.GetStoreDeviceByHardwareIDParams{
+		StoreID:    targetStoreID,
+		HardwareID: storeDevice.HardwareID,
+	}
+	if targetDevice, err := s.store.GetStoreDeviceByHardwareID(c, arg2); err == nil {
+		if targetDevice.DeviceID != storeDevice.DeviceID || targetDevice.State != fsmutil.StoreDeviceStateArchived {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeStoreDeviceRegisteredError, fmt.Sprintf("store device is registered in target store, store_id=%s, device_id=%s", targetStoreID, targetDevice.DeviceID)))
+			return
+		}
+	} else if err != sql.ErrNoRows {
+		logutil.GetLogger().Errorf("get store device by hardware id error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg3 := db.TransferStoreDeviceWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		SourceStoreID:    sourceStoreID,
+		TargetStoreID:    targetStoreID,
+		DeviceID:         storeDevice.DeviceID,
+		SourceState:      storeDeviceFSM.Current(),
+	}
+
+	if _, err := s.store.TransferStoreDeviceWithLog(c, arg3); err != nil {
+		if err == db.ErrStoreDeviceRegistered {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeStoreDeviceRegisteredError, fmt.Sprintf("store device is registered in target store, store_id=%s, device_id=%s", targetStoreID, storeDevice.DeviceID)))
+			return
+		}
+		logutil.GetLogger().Errorf("transfer store device with log error, err=%s, arg=%#v", err, arg3)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type getStoreDeviceMisplacementsUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+// getStoreDeviceMisplacements 已在其他商店使用中、卻出現在此商店 edge 的機台
+func (s *Server) getStoreDeviceMisplacements(c *gin.Context) {
+	var req getStoreDeviceMisplacementsUri
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.StoreID == nil || *req.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*req.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store not found, store_id=%s", *req.StoreID)))
+		return
+	}
+
+	misplacements, err := s.store.GetStoreDeviceMisplacements(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store device misplacements error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	items := make([]gin.H, 0, len(misplacements))
+	for _, misplacement := range misplacements {
+		items = append(items, gin.H{
+			"hardware_id":          misplacement.HardwareID,
+			"registered_store_id":  misplacement.RegisteredStoreID.String(),
+			"registered_device_id": misplacement.RegisteredDeviceID,
+			"first_seen_at":        misplacement.FirstSeenAt,
+			"last_seen_at":         misplacement.LastSeenAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"misplacements": items})
+}
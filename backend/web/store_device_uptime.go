@@ -0,0 +1,149 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	fsmutil "backend/util/fsm"
+	logutil "backend/util/log"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type getStoreDeviceUptimeReportUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+type getStoreDeviceUptimeReportQuery struct {
+	From *int64 `form:"from"`
+	To   *int64 `form:"to"`
+}
+
+// getStoreDeviceUptimeReport 依 edge 回報的連線 session 計算各機台在區間內的上線比例
+func (s *Server) getStoreDeviceUptimeReport(c *gin.Context) {
+	var reqUri getStoreDeviceUptimeReportUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store not found, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	var reqQuery getStoreDeviceUptimeReportQuery
+	if err := c.ShouldBindQuery(&reqQuery); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqQuery.From == nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "from is null"))
+		return
+	}
+
+	if reqQuery.To == nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "to is null"))
+		return
+	}
+
+	if *reqQuery.From >= *reqQuery.To {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "from is greater than or equal to to"))
+		return
+	}
+
+	maxRange := s.config.MaxStoreDeviceUptimeRange.Milliseconds()
+	if *reqQuery.From < *reqQuery.To-maxRange {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("range between from and to is longer than %d", maxRange)))
+		return
+	}
+
+	arg1 := db.GetStoreDevicesParams{
+		StoreID: storeID,
+	}
+	storeDevices, err := s.store.GetStoreDevices(c, arg1)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store devices error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	arg2 := db.GetStoreDeviceSessionsInRangeParams{
+		StoreID: storeID,
+		ToTs:    *reqQuery.To,
+		FromTs:  *reqQuery.From,
+	}
+	sessions, err := s.store.GetStoreDeviceSessionsInRange(c, arg2)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store device sessions in range error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	// 尚未發生的時間不計入區間，連線中的 session 算到現在為止
+	now := time.Now().UnixMilli()
+	from, to := *reqQuery.From, *reqQuery.To
+	if to > now {
+		to = now
+	}
+
+	onlineMs := make(map[string]int64)
+	for _, session := range sessions {
+		start, end := session.StartedAt, now
+		if endedAt, ended := storeDeviceSessionEndedAt(session, now, s.config.StoreDeviceSessionTimeout); ended {
+			end = endedAt
+		}
+		if start < from {
+			start = from
+		}
+		if end > to {
+			end = to
+		}
+		if end > start {
+			onlineMs[session.DeviceID] += end - start
+		}
+	}
+
+	devices := make([]gin.H, 0, len(storeDevices))
+	for _, storeDevice := range storeDevices {
+		if storeDevice.State == fsmutil.StoreDeviceStateArchived {
+			continue
+		}
+		uptime := float64(0)
+		if to > from {
+			uptime = float64(onlineMs[storeDevice.DeviceID]) * 100 / float64(to-from)
+		}
+		devices = append(devices, gin.H{
+			"id":             storeDevice.DeviceID,
+			"name":           storeDevice.Name,
+			"state":          storeDevice.State,
+			"online_ms":      onlineMs[storeDevice.DeviceID],
+			"uptime_percent": uptime,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":    *reqQuery.From,
+		"to":      *reqQuery.To,
+		"devices": devices,
+	})
+}
+
+// storeDeviceSessionEndedAt 取得 session 的結束時間，尚未結束但超過 timeout 未回報者視為已於最後回報時間結束
+func storeDeviceSessionEndedAt(session db.StoreDeviceSession, now int64, timeout time.Duration) (int64, bool) {
+	if session.EndedAt.Valid {
+		return session.EndedAt.Int64, true
+	}
+	if now-session.LastSeenAt > timeout.Milliseconds() {
+		return session.LastSeenAt, true
+	}
+	return 0, false
+}
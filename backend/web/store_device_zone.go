@@ -0,0 +1,417 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	"backend/token"
+	distlockutil "backend/util/distlock"
+	logutil "backend/util/log"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func newStoreDeviceZoneResponse(zone db.StoreDeviceZone) gin.H {
+	return gin.H{
+		"id":         zone.ID.String(),
+		"name":       zone.Name,
+		"sort_order": zone.SortOrder,
+		"created_at": zone.CreatedAt,
+	}
+}
+
+// bindStoreDeviceZoneStoreID 檢查 store_id，失敗時已回應
+func bindStoreDeviceZoneStoreID(c *gin.Context, storeIDStr *string) (uuid.UUID, bool) {
+	if storeIDStr == nil || *storeIDStr == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return uuid.UUID{}, false
+	}
+
+	storeID, err := uuid.Parse(*storeIDStr)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store not found, store_id=%s", *storeIDStr)))
+		return uuid.UUID{}, false
+	}
+
+	return storeID, true
+}
+
+// bindStoreDeviceZone 檢查 zone_id 並取得屬於該商店的區域，失敗時已回應
+func (s *Server) bindStoreDeviceZone(c *gin.Context, storeID uuid.UUID, zoneIDStr *string) (db.StoreDeviceZone, bool) {
+	if zoneIDStr == nil || *zoneIDStr == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "zone_id is null or empty"))
+		return db.StoreDeviceZone{}, false
+	}
+
+	zoneID, err := uuid.Parse(*zoneIDStr)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreDeviceZoneNotFoundError, fmt.Sprintf("store device zone not found, store_id=%s, zone_id=%s", storeID, *zoneIDStr)))
+		return db.StoreDeviceZone{}, false
+	}
+
+	arg := db.GetStoreDeviceZoneParams{
+		StoreID: storeID,
+		ID:      zoneID,
+	}
+
+	zone, err := s.store.GetStoreDeviceZone(c, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreDeviceZoneNotFoundError, fmt.Sprintf("store device zone not found, store_id=%s, zone_id=%s", storeID, *zoneIDStr)))
+			return db.StoreDeviceZone{}, false
+		}
+		logutil.GetLogger().Errorf("get store device zone error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return db.StoreDeviceZone{}, false
+	}
+
+	return zone, true
+}
+
+type getStoreDeviceZonesUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+func (s *Server) getStoreDeviceZones(c *gin.Context) {
+	var req getStoreDeviceZonesUri
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	storeID, ok := bindStoreDeviceZoneStoreID(c, req.StoreID)
+	if !ok {
+		return
+	}
+
+	zones, err := s.store.GetStoreDeviceZones(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store device zones error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	res := make([]gin.H, 0, len(zones))
+	for _, zone := range zones {
+		res = append(res, newStoreDeviceZoneResponse(zone))
+	}
+	c.JSON(http.StatusOK, gin.H{"zones": res})
+}
+
+type createStoreDeviceZoneUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+type storeDeviceZoneRequest struct {
+	Name      *string `json:"name"`
+	SortOrder *int32  `json:"sort_order"`
+}
+
+// validate 檢查區域名稱與排序，失敗時已回應
+func (req storeDeviceZoneRequest) validate(c *gin.Context, maxNameLength int16) bool {
+	if req.Name == nil || *req.Name == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "name is null or empty"))
+		return false
+	}
+
+	if len(*req.Name) > int(maxNameLength) {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError,
+			fmt.Sprintf("name longer than %d characters", maxNameLength)))
+		return false
+	}
+
+	if req.SortOrder == nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "sort_order is null"))
+		return false
+	}
+
+	return true
+}
+
+func (s *Server) createStoreDeviceZone(c *gin.Context) {
+	var reqUri createStoreDeviceZoneUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	storeID, ok := bindStoreDeviceZoneStoreID(c, reqUri.StoreID)
+	if !ok {
+		return
+	}
+
+	var reqJson storeDeviceZoneRequest
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if !reqJson.validate(c, s.config.MaxStoreNameLength) {
+		return
+	}
+
+	arg := db.CreateStoreDeviceZoneParams{
+		ID:        uuid.New(),
+		StoreID:   storeID,
+		Name:      *reqJson.Name,
+		SortOrder: *reqJson.SortOrder,
+	}
+
+	zone, err := s.store.CreateStoreDeviceZone(c, arg)
+	if err != nil {
+		logutil.GetLogger().Errorf("create store device zone error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, newStoreDeviceZoneResponse(zone))
+}
+
+type updateStoreDeviceZoneUri struct {
+	StoreID *string `uri:"store_id"`
+	ZoneID  *string `uri:"zone_id"`
+}
+
+func (s *Server) updateStoreDeviceZone(c *gin.Context) {
+	var reqUri updateStoreDeviceZoneUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	storeID, ok := bindStoreDeviceZoneStoreID(c, reqUri.StoreID)
+	if !ok {
+		return
+	}
+
+	zone, ok := s.bindStoreDeviceZone(c, storeID, reqUri.ZoneID)
+	if !ok {
+		return
+	}
+
+	var reqJson storeDeviceZoneRequest
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if !reqJson.validate(c, s.config.MaxStoreNameLength) {
+		return
+	}
+
+	arg := db.UpdateStoreDeviceZoneParams{
+		StoreID:   storeID,
+		ID:        zone.ID,
+		Name:      *reqJson.Name,
+		SortOrder: *reqJson.SortOrder,
+	}
+
+	zone, err := s.store.UpdateStoreDeviceZone(c, arg)
+	if err != nil {
+		logutil.GetLogger().Errorf("update store device zone error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, newStoreDeviceZoneResponse(zone))
+}
+
+type deleteStoreDeviceZoneUri struct {
+	StoreID *string `uri:"store_id"`
+	ZoneID  *string `uri:"zone_id"`
+}
+
+func (s *Server) deleteStoreDeviceZone(c *gin.Context) {
+	var reqUri deleteStoreDeviceZoneUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	storeID, ok := bindStoreDeviceZoneStoreID(c, reqUri.StoreID)
+	if !ok {
+		return
+	}
+
+	zone, ok := s.bindStoreDeviceZone(c, storeID, reqUri.ZoneID)
+	if !ok {
+		return
+	}
+
+	// 與設定機台區域使用同一個鎖，避免刪除後仍有機台被設定到此區域
+	m := s.rs.NewMutex(distlockutil.GetStoreDeviceZoneIDMutexName(storeID.String(), zone.ID.String()))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreDeviceZoneIDMutexName(storeID.String(), zone.ID.String()))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreDeviceZoneIDMutexName(storeID.String(), zone.ID.String()))
+		}
+	}()
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.DeleteStoreDeviceZoneWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		StoreID:          storeID,
+		ZoneID:           zone.ID,
+	}
+
+	if err := s.store.DeleteStoreDeviceZoneWithLog(c, arg); err != nil {
+		logutil.GetLogger().Errorf("delete store device zone with log error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type updateStoreDeviceLayoutUri struct {
+	StoreID  *string `uri:"store_id"`
+	DeviceID *string `uri:"device_id"`
+}
+
+// updateStoreDeviceLayoutRequest 整筆覆寫，zone_id、machine_number、capacity_kg 為 null 時清除
+type updateStoreDeviceLayoutRequest struct {
+	ZoneID        *string  `json:"zone_id"`
+	SortOrder     *int32   `json:"sort_order"`
+	MachineNumber *string  `json:"machine_number"`
+	CapacityKg    *float64 `json:"capacity_kg"`
+}
+
+func (s *Server) updateStoreDeviceLayout(c *gin.Context) {
+	var reqUri updateStoreDeviceLayoutUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	if reqUri.DeviceID == nil || *reqUri.DeviceID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "device_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store device not found, store_id=%s, device_id=%s", *reqUri.StoreID, *reqUri.DeviceID)))
+		return
+	}
+
+	var reqJson updateStoreDeviceLayoutRequest
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqJson.SortOrder == nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "sort_order is null"))
+		return
+	}
+
+	if reqJson.MachineNumber != nil && len(*reqJson.MachineNumber) > int(s.config.MaxStoreNameLength) {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError,
+			fmt.Sprintf("machine_number longer than %d characters", s.config.MaxStoreNameLength)))
+		return
+	}
+
+	if reqJson.CapacityKg != nil && *reqJson.CapacityKg <= 0 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "capacity_kg is smaller than or equal to 0"))
+		return
+	}
+
+	m := s.rs.NewMutex(distlockutil.GetStoreDeviceIDMutexName(storeID.String(), *reqUri.DeviceID))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreDeviceIDMutexName(storeID.String(), *reqUri.DeviceID))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreDeviceIDMutexName(storeID.String(), *reqUri.DeviceID))
+		}
+	}()
+
+	arg1 := db.GetStoreDeviceParams{
+		StoreID:  storeID,
+		DeviceID: *reqUri.DeviceID,
+	}
+
+	storeDevice, err := s.store.GetStoreDevice(c, arg1)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreDeviceNotFoundError, fmt.Sprintf("store device not found, store_id=%s, device_id=%s", *reqUri.StoreID, *reqUri.DeviceID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get store device error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg2 := db.SetStoreDeviceLayoutWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		StoreID:          storeID,
+		DeviceID:         storeDevice.DeviceID,
+		SortOrder:        *reqJson.SortOrder,
+	}
+
+	// 區域必須屬於同一間商店，持有區域的鎖直到寫入，避免檢查後區域被刪除
+	if reqJson.ZoneID != nil {
+		if zoneID, err := uuid.Parse(*reqJson.ZoneID); err == nil {
+			m2 := s.rs.NewMutex(distlockutil.GetStoreDeviceZoneIDMutexName(storeID.String(), zoneID.String()))
+			if err := m2.Lock(); err != nil {
+				logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreDeviceZoneIDMutexName(storeID.String(), zoneID.String()))
+				c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+				return
+			}
+			defer func() {
+				if ok, err := m2.Unlock(); !ok || err != nil {
+					logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreDeviceZoneIDMutexName(storeID.String(), zoneID.String()))
+				}
+			}()
+		}
+
+		zone, ok := s.bindStoreDeviceZone(c, storeID, reqJson.ZoneID)
+		if !ok {
+			return
+		}
+		arg2.ZoneID = uuid.NullUUID{Valid: true, UUID: zone.ID}
+	}
+	if reqJson.MachineNumber != nil && *reqJson.MachineNumber != "" {
+		arg2.MachineNumber = sql.NullString{Valid: true, String: *reqJson.MachineNumber}
+	}
+	if reqJson.CapacityKg != nil {
+		arg2.CapacityKg = sql.NullFloat64{Valid: true, Float64: *reqJson.CapacityKg}
+	}
+
+	if arg2.ZoneID == storeDevice.ZoneID && arg2.SortOrder == storeDevice.SortOrder &&
+		arg2.MachineNumber == storeDevice.MachineNumber && arg2.CapacityKg == storeDevice.CapacityKg {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	if err := s.store.SetStoreDeviceLayoutWithLog(c, arg2); err != nil {
+		logutil.GetLogger().Errorf("set store device layout with log error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,88 @@
+package web
+
+import (
+	iotsdk "backend/iot-sdk"
+	logutil "backend/util/log"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type getStoreEdgeUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+// getStoreEdge 透過 iot 查詢商店 edge 的版本與執行狀態，用於排查 edge 問題
+func (s *Server) getStoreEdge(c *gin.Context) {
+	var req getStoreEdgeUri
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.StoreID == nil || *req.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*req.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *req.StoreID)))
+		return
+	}
+
+	accessible, err := s.isStoreAccessible(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("check store accessible error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if !accessible {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *req.StoreID)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c, 3*time.Second)
+	defer cancel()
+
+	info, err := s.iot.GetEdgeSystemInfo(ctx, storeID)
+	if err != nil {
+		switch err.(type) {
+		case *iotsdk.StoreNotFoundError:
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeStoreNotOnlineError, fmt.Sprintf("store is not online, store_id=%s", *req.StoreID)))
+		default:
+			logutil.GetLogger().Errorf("get edge system info error, err=%s, store_id=%s", err, storeID)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		}
+		return
+	}
+
+	diagnostics, err := s.iot.GetEdgeDiagnostics(ctx, storeID)
+	if err != nil {
+		switch err.(type) {
+		case *iotsdk.StoreNotFoundError:
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeStoreNotOnlineError, fmt.Sprintf("store is not online, store_id=%s", *req.StoreID)))
+		default:
+			logutil.GetLogger().Errorf("get edge diagnostics error, err=%s, store_id=%s", err, storeID)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"edge_version": info.EdgeVersion,
+		"diagnostics": gin.H{
+			"uptime_ms":                    diagnostics.UptimeMs,
+			"coin_acceptor_count":          diagnostics.CoinAcceptorCount,
+			"unuploaded_records":           diagnostics.UnuploadedRecords,
+			"unuploaded_records_truncated": diagnostics.UnuploadedRecordsTruncated,
+			"mqtt_connected":               diagnostics.MqttConnected,
+			"disk_total_bytes":             diagnostics.DiskTotalBytes,
+			"disk_free_bytes":              diagnostics.DiskFreeBytes,
+		},
+	})
+}
@@ -0,0 +1,355 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	"backend/token"
+	logutil "backend/util/log"
+	roleutil "backend/util/role"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// isStoreGroupLimited 可管理 store 但沒有 ScopeStoreAllAccess 的使用者 (如 hq) 只能管理所屬 store group 內的 store
+func isStoreGroupLimited(scopes roleutil.Scopes) bool {
+	return contains(scopes, roleutil.ScopeStoreWrite) && !contains(scopes, roleutil.ScopeStoreAllAccess)
+}
+
+// isStoreAccessible 受 store group 限制的使用者只能存取所屬 store group 內的 store，其他使用者不受限制
+func (s *Server) isStoreAccessible(c *gin.Context, storeID uuid.UUID) (bool, error) {
+	scopes := c.MustGet(authorizationScopesKey).(roleutil.Scopes)
+	if !isStoreGroupLimited(scopes) {
+		return true, nil
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	return s.store.IsStoreInUserStoreGroups(c, db.IsStoreInUserStoreGroupsParams{
+		UserID:  authPayload.Subject,
+		StoreID: storeID,
+	})
+}
+
+// isStoreGroupAccessible 沒有 ScopeStoreAllAccess 的使用者只能存取自己所屬的 store group
+func (s *Server) isStoreGroupAccessible(c *gin.Context, storeGroupID uuid.UUID) (bool, error) {
+	scopes := c.MustGet(authorizationScopesKey).(roleutil.Scopes)
+	if contains(scopes, roleutil.ScopeStoreAllAccess) {
+		return true, nil
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	return s.store.IsUserInStoreGroup(c, db.IsUserInStoreGroupParams{
+		StoreGroupID: storeGroupID,
+		UserID:       authPayload.Subject,
+	})
+}
+
+func newStoreGroupResponse(storeGroup db.StoreGroup) gin.H {
+	return gin.H{
+		"id":         storeGroup.ID.String(),
+		"name":       storeGroup.Name,
+		"created_by": storeGroup.CreatedBy.String(),
+		"created_at": storeGroup.CreatedAt,
+	}
+}
+
+func (s *Server) getStoreGroups(c *gin.Context) {
+	storeGroups, err := s.store.GetStoreGroups(c)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store groups error, err=%s", err)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	res := make([]gin.H, 0, len(storeGroups))
+	for _, storeGroup := range storeGroups {
+		res = append(res, newStoreGroupResponse(storeGroup))
+	}
+	c.JSON(http.StatusOK, gin.H{"store_groups": res})
+}
+
+type createStoreGroupRequest struct {
+	Name *string `json:"name"`
+}
+
+func (s *Server) createStoreGroup(c *gin.Context) {
+	var req createStoreGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.Name == nil || *req.Name == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "name is null or empty"))
+		return
+	}
+
+	if len(*req.Name) > int(s.config.MaxStoreNameLength) {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError,
+			fmt.Sprintf("name longer than %d characters", s.config.MaxStoreNameLength)))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.CreateStoreGroupParams{
+		ID:        uuid.New(),
+		Name:      *req.Name,
+		CreatedBy: authPayload.Subject,
+	}
+
+	storeGroup, err := s.store.CreateStoreGroup(c, arg)
+	if err != nil {
+		logutil.GetLogger().Errorf("create store group error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, newStoreGroupResponse(storeGroup))
+}
+
+type getStoreGroupUri struct {
+	StoreGroupID *string `uri:"store_group_id"`
+}
+
+func (s *Server) getStoreGroup(c *gin.Context) {
+	var req getStoreGroupUri
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	storeGroup, ok := s.bindStoreGroup(c, req.StoreGroupID)
+	if !ok {
+		return
+	}
+
+	storeIDs, err := s.store.GetStoreGroupStoreIDs(c, storeGroup.ID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store group store ids error, err=%s, store_group_id=%s", err, storeGroup.ID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	userIDs, err := s.store.GetStoreGroupUserIDs(c, storeGroup.ID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store group user ids error, err=%s, store_group_id=%s", err, storeGroup.ID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	storeIDStrs := make([]string, 0, len(storeIDs))
+	for _, storeID := range storeIDs {
+		storeIDStrs = append(storeIDStrs, storeID.String())
+	}
+	userIDStrs := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		userIDStrs = append(userIDStrs, userID.String())
+	}
+
+	res := newStoreGroupResponse(storeGroup)
+	res["store_ids"] = storeIDStrs
+	res["user_ids"] = userIDStrs
+	c.JSON(http.StatusOK, res)
+}
+
+// bindStoreGroup 檢查 store_group_id 並取得 store group，失敗時已回應
+func (s *Server) bindStoreGroup(c *gin.Context, storeGroupIDStr *string) (db.StoreGroup, bool) {
+	if storeGroupIDStr == nil || *storeGroupIDStr == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_group_id is null or empty"))
+		return db.StoreGroup{}, false
+	}
+
+	storeGroupID, err := uuid.Parse(*storeGroupIDStr)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreGroupNotFoundError, fmt.Sprintf("store group is not, store_group_id=%s", *storeGroupIDStr)))
+		return db.StoreGroup{}, false
+	}
+
+	storeGroup, err := s.store.GetStoreGroup(c, storeGroupID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreGroupNotFoundError, fmt.Sprintf("store group is not, store_group_id=%s", *storeGroupIDStr)))
+			return db.StoreGroup{}, false
+		}
+		logutil.GetLogger().Errorf("get store group error, err=%s, store_group_id=%s", err, storeGroupID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return db.StoreGroup{}, false
+	}
+
+	return storeGroup, true
+}
+
+type updateStoreGroupStoreUri struct {
+	StoreGroupID *string `uri:"store_group_id"`
+}
+
+type updateStoreGroupStoreRequest struct {
+	StoreID *string `json:"store_id"`
+}
+
+func (s *Server) addStoreToStoreGroup(c *gin.Context) {
+	s.updateStoreGroupStore(c, true)
+}
+
+func (s *Server) removeStoreFromStoreGroup(c *gin.Context) {
+	s.updateStoreGroupStore(c, false)
+}
+
+func (s *Server) updateStoreGroupStore(c *gin.Context, add bool) {
+	var reqUri updateStoreGroupStoreUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	var req updateStoreGroupStoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.StoreID == nil || *req.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeGroup, ok := s.bindStoreGroup(c, reqUri.StoreGroupID)
+	if !ok {
+		return
+	}
+
+	storeID, err := uuid.Parse(*req.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *req.StoreID)))
+		return
+	}
+
+	if !add {
+		arg := db.RemoveStoreFromStoreGroupParams{
+			StoreGroupID: storeGroup.ID,
+			StoreID:      storeID,
+		}
+		if err := s.store.RemoveStoreFromStoreGroup(c, arg); err != nil {
+			logutil.GetLogger().Errorf("remove store from store group error, err=%s, arg=%#v", err, arg)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	if _, err := s.store.GetStore(c, storeID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *req.StoreID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get store error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.AddStoreToStoreGroupParams{
+		StoreGroupID: storeGroup.ID,
+		StoreID:      storeID,
+		CreatedBy:    authPayload.Subject,
+	}
+	if err := s.store.AddStoreToStoreGroup(c, arg); err != nil {
+		logutil.GetLogger().Errorf("add store to store group error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type updateStoreGroupUserUri struct {
+	StoreGroupID *string `uri:"store_group_id"`
+}
+
+type updateStoreGroupUserRequest struct {
+	UserID *string `json:"user_id"`
+}
+
+func (s *Server) addUserToStoreGroup(c *gin.Context) {
+	s.updateStoreGroupUser(c, true)
+}
+
+func (s *Server) removeUserFromStoreGroup(c *gin.Context) {
+	s.updateStoreGroupUser(c, false)
+}
+
+func (s *Server) updateStoreGroupUser(c *gin.Context, add bool) {
+	var reqUri updateStoreGroupUserUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	var req updateStoreGroupUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.UserID == nil || *req.UserID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "user_id is null or empty"))
+		return
+	}
+
+	storeGroup, ok := s.bindStoreGroup(c, reqUri.StoreGroupID)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(*req.UserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeUserNotFoundError, fmt.Sprintf("user is not, user_id=%s", *req.UserID)))
+		return
+	}
+
+	if !add {
+		arg := db.RemoveUserFromStoreGroupParams{
+			StoreGroupID: storeGroup.ID,
+			UserID:       userID,
+		}
+		if err := s.store.RemoveUserFromStoreGroup(c, arg); err != nil {
+			logutil.GetLogger().Errorf("remove user from store group error, err=%s, arg=%#v", err, arg)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	if _, err := s.store.GetUser(c, userID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeUserNotFoundError, fmt.Sprintf("user is not, user_id=%s", *req.UserID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get user error, err=%s, user_id=%s", err, userID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.AddUserToStoreGroupParams{
+		StoreGroupID: storeGroup.ID,
+		UserID:       userID,
+		CreatedBy:    authPayload.Subject,
+	}
+	if err := s.store.AddUserToStoreGroup(c, arg); err != nil {
+		logutil.GetLogger().Errorf("add user to store group error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,316 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	"backend/token"
+	distlockutil "backend/util/distlock"
+	fsmutil "backend/util/fsm"
+	logutil "backend/util/log"
+	roleutil "backend/util/role"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type inviteStoreUserUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+type inviteStoreUserRequest struct {
+	PhoneNumber *string `json:"phone_number"`
+	RoleID      *int16  `json:"role_id"`
+}
+
+// inviteStoreUser 邀請已註冊的使用者成為 owner、mgr 或自訂 role，store user 會處於 pending 直到對方接受
+func (s *Server) inviteStoreUser(c *gin.Context) {
+	var reqUri inviteStoreUserUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	var req inviteStoreUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	if req.PhoneNumber == nil || *req.PhoneNumber == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "phone_number is null or empty"))
+		return
+	}
+
+	if req.RoleID == nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "role_id is null"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store not found, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	role, ok, err := s.roles.get(c, *req.RoleID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get role error, err=%s, role_id=%d", err, *req.RoleID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if !ok || (role.StoreID.Valid && role.StoreID.UUID != storeID) {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeRoleNotFoundError, fmt.Sprintf("role not found, role_id=%d", *req.RoleID)))
+		return
+	}
+
+	// 只能邀請 owner、mgr 或自訂 role，cust 請使用 join code
+	scopes := c.MustGet(authorizationScopesKey).(roleutil.Scopes)
+	switch manageableRoleName(role.ID) {
+	case roleutil.RoleOwner:
+		if !contains(scopes, roleutil.ScopeStoreUserOwnerEnable) {
+			c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, messageForbiddenError))
+			return
+		}
+	case roleutil.RoleMgr:
+		if !contains(scopes, roleutil.ScopeStoreUserMgrEnable) || !isScopesMatched(scopes, role.StoreUserScopes) {
+			c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, messageForbiddenError))
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("role is not allowed, role_id=%d", role.ID)))
+		return
+	}
+
+	user, err := s.store.GetUserByPhoneNumber(c, *req.PhoneNumber)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codePhoneNumberNotRegisterError, fmt.Sprintf("phone number is not registered, phone_number=%s", *req.PhoneNumber)))
+			return
+		}
+		logutil.GetLogger().Errorf("get user by phone number error, err=%s, phone_number=%s", err, *req.PhoneNumber)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	m := s.rs.NewMutex(distlockutil.GetStoreUserIDMutexName(storeID.String(), user.ID.String()))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), user.ID.String()))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), user.ID.String()))
+		}
+	}()
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg1 := db.GetStoreUserParams{
+		StoreID: storeID,
+		UserID:  user.ID,
+	}
+
+	storeUser, err := s.store.GetStoreUser(c, arg1)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logutil.GetLogger().Errorf("get store user error, err=%s, arg=%#v", err, arg1)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+
+		arg2 := db.CreateStoreUserWithLogParams{
+			ChangedAt:        time.Now().UnixMilli(),
+			ChangeType:       storeUserChangedTypeInvite,
+			ChangedBy:        uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+			ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+			ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+			StoreID:          storeID,
+			UserID:           user.ID,
+			RoleID:           role.ID,
+			State:            fsmutil.StoreUserStatePending,
+		}
+		if _, err := s.store.CreateStoreUserWithLog(c, arg2); err != nil {
+			logutil.GetLogger().Errorf("create store user with log error, err=%s, arg=%#v", err, arg2)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	// admin 與 hq 不可被邀請覆蓋
+	switch manageableRoleName(storeUser.RoleID) {
+	case roleutil.RoleOwner, roleutil.RoleMgr, roleutil.RoleCust:
+	default:
+		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, messageForbiddenError))
+		return
+	}
+
+	// pending 時重複邀請只會更新 role
+	storeUserFSM := fsmutil.NewStoreUserFSM(storeUser.State)
+	if err := storeUserFSM.Event(c, fsmutil.StoreUserEventInvite); err != nil {
+		switch storeUser.State {
+		case fsmutil.StoreUserStatePending:
+		case fsmutil.StoreUserStateActive:
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeStoreUserRegisteredError, "store user exists"))
+			return
+		default:
+			logutil.GetLogger().Errorf("store user fsm error, err=%s, init_state=%s, event=%s", err, storeUser.State, fsmutil.StoreUserEventInvite)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+	}
+
+	arg3 := db.SetStoreUserRoleIDAndStateWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangeType:       storeUserChangedTypeInvite,
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		StoreID:          storeID,
+		UserID:           user.ID,
+		RoleID:           role.ID,
+		State:            storeUserFSM.Current(),
+	}
+	if err := s.store.SetStoreUserRoleIDAndStateWithLog(c, arg3); err != nil {
+		logutil.GetLogger().Errorf("set store user role id and state with log error, err=%s, arg=%#v", err, arg3)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) getUserStoreInvitations(c *gin.Context) {
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	invitations, err := s.store.GetStoreUserInvitationsByUserID(c, authPayload.Subject)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store user invitations by user id error, err=%s, user_id=%s", err, authPayload.Subject)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	res := make([]gin.H, 0, len(invitations))
+	for _, invitation := range invitations {
+		role, _, err := s.roles.get(c, invitation.RoleID)
+		if err != nil {
+			logutil.GetLogger().Errorf("get role error, err=%s, role_id=%d", err, invitation.RoleID)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		res = append(res, gin.H{
+			"store_id":      invitation.StoreID.String(),
+			"store_name":    invitation.StoreName,
+			"store_address": invitation.StoreAddress,
+			"role_id":       invitation.RoleID,
+			"role":          role.Name,
+			"created_at":    invitation.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"invitations": res})
+}
+
+type acceptStoreInvitationUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+func (s *Server) acceptStoreInvitation(c *gin.Context) {
+	var req acceptStoreInvitationUri
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+	s.respondStoreInvitation(c, req.StoreID, fsmutil.StoreUserEventAcceptInvitation, storeUserChangedTypeAccept)
+}
+
+type declineStoreInvitationUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+func (s *Server) declineStoreInvitation(c *gin.Context) {
+	var req declineStoreInvitationUri
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+	s.respondStoreInvitation(c, req.StoreID, fsmutil.StoreUserEventDeclineInvitation, storeUserChangedTypeDecline)
+}
+
+// respondStoreInvitation 受邀者接受或拒絕自己的 pending 邀請
+func (s *Server) respondStoreInvitation(c *gin.Context, reqStoreID *string, event string, changeType string) {
+	if reqStoreID == nil || *reqStoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqStoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreInvitationNotFoundError, fmt.Sprintf("store invitation not found, store_id=%s", *reqStoreID)))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	m := s.rs.NewMutex(distlockutil.GetStoreUserIDMutexName(storeID.String(), authPayload.Subject.String()))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), authPayload.Subject.String()))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), authPayload.Subject.String()))
+		}
+	}()
+
+	arg1 := db.GetStoreUserParams{
+		StoreID: storeID,
+		UserID:  authPayload.Subject,
+	}
+
+	storeUser, err := s.store.GetStoreUser(c, arg1)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreInvitationNotFoundError, fmt.Sprintf("store invitation not found, store_id=%s", *reqStoreID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get store user error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	storeUserFSM := fsmutil.NewStoreUserFSM(storeUser.State)
+	if err := storeUserFSM.Event(c, event); err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreInvitationNotFoundError, fmt.Sprintf("store invitation not found, store_id=%s", *reqStoreID)))
+		return
+	}
+
+	arg2 := db.SetStoreUserStateWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangeType:       changeType,
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		StoreID:          storeID,
+		UserID:           authPayload.Subject,
+		State:            storeUserFSM.Current(),
+	}
+	if err := s.store.SetStoreUserStateWithLog(c, arg2); err != nil {
+		logutil.GetLogger().Errorf("set store user state with log error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
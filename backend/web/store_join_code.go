@@ -0,0 +1,314 @@
+package web
+
+import (
+	db "backend/db/sqlc"
+	"backend/token"
+	distlockutil "backend/util/distlock"
+	fsmutil "backend/util/fsm"
+	logutil "backend/util/log"
+	roleutil "backend/util/role"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type createStoreJoinCodeUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+type createStoreJoinCodeRequest struct {
+	MaxUses   *int32 `json:"max_uses"`
+	ExpiredAt *int64 `json:"expired_at"`
+}
+
+func (s *Server) createStoreJoinCode(c *gin.Context) {
+	var reqUri createStoreJoinCodeUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	var req createStoreJoinCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	if req.ExpiredAt == nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "expired_at is null"))
+		return
+	}
+
+	if time.Now().After(time.UnixMilli(*req.ExpiredAt)) {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "expired_at should be in the future"))
+		return
+	}
+
+	maxUses := sql.NullInt32{}
+	if req.MaxUses != nil {
+		if *req.MaxUses <= 0 {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "max_uses should be positive"))
+			return
+		}
+		maxUses = sql.NullInt32{Valid: true, Int32: *req.MaxUses}
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store not found, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
+	code, err := genStoreJoinCode(s.config.StoreJoinCodeLength)
+	if err != nil {
+		logutil.GetLogger().Errorf("gen store join code error, err=%s", err)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.CreateStoreJoinCodeParams{
+		ID:        uuid.New(),
+		StoreID:   storeID,
+		Code:      code,
+		MaxUses:   maxUses,
+		ExpiredAt: *req.ExpiredAt,
+		CreatedBy: authPayload.Subject,
+	}
+	joinCode, err := s.store.CreateStoreJoinCode(c, arg)
+	if err != nil {
+		logutil.GetLogger().Errorf("create store join code error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":   joinCode.ID.String(),
+		"code": joinCode.Code,
+	})
+}
+
+type getStoreJoinCodesUri struct {
+	StoreID *string `uri:"store_id"`
+}
+
+func (s *Server) getStoreJoinCodes(c *gin.Context) {
+	var req getStoreJoinCodesUri
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.StoreID == nil || *req.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*req.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store not found, store_id=%s", *req.StoreID)))
+		return
+	}
+
+	joinCodes, err := s.store.GetStoreJoinCodes(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store join codes error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	res := make([]gin.H, 0, len(joinCodes))
+	for _, joinCode := range joinCodes {
+		var maxUses *int32
+		if joinCode.MaxUses.Valid {
+			maxUses = &joinCode.MaxUses.Int32
+		}
+		res = append(res, gin.H{
+			"id":         joinCode.ID.String(),
+			"code":       joinCode.Code,
+			"max_uses":   maxUses,
+			"used_count": joinCode.UsedCount,
+			"expired_at": joinCode.ExpiredAt,
+			"is_revoked": joinCode.IsRevoked,
+			"created_by": joinCode.CreatedBy.String(),
+			"created_at": joinCode.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"join_codes": res})
+}
+
+type revokeStoreJoinCodeUri struct {
+	StoreID    *string `uri:"store_id"`
+	JoinCodeID *string `uri:"join_code_id"`
+}
+
+func (s *Server) revokeStoreJoinCode(c *gin.Context) {
+	var req revokeStoreJoinCodeUri
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.StoreID == nil || *req.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	if req.JoinCodeID == nil || *req.JoinCodeID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "join_code_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*req.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreJoinCodeNotFoundError, fmt.Sprintf("store join code not found, store_id=%s, join_code_id=%s", *req.StoreID, *req.JoinCodeID)))
+		return
+	}
+
+	joinCodeID, err := uuid.Parse(*req.JoinCodeID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreJoinCodeNotFoundError, fmt.Sprintf("store join code not found, store_id=%s, join_code_id=%s", *req.StoreID, *req.JoinCodeID)))
+		return
+	}
+
+	arg1 := db.GetStoreJoinCodeParams{
+		StoreID: storeID,
+		ID:      joinCodeID,
+	}
+	if _, err := s.store.GetStoreJoinCode(c, arg1); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreJoinCodeNotFoundError, fmt.Sprintf("store join code not found, store_id=%s, join_code_id=%s", *req.StoreID, *req.JoinCodeID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get store join code error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg2 := db.RevokeStoreJoinCodeParams{
+		StoreID:   storeID,
+		ID:        joinCodeID,
+		RevokedBy: uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		RevokedAt: sql.NullInt64{Valid: true, Int64: time.Now().UnixMilli()},
+	}
+	if err := s.store.RevokeStoreJoinCode(c, arg2); err != nil {
+		logutil.GetLogger().Errorf("revoke store join code error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type joinStoreByCodeRequest struct {
+	Code *string `json:"code"`
+}
+
+// joinStoreByCode member 以 join code 加入 store 成為 cust
+func (s *Server) joinStoreByCode(c *gin.Context) {
+	var req joinStoreByCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.Code == nil || *req.Code == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "code is null or empty"))
+		return
+	}
+
+	joinCode, err := s.store.GetStoreJoinCodeByCode(c, strings.ToUpper(*req.Code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidJoinCodeError, "join code is invalid"))
+			return
+		}
+		logutil.GetLogger().Errorf("get store join code by code error, err=%s", err)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if joinCode.IsRevoked || time.Now().After(time.UnixMilli(joinCode.ExpiredAt)) {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidJoinCodeError, "join code is invalid"))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	m := s.rs.NewMutex(distlockutil.GetStoreUserIDMutexName(joinCode.StoreID.String(), authPayload.Subject.String()))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(joinCode.StoreID.String(), authPayload.Subject.String()))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(joinCode.StoreID.String(), authPayload.Subject.String()))
+		}
+	}()
+
+	store, err := s.store.GetStore(c, joinCode.StoreID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get store error, err=%s, store_id=%s", err, joinCode.StoreID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if store.State != fsmutil.StoreStateActive {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidJoinCodeError, "join code is invalid"))
+		return
+	}
+
+	arg1 := db.GetStoreUserParams{
+		StoreID: joinCode.StoreID,
+		UserID:  authPayload.Subject,
+	}
+
+	if _, err := s.store.GetStoreUser(c, arg1); err != nil {
+		if err != sql.ErrNoRows {
+			logutil.GetLogger().Errorf("get store user error, err=%s, arg=%#v", err, arg1)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+	} else {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeStoreUserRegisteredError, "store user exists"))
+		return
+	}
+
+	arg2 := db.CreateStoreUserByJoinCodeWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangeType:       storeUserChangedTypeJoinByCode,
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		JoinCodeID:       joinCode.ID,
+		StoreID:          joinCode.StoreID,
+		UserID:           authPayload.Subject,
+		RoleID:           roleutil.GetRoleByName(roleutil.RoleCust).ID,
+		State:            fsmutil.InitStoreUserState,
+	}
+	if _, err := s.store.CreateStoreUserByJoinCodeWithLog(c, arg2); err != nil {
+		if err == db.ErrStoreJoinCodeUnavailable {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidJoinCodeError, "join code is invalid"))
+			return
+		}
+		logutil.GetLogger().Errorf("create store user by join code with log error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"store_id": joinCode.StoreID.String()})
+}
@@ -3,6 +3,7 @@ package web
 import (
 	db "backend/db/sqlc"
 	"backend/token"
+	auditutil "backend/util/audit"
 	distlockutil "backend/util/distlock"
 	fsmutil "backend/util/fsm"
 	logutil "backend/util/log"
@@ -24,8 +25,17 @@ const (
 	storeUserChangedTypeChangeToOwner string = "change_to_owner"
 	storeUserChangedTypeChangeToMgr   string = "change_to_mgr"
 	storeUserChangedTypeChangeToCust  string = "change_to_cust"
+	storeUserChangedTypeChangeRole    string = "change_role"
+	storeUserChangedTypeJoinByCode    string = "join_by_code"
+	storeUserChangedTypeInvite        string = "invite"
+	storeUserChangedTypeAccept        string = "accept_invitation"
+	storeUserChangedTypeDecline       string = "decline_invitation"
+	storeUserChangedTypeUpdateScopes  string = "update_scopes"
 	storeUserChangedTypeUpdateBalance string = "update_balance"
 	storeUserChangedTypeCashTopUp     string = "cash_top_up"
+	storeUserChangedTypeAdjustBalance string = "adjust_balance"
+	storeUserChangedTypeCreditLimit   string = "update_credit_limit"
+	storeUserChangedTypeTransfer      string = "transfer_balance"
 )
 
 type registerStoreUserUri struct {
@@ -50,6 +60,17 @@ func (s *Server) registerStoreUser(c *gin.Context) {
 		return
 	}
 
+	accessible, err := s.isStoreAccessible(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("check store accessible error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if !accessible {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreNotFoundError, fmt.Sprintf("store is not, store_id=%s", *reqUri.StoreID)))
+		return
+	}
+
 	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
 
 	m := s.rs.NewMutex(distlockutil.GetStoreUserIDMutexName(storeID.String(), authPayload.Subject.String()))
@@ -90,14 +111,16 @@ func (s *Server) registerStoreUser(c *gin.Context) {
 		return
 	}
 
+	// cust 須透過 join code 加入 store，請使用 joinStoreByCode
 	scopes := c.MustGet(authorizationScopesKey).(roleutil.Scopes)
-	roleName := roleutil.RoleCust
+	var roleName string
 	if contains(scopes, roleutil.ScopeStoreUserAdminRegister) {
 		roleName = roleutil.RoleAdmin
 	} else if contains(scopes, roleutil.ScopeStoreUserHqRegister) {
 		roleName = roleutil.RoleHq
-	} else if contains(scopes, roleutil.ScopeStoreUserCustRegister) {
-		roleName = roleutil.RoleCust
+	} else {
+		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, messageForbiddenError))
+		return
 	}
 
 	arg2 := db.CreateStoreUserWithLogParams{
@@ -189,9 +212,9 @@ func (s *Server) enableStoreUser(c *gin.Context) {
 	}
 
 	scopes := c.MustGet(authorizationScopesKey).(roleutil.Scopes)
-	if !((roleutil.GetRoleByID(storeUser.RoleID).Name == roleutil.RoleOwner && contains(scopes, roleutil.ScopeStoreUserOwnerEnable)) ||
-		roleutil.GetRoleByID(storeUser.RoleID).Name == roleutil.RoleMgr && contains(scopes, roleutil.ScopeStoreUserMgrEnable) ||
-		roleutil.GetRoleByID(storeUser.RoleID).Name == roleutil.RoleCust && contains(scopes, roleutil.ScopeStoreUserCustEnable)) {
+	if !((manageableRoleName(storeUser.RoleID) == roleutil.RoleOwner && contains(scopes, roleutil.ScopeStoreUserOwnerEnable)) ||
+		manageableRoleName(storeUser.RoleID) == roleutil.RoleMgr && contains(scopes, roleutil.ScopeStoreUserMgrEnable) ||
+		manageableRoleName(storeUser.RoleID) == roleutil.RoleCust && contains(scopes, roleutil.ScopeStoreUserCustEnable)) {
 		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, messageForbiddenError))
 		return
 	}
@@ -202,6 +225,9 @@ func (s *Server) enableStoreUser(c *gin.Context) {
 		case fsmutil.StoreUserStateActive:
 			c.Status(http.StatusNoContent)
 			return
+		case fsmutil.StoreUserStatePending:
+			c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, fmt.Sprintf("store user is pending, store_id=%s, user_id=%s", *req.StoreID, *req.UserID)))
+			return
 		default:
 			logutil.GetLogger().Errorf("store user fsm error, err=%s, init_state=%s, event=%s", err, storeUser.State, fsmutil.StoreUserEventEnable)
 			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
@@ -293,9 +319,9 @@ func (s *Server) deactiveStoreUser(c *gin.Context) {
 	}
 
 	scopes := c.MustGet(authorizationScopesKey).(roleutil.Scopes)
-	if !(roleutil.GetRoleByID(storeUser.RoleID).Name == roleutil.RoleOwner && contains(scopes, roleutil.ScopeStoreUserOwnerDeactive) ||
-		roleutil.GetRoleByID(storeUser.RoleID).Name == roleutil.RoleMgr && contains(scopes, roleutil.ScopeStoreUserMgrDeactive) ||
-		roleutil.GetRoleByID(storeUser.RoleID).Name == roleutil.RoleCust && contains(scopes, roleutil.ScopeStoreUserCustDeactive)) {
+	if !(manageableRoleName(storeUser.RoleID) == roleutil.RoleOwner && contains(scopes, roleutil.ScopeStoreUserOwnerDeactive) ||
+		manageableRoleName(storeUser.RoleID) == roleutil.RoleMgr && contains(scopes, roleutil.ScopeStoreUserMgrDeactive) ||
+		manageableRoleName(storeUser.RoleID) == roleutil.RoleCust && contains(scopes, roleutil.ScopeStoreUserCustDeactive)) {
 		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, messageForbiddenError))
 		return
 	}
@@ -397,8 +423,8 @@ func (s *Server) changeStoreUserToOwner(c *gin.Context) {
 	}
 
 	scopes := c.MustGet(authorizationScopesKey).(roleutil.Scopes)
-	if !(roleutil.GetRoleByID(storeUser.RoleID).Name == roleutil.RoleMgr && contains(scopes, roleutil.ScopeStoreUserMgrDeactive) ||
-		roleutil.GetRoleByID(storeUser.RoleID).Name == roleutil.RoleCust && contains(scopes, roleutil.ScopeStoreUserCustDeactive)) {
+	if !(manageableRoleName(storeUser.RoleID) == roleutil.RoleMgr && contains(scopes, roleutil.ScopeStoreUserMgrDeactive) ||
+		manageableRoleName(storeUser.RoleID) == roleutil.RoleCust && contains(scopes, roleutil.ScopeStoreUserCustDeactive)) {
 		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, messageForbiddenError))
 		return
 	}
@@ -487,8 +513,8 @@ func (s *Server) changeStoreUserToMgr(c *gin.Context) {
 	}
 
 	scopes := c.MustGet(authorizationScopesKey).(roleutil.Scopes)
-	if !(roleutil.GetRoleByID(storeUser.RoleID).Name == roleutil.RoleOwner && contains(scopes, roleutil.ScopeStoreUserOwnerDeactive) ||
-		roleutil.GetRoleByID(storeUser.RoleID).Name == roleutil.RoleCust && contains(scopes, roleutil.ScopeStoreUserCustDeactive)) {
+	if !(manageableRoleName(storeUser.RoleID) == roleutil.RoleOwner && contains(scopes, roleutil.ScopeStoreUserOwnerDeactive) ||
+		manageableRoleName(storeUser.RoleID) == roleutil.RoleCust && contains(scopes, roleutil.ScopeStoreUserCustDeactive)) {
 		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, messageForbiddenError))
 		return
 	}
@@ -577,8 +603,8 @@ func (s *Server) changeStoreUserToCust(c *gin.Context) {
 	}
 
 	scopes := c.MustGet(authorizationScopesKey).(roleutil.Scopes)
-	if !(roleutil.GetRoleByID(storeUser.RoleID).Name == roleutil.RoleOwner && contains(scopes, roleutil.ScopeStoreUserOwnerDeactive) ||
-		roleutil.GetRoleByID(storeUser.RoleID).Name == roleutil.RoleMgr && contains(scopes, roleutil.ScopeStoreUserMgrDeactive)) {
+	if !(manageableRoleName(storeUser.RoleID) == roleutil.RoleOwner && contains(scopes, roleutil.ScopeStoreUserOwnerDeactive) ||
+		manageableRoleName(storeUser.RoleID) == roleutil.RoleMgr && contains(scopes, roleutil.ScopeStoreUserMgrDeactive)) {
 		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, messageForbiddenError))
 		return
 	}
@@ -604,6 +630,130 @@ func (s *Server) changeStoreUserToCust(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+type changeStoreUserRoleUri struct {
+	StoreID *string `uri:"store_id"`
+	UserID  *string `uri:"user_id"`
+}
+
+type changeStoreUserRoleRequest struct {
+	RoleID *int16 `json:"role_id"`
+}
+
+// changeStoreUserRole 將 mgr、cust 或自訂 role 的 store user 改為自訂 role
+func (s *Server) changeStoreUserRole(c *gin.Context) {
+	var reqUri changeStoreUserRoleUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	var req changeStoreUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	if reqUri.UserID == nil || *reqUri.UserID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "user_id is null or empty"))
+		return
+	}
+
+	if req.RoleID == nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "role_id is null"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+		return
+	}
+
+	userID, err := uuid.Parse(*reqUri.UserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+		return
+	}
+
+	// 內建 role 請使用 change-to-owner/mgr/cust
+	role, ok, err := s.roles.get(c, *req.RoleID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get role error, err=%s, role_id=%d", err, *req.RoleID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if !ok || role.IsBuiltIn || (role.StoreID.Valid && role.StoreID.UUID != storeID) {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeRoleNotFoundError, fmt.Sprintf("role not found, role_id=%d", *req.RoleID)))
+		return
+	}
+
+	// 不可授予超過自己的 scopes
+	scopes := c.MustGet(authorizationScopesKey).(roleutil.Scopes)
+	if !isScopesMatched(scopes, role.StoreUserScopes) {
+		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, messageForbiddenError))
+		return
+	}
+
+	m := s.rs.NewMutex(distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+		}
+	}()
+
+	arg1 := db.GetStoreUserParams{
+		StoreID: storeID,
+		UserID:  userID,
+	}
+
+	storeUser, err := s.store.GetStoreUser(c, arg1)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get store user error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if !(manageableRoleName(storeUser.RoleID) == roleutil.RoleMgr && contains(scopes, roleutil.ScopeStoreUserMgrDeactive) ||
+		manageableRoleName(storeUser.RoleID) == roleutil.RoleCust && contains(scopes, roleutil.ScopeStoreUserCustDeactive)) {
+		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, messageForbiddenError))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg2 := db.SetStoreUserRoleIDWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangeType:       storeUserChangedTypeChangeRole,
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		StoreID:          storeID,
+		UserID:           userID,
+		RoleID:           role.ID,
+	}
+	if err := s.store.SetStoreUserRoleIDWithLog(c, arg2); err != nil {
+		logutil.GetLogger().Errorf("set store user role id with log error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 type getStoreUserBalanceUri struct {
 	StoreID *string `uri:"store_id"`
 	UserID  *string `uri:"user_id"`
@@ -663,11 +813,34 @@ func (s *Server) getStoreUserBalance(c *gin.Context) {
 		return
 	}
 
+	// 商店未參與品牌錢包時 brand_wallet_balance 為 null
+	var brandWalletBalance *int64
+	storeGroupID, ok, err := s.getBrandWalletStoreGroupID(c, storeID)
+	if err != nil {
+		logutil.GetLogger().Errorf("get brand wallet store error, err=%s, store_id=%s", err, storeID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if ok {
+		arg2 := db.GetBrandWalletBalanceParams{
+			StoreGroupID: storeGroupID,
+			UserID:       userID,
+		}
+		balance, err := s.store.GetBrandWalletBalance(c, arg2)
+		if err != nil {
+			logutil.GetLogger().Errorf("get brand wallet balance error, err=%s, arg=%#v", err, arg2)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		brandWalletBalance = &balance
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"balance":         storeUser.Balance,
-		"points":          storeUser.Points,
-		"balance_earmark": storeUser.BalanceEarmark,
-		"points_earmark":  storeUser.PointsEarmark,
+		"balance":              storeUser.Balance,
+		"points":               storeUser.Points,
+		"balance_earmark":      storeUser.BalanceEarmark,
+		"points_earmark":       storeUser.PointsEarmark,
+		"brand_wallet_balance": brandWalletBalance,
 	})
 }
 
@@ -766,7 +939,7 @@ func (s *Server) getStoreUserRecords(c *gin.Context) {
 	records := make([]gin.H, 0, len(storeUserRecords))
 	for _, record := range storeUserRecords {
 		switch record.Type {
-		case db.RecordTypeCashTopUp:
+		case db.RecordTypeCashTopUp, db.RecordTypeBrandWalletCashTopUp:
 			records = append(records, gin.H{
 				"type":                 record.Type,
 				"created_by_user_id":   record.CreatedByUserID.UUID,
@@ -777,7 +950,7 @@ func (s *Server) getStoreUserRecords(c *gin.Context) {
 				"points_amount":        record.PointAmount.Int32,
 				"ts":                   record.Ts,
 			})
-		case db.RecordTypeCoinAcceptorRemoteInsertCoins:
+		case db.RecordTypeCoinAcceptorRemoteInsertCoins, db.RecordTypeBrandWalletInsertCoins:
 			records = append(records, gin.H{
 				"type":                record.Type,
 				"device_id":           record.DeviceID.String,
@@ -788,6 +961,27 @@ func (s *Server) getStoreUserRecords(c *gin.Context) {
 				"point_amount":        record.PointAmount.Int32,
 				"ts":                  record.Ts,
 			})
+		case db.RecordTypeBalanceAdjustment:
+			records = append(records, gin.H{
+				"type":                 record.Type,
+				"created_by_user_id":   record.CreatedByUserID.UUID,
+				"created_by_user_name": record.CreatedByUserName.String,
+				"user_id":              record.UserID.UUID,
+				"user_name":            record.UserName.String,
+				"amount":               record.Amount,
+				"point_amount":         record.PointAmount.Int32,
+				"ts":                   record.Ts,
+			})
+		case db.RecordTypeBalanceTransfer:
+			records = append(records, gin.H{
+				"type":                 record.Type,
+				"created_by_user_id":   record.CreatedByUserID.UUID,
+				"created_by_user_name": record.CreatedByUserName.String,
+				"user_id":              record.UserID.UUID,
+				"user_name":            record.UserName.String,
+				"amount":               record.Amount,
+				"ts":                   record.Ts,
+			})
 		default:
 			logutil.GetLogger().Warnf("unknown store user record type error, store_id=%s, user_id=%s, type=%s", storeID, userID, record.Type)
 		}
@@ -796,6 +990,150 @@ func (s *Server) getStoreUserRecords(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"records": records})
 }
 
+type getStoreUserWalletAtUri struct {
+	StoreID *string `uri:"store_id"`
+	UserID  *string `uri:"user_id"`
+}
+
+type getStoreUserWalletAtQuery struct {
+	Ts     *int64 `form:"ts"`
+	Window *int64 `form:"window"`
+}
+
+func (s *Server) getStoreUserWalletAt(c *gin.Context) {
+	var reqUri getStoreUserWalletAtUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	if reqUri.UserID == nil || *reqUri.UserID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "user_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+		return
+	}
+
+	userID, err := uuid.Parse(*reqUri.UserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+		return
+	}
+
+	var reqQuery getStoreUserWalletAtQuery
+	if err := c.ShouldBindQuery(&reqQuery); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqQuery.Ts == nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "ts is null"))
+		return
+	}
+
+	// ts 前後各 window 毫秒內的變動與交易紀錄
+	maxWindow := s.config.StoreUserWalletWindow.Milliseconds()
+	window := maxWindow
+	if reqQuery.Window != nil {
+		if *reqQuery.Window <= 0 || *reqQuery.Window > maxWindow {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("window should be between 1 and %d", maxWindow)))
+			return
+		}
+		window = *reqQuery.Window
+	}
+
+	arg1 := db.GetStoreUserWalletAtParams{
+		StoreID: storeID,
+		UserID:  userID,
+		Ts:      *reqQuery.Ts,
+		FromTs:  *reqQuery.Ts - window,
+		ToTs:    *reqQuery.Ts + window + 1,
+	}
+
+	wallet, err := s.store.GetStoreUserWalletAt(c, arg1)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError,
+				fmt.Sprintf("store user not found at ts, store_id=%s, user_id=%s, ts=%d", *reqUri.StoreID, *reqUri.UserID, *reqQuery.Ts)))
+			return
+		}
+		logutil.GetLogger().Errorf("get store user wallet at error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	// 第一筆變動須與 window 之前的快照比較
+	var prevFields []auditutil.Field
+	if len(wallet.Histories) > 0 {
+		arg2 := db.GetPreviousStoreUsersHistoryParams{
+			StoreID:          storeID,
+			UserID:           userID,
+			ChangedAt:        wallet.Histories[0].ChangedAt,
+			HistoryCreatedAt: wallet.Histories[0].HistoryCreatedAt,
+		}
+		prev, err := s.store.GetPreviousStoreUsersHistory(c, arg2)
+		if err != nil && err != sql.ErrNoRows {
+			logutil.GetLogger().Errorf("get previous store users history error, err=%s, arg=%#v", err, arg2)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		if err == nil {
+			prevFields = storeUsersHistoryFields(prev)
+		}
+	}
+
+	changes := make([]gin.H, 0, len(wallet.Histories))
+	for _, h := range wallet.Histories {
+		fields := storeUsersHistoryFields(h)
+		entry := newAuditEntry(h.ChangedAt, h.ChangedType, h.ChangedBy, h.ChangedUserAgent, h.ChangedClientIp)
+		entry["balance"] = h.Balance
+		entry["points"] = h.Points
+		entry["balance_earmark"] = h.BalanceEarmark
+		entry["points_earmark"] = h.PointsEarmark
+		entry["changes"] = auditutil.Diff(prevFields, fields)
+		changes = append(changes, entry)
+		prevFields = fields
+	}
+
+	records := make([]gin.H, 0, len(wallet.Records))
+	for _, record := range wallet.Records {
+		records = append(records, gin.H{
+			"type":                record.Type,
+			"created_by_user_id":  record.CreatedBy.UUID,
+			"record_id":           record.RecordID.String,
+			"device_id":           record.DeviceID.String,
+			"from_online_payment": record.FromOnlinePayment.String,
+			"amount":              record.Amount,
+			"point_amount":        record.PointAmount.Int32,
+			"ts":                  record.Ts,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ts":                  *reqQuery.Ts,
+		"snapshot_changed_at": wallet.Snapshot.ChangedAt,
+		"balance":             wallet.Snapshot.Balance,
+		"points":              wallet.Snapshot.Points,
+		"balance_earmark":     wallet.Snapshot.BalanceEarmark,
+		"points_earmark":      wallet.Snapshot.PointsEarmark,
+		"role_id":             wallet.Snapshot.RoleID,
+		"state":               wallet.Snapshot.State,
+		"from":                arg1.FromTs,
+		"to":                  arg1.ToTs,
+		"histories":           changes,
+		"records":             records,
+	})
+}
+
 type getStoreUsersUri struct {
 	StoreID *string `uri:"store_id"`
 }
@@ -837,12 +1175,19 @@ func (s *Server) getStoreUsers(c *gin.Context) {
 
 	res := make([]gin.H, 0, len(storeUsers))
 	for _, storeUser := range storeUsers {
+		role, _, err := s.roles.get(c, storeUser.RoleID)
+		if err != nil {
+			logutil.GetLogger().Errorf("get role error, err=%s, role_id=%d", err, storeUser.RoleID)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
 		res = append(res, gin.H{
 			"id":           storeUser.ID.String(),
 			"phone_number": storeUser.PhoneNumber,
 			"name":         storeUser.Name,
 			"state":        storeUser.State,
-			"role":         roleutil.GetRoleByID(storeUser.RoleID).Name,
+			"role":         role.Name,
+			"role_id":      storeUser.RoleID,
 		})
 	}
 	c.JSON(http.StatusOK, gin.H{"users": res})
@@ -994,3 +1339,191 @@ func (s *Server) assistCustCashTopUp(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+type getStoreUserScopeOverrideUri struct {
+	StoreID *string `uri:"store_id"`
+	UserID  *string `uri:"user_id"`
+}
+
+func (s *Server) getStoreUserScopeOverride(c *gin.Context) {
+	var req getStoreUserScopeOverrideUri
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.StoreID == nil || *req.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	if req.UserID == nil || *req.UserID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "user_id is null or empty"))
+		return
+	}
+
+	storeID, err := uuid.Parse(*req.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *req.StoreID, *req.UserID)))
+		return
+	}
+
+	userID, err := uuid.Parse(*req.UserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *req.StoreID, *req.UserID)))
+		return
+	}
+
+	arg := db.GetStoreUserScopeOverrideParams{
+		StoreID: storeID,
+		UserID:  userID,
+	}
+
+	override, err := s.store.GetStoreUserScopeOverride(c, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusOK, gin.H{
+				"granted_scopes": []string{},
+				"denied_scopes":  []string{},
+			})
+			return
+		}
+		logutil.GetLogger().Errorf("get store user scope override error, err=%s, arg=%#v", err, arg)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"granted_scopes": override.GrantedScopes,
+		"denied_scopes":  override.DeniedScopes,
+	})
+}
+
+type updateStoreUserScopeOverrideUri struct {
+	StoreID *string `uri:"store_id"`
+	UserID  *string `uri:"user_id"`
+}
+
+type updateStoreUserScopeOverrideRequest struct {
+	GrantedScopes []string `json:"granted_scopes"`
+	DeniedScopes  []string `json:"denied_scopes"`
+}
+
+// updateStoreUserScopeOverride 設定 mgr、cust 或自訂 role 的 store user 額外授予或禁止的 scopes
+func (s *Server) updateStoreUserScopeOverride(c *gin.Context) {
+	var reqUri updateStoreUserScopeOverrideUri
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	var req updateStoreUserScopeOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if reqUri.StoreID == nil || *reqUri.StoreID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "store_id is null or empty"))
+		return
+	}
+
+	if reqUri.UserID == nil || *reqUri.UserID == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "user_id is null or empty"))
+		return
+	}
+
+	if req.GrantedScopes == nil {
+		req.GrantedScopes = []string{}
+	}
+	if req.DeniedScopes == nil {
+		req.DeniedScopes = []string{}
+	}
+
+	// 不可授予超過自己的 scopes
+	scopes := c.MustGet(authorizationScopesKey).(roleutil.Scopes)
+	for _, scope := range req.GrantedScopes {
+		if !contains(roleutil.StoreUserScopes, scope) || !contains(scopes, scope) {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("granted scope is not allowed, scope=%s", scope)))
+			return
+		}
+	}
+	for _, scope := range req.DeniedScopes {
+		if !contains(roleutil.StoreUserScopes, scope) {
+			c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, fmt.Sprintf("denied scope is not allowed, scope=%s", scope)))
+			return
+		}
+	}
+
+	storeID, err := uuid.Parse(*reqUri.StoreID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+		return
+	}
+
+	userID, err := uuid.Parse(*reqUri.UserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+		return
+	}
+
+	// 不可修改自己的 scopes
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	if userID == authPayload.Subject {
+		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, messageForbiddenError))
+		return
+	}
+
+	m := s.rs.NewMutex(distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetStoreUserIDMutexName(storeID.String(), userID.String()))
+		}
+	}()
+
+	arg1 := db.GetStoreUserParams{
+		StoreID: storeID,
+		UserID:  userID,
+	}
+
+	storeUser, err := s.store.GetStoreUser(c, arg1)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, newErrorResponse(codeStoreUserNotFoundError, fmt.Sprintf("store user not found, store_id=%s, user_id=%s", *reqUri.StoreID, *reqUri.UserID)))
+			return
+		}
+		logutil.GetLogger().Errorf("get store user error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if !(manageableRoleName(storeUser.RoleID) == roleutil.RoleMgr && contains(scopes, roleutil.ScopeStoreUserMgrEnable) ||
+		manageableRoleName(storeUser.RoleID) == roleutil.RoleCust && contains(scopes, roleutil.ScopeStoreUserCustEnable)) {
+		c.JSON(http.StatusForbidden, newErrorResponse(codeForbiddenError, messageForbiddenError))
+		return
+	}
+
+	arg2 := db.SetStoreUserScopeOverrideWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangeType:       storeUserChangedTypeUpdateScopes,
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: authPayload.Subject},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		StoreID:          storeID,
+		UserID:           userID,
+		GrantedScopes:    req.GrantedScopes,
+		DeniedScopes:     req.DeniedScopes,
+	}
+	if _, err := s.store.SetStoreUserScopeOverrideWithLog(c, arg2); err != nil {
+		logutil.GetLogger().Errorf("set store user scope override with log error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
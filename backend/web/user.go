@@ -28,6 +28,8 @@ const (
 	userChangedTypeResetPassword           string = "reset_password"
 	userChangedTypeChangePassword          string = "change_password"
 	userChangedTypeUpdateInfo              string = "update_info"
+	userChangedTypeChangePhoneNumber       string = "change_phone_number"
+	userChangedTypeLinkExternalIdentity    string = "link_external_identity"
 )
 
 type sendCheckPhoneNumberOwnerMsgRequest struct {
@@ -297,8 +299,8 @@ func (s *Server) registerUser(c *gin.Context) {
 		return
 	}
 
-	if err := passwordutil.DoesPasswordMeetRule(*req.Password); err != nil {
-		c.JSON(http.StatusBadRequest, newErrorResponse(codeWeakPasswordError, "password is weak"))
+	if code, message, ok := s.checkPasswordPolicy(*req.Password); !ok {
+		c.JSON(http.StatusBadRequest, newErrorResponse(code, message))
 		return
 	}
 
@@ -491,18 +493,30 @@ func (s *Server) loginUser(c *gin.Context) {
 		}
 	}
 
-	accessToken, accessPayload, err := s.tokenMaker.CreateToken(user.ID, s.config.Token.AccessTokenDuration)
+	accessToken, refreshToken, err := s.createUserTokens(c, user.ID)
 	if err != nil {
-		logutil.GetLogger().Errorf("create access token error, err=%s, user_id=%s", err, user.ID)
+		logutil.GetLogger().Errorf("create user tokens error, err=%s, user_id=%s", err, user.ID)
 		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
 		return
 	}
 
-	refreshToken, refreshPayload, err := s.tokenMaker.CreateToken(user.ID, s.config.Token.RefreshTokenDuration)
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"token_type":    authorizationTypeBearer,
+	})
+}
+
+// createUserTokens 產生 access token 與 refresh token 並存入 db
+func (s *Server) createUserTokens(c *gin.Context, userID uuid.UUID) (string, string, error) {
+	accessToken, accessPayload, err := s.tokenMaker.CreateToken(userID, s.config.Token.AccessTokenDuration)
 	if err != nil {
-		logutil.GetLogger().Errorf("create refresh token error, err=%s, user_id=%s", err, user.ID)
-		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
-		return
+		return "", "", err
+	}
+
+	refreshToken, refreshPayload, err := s.tokenMaker.CreateToken(userID, s.config.Token.RefreshTokenDuration)
+	if err != nil {
+		return "", "", err
 	}
 
 	arg := db.CreateTokenParams{
@@ -515,9 +529,7 @@ func (s *Server) loginUser(c *gin.Context) {
 		IssuedAt:  accessPayload.IssuedAt,
 	}
 	if _, err := s.store.CreateToken(c, arg); err != nil {
-		logutil.GetLogger().Errorf("create access token error, err=%s, arg=%#v", err, arg)
-		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
-		return
+		return "", "", err
 	}
 
 	arg = db.CreateTokenParams{
@@ -530,16 +542,10 @@ func (s *Server) loginUser(c *gin.Context) {
 		IssuedAt:  refreshPayload.IssuedAt,
 	}
 	if _, err := s.store.CreateToken(c, arg); err != nil {
-		logutil.GetLogger().Errorf("create refresh token error, err=%s, arg=%#v", err, arg)
-		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
-		return
+		return "", "", err
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
-		"token_type":    authorizationTypeBearer,
-	})
+	return accessToken, refreshToken, nil
 }
 
 type renewAccessTokenParams struct {
@@ -624,8 +630,8 @@ func (s *Server) resetUserPassword(c *gin.Context) {
 		return
 	}
 
-	if err := passwordutil.DoesPasswordMeetRule(*req.NewPassword); err != nil {
-		c.JSON(http.StatusBadRequest, newErrorResponse(codeWeakPasswordError, "new password is weak"))
+	if code, message, ok := s.checkPasswordPolicy(*req.NewPassword); !ok {
+		c.JSON(http.StatusBadRequest, newErrorResponse(code, "new "+message))
 		return
 	}
 
@@ -705,6 +711,18 @@ func (s *Server) resetUserPassword(c *gin.Context) {
 		return
 	}
 
+	// 檢查新密碼是否與最近使用過的密碼重複
+	reused, err := s.isPasswordRecentlyUsed(c, user.ID, *req.NewPassword)
+	if err != nil {
+		logutil.GetLogger().Errorf("check password recently used error, err=%s, user_id=%s", err, user.ID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if reused {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codePasswordReusedError, "new password was used recently"))
+		return
+	}
+
 	// block 合法的 ver code
 	if err := s.store.BlockVerCodes(c, validCode.ID); err != nil {
 		logutil.GetLogger().Errorf("block ver code error, err=%s, id=%s", err, validCode.ID)
@@ -850,8 +868,8 @@ func (s *Server) changeUserPassword(c *gin.Context) {
 		return
 	}
 
-	if err := passwordutil.DoesPasswordMeetRule(*req.NewPassword); err != nil {
-		c.JSON(http.StatusBadRequest, newErrorResponse(codeWeakPasswordError, "new password is weak"))
+	if code, message, ok := s.checkPasswordPolicy(*req.NewPassword); !ok {
+		c.JSON(http.StatusBadRequest, newErrorResponse(code, "new "+message))
 		return
 	}
 
@@ -904,6 +922,18 @@ func (s *Server) changeUserPassword(c *gin.Context) {
 		return
 	}
 
+	// 檢查新密碼是否與最近使用過的密碼重複
+	reused, err := s.isPasswordRecentlyUsed(c, user.ID, *req.NewPassword)
+	if err != nil {
+		logutil.GetLogger().Errorf("check password recently used error, err=%s, user_id=%s", err, user.ID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	if reused {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codePasswordReusedError, "new password was used recently"))
+		return
+	}
+
 	hashedPassword, err := passwordutil.HashPassword(*req.NewPassword)
 	if err != nil {
 		logutil.GetLogger().Errorf("hash password error, err=%s", err)
@@ -931,3 +961,221 @@ func (s *Server) changeUserPassword(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+type sendChangePhoneNumberMsgRequest struct {
+	NewPhoneNumber *string `json:"new_phone_number"`
+}
+
+func (s *Server) sendChangePhoneNumberMsg(c *gin.Context) {
+	// TODO: rate limit
+	var req sendChangePhoneNumberMsgRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.NewPhoneNumber == nil || *req.NewPhoneNumber == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "new_phone_number is null or empty"))
+		return
+	}
+
+	if len(*req.NewPhoneNumber) != 10 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "new_phone_number is not 10 characters"))
+		return
+	}
+
+	if !strings.HasPrefix(*req.NewPhoneNumber, "09") {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "new_phone_number should start from 09"))
+		return
+	}
+
+	m := s.rs.NewMutex(distlockutil.GetUserPhoneNumberMutexName(*req.NewPhoneNumber))
+	if err := m.Lock(); err != nil {
+		logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, distlockutil.GetUserPhoneNumberMutexName(*req.NewPhoneNumber))
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+	defer func() {
+		if ok, err := m.Unlock(); !ok || err != nil {
+			logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, distlockutil.GetUserPhoneNumberMutexName(*req.NewPhoneNumber))
+		}
+	}()
+
+	if _, err := s.store.GetUserByPhoneNumber(c, *req.NewPhoneNumber); err != nil {
+		if err != sql.ErrNoRows {
+			logutil.GetLogger().Errorf("get user by phone number error, err=%s, phone_number=%s", err, *req.NewPhoneNumber)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+	} else {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codePhoneNumberRegisteredError, "phone number exists"))
+		return
+	}
+
+	arg1 := db.GetVerCodesByTypeAndPhoneNumberParams{
+		Type:        verCodeTypeChangePhoneNumber,
+		PhoneNumber: *req.NewPhoneNumber,
+		FromTs:      time.Now().Add(-s.config.VerCode.ChangePhoneNumber.TimePeriod).UnixMilli(),
+	}
+	codes, err := s.store.GetVerCodesByTypeAndPhoneNumber(c, arg1)
+	if err != nil {
+		logutil.GetLogger().Errorf("get ver code by phone number and type error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if len(codes) >= s.config.VerCode.ChangePhoneNumber.MaxMsgPerTimePeriod {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeSendChangePhoneNumberMsgMeetLimitError,
+			fmt.Sprintf("phone number change SMS limit has been reached, phone_number=%s", *req.NewPhoneNumber)))
+		return
+	}
+
+	newCode := randomutil.RandomNumString(s.config.VerCode.ChangePhoneNumber.Length)
+
+	// TODO: 呼叫簡訊業者 API 寄出 ver code
+
+	arg2 := db.CreateVerCodeParams{
+		ID:          uuid.New(),
+		PhoneNumber: *req.NewPhoneNumber,
+		Code:        newCode,
+		Type:        verCodeTypeChangePhoneNumber,
+		RequestID:   "", // TODO: 簡訊業者 API 回傳的 RequestID
+		ExpiredAt:   time.Now().Add(s.config.VerCode.ChangePhoneNumber.LiveTime).UnixMilli(),
+	}
+
+	if _, err = s.store.CreateVerCode(c, arg2); err != nil {
+		logutil.GetLogger().Errorf("create ver code error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type changeUserPhoneNumberParams struct {
+	NewPhoneNumber *string `json:"new_phone_number"`
+	VerCode        *string `json:"ver_code"`
+}
+
+func (s *Server) changeUserPhoneNumber(c *gin.Context) {
+	var req changeUserPhoneNumberParams
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, messageWrongRequestPayload))
+		return
+	}
+
+	if req.NewPhoneNumber == nil || *req.NewPhoneNumber == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "new_phone_number is null or empty"))
+		return
+	}
+
+	if req.VerCode == nil || *req.VerCode == "" {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "ver_code is null or empty"))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := s.store.GetUser(c, authPayload.Subject)
+	if err != nil {
+		logutil.GetLogger().Errorf("get user error, err=%s, user=%s", err, authPayload.Subject)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	if user.PhoneNumber == *req.NewPhoneNumber {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeInvalidParameterError, "new phone number is the same as old phone number"))
+		return
+	}
+
+	// 依固定順序鎖定新舊手機號碼，避免兩個使用者互換號碼時發生 deadlock
+	phoneNumbers := []string{user.PhoneNumber, *req.NewPhoneNumber}
+	if phoneNumbers[0] > phoneNumbers[1] {
+		phoneNumbers[0], phoneNumbers[1] = phoneNumbers[1], phoneNumbers[0]
+	}
+	for _, phoneNumber := range phoneNumbers {
+		mutexName := distlockutil.GetUserPhoneNumberMutexName(phoneNumber)
+		m := s.rs.NewMutex(mutexName)
+		if err := m.Lock(); err != nil {
+			logutil.GetLogger().Errorf("lock error, err=%s, mutex_name=%s", err, mutexName)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+		defer func() {
+			if ok, err := m.Unlock(); !ok || err != nil {
+				logutil.GetLogger().Errorf("unlock error, err=%s, mutex_name=%s", err, mutexName)
+			}
+		}()
+	}
+
+	user, err = s.store.GetUser(c, authPayload.Subject)
+	if err != nil {
+		logutil.GetLogger().Errorf("get user error, err=%s, user=%s", err, authPayload.Subject)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	// 檢查 phone number 是否被申請過
+	if _, err := s.store.GetUserByPhoneNumber(c, *req.NewPhoneNumber); err != nil {
+		if err != sql.ErrNoRows {
+			logutil.GetLogger().Errorf("get user by phone number error, err=%s, phone_number=%s", err, *req.NewPhoneNumber)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+			return
+		}
+	} else {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codePhoneNumberRegisteredError, "phone number exists"))
+		return
+	}
+
+	// 檢查 ver code 是否合法
+	arg1 := db.GetVerCodesByTypeAndPhoneNumberAndCodeParams{
+		Type:        verCodeTypeChangePhoneNumber,
+		PhoneNumber: *req.NewPhoneNumber,
+		Code:        *req.VerCode,
+	}
+
+	codes, err := s.store.GetVerCodesByTypeAndPhoneNumberAndCode(c, arg1)
+	if err != nil {
+		logutil.GetLogger().Errorf("get ver codes by type, phone number and code error, err=%s, arg=%#v", err, arg1)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	var validCode *db.VerCode
+	for _, code := range codes {
+		if isVerCodeValid(code) {
+			validCode = &code
+			break
+		}
+	}
+
+	if validCode == nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(codeWrongVerCodeError, "verification code is incorrect"))
+		return
+	}
+
+	// block 合法的 ver code
+	if err := s.store.BlockVerCodes(c, validCode.ID); err != nil {
+		logutil.GetLogger().Errorf("block ver code error, err=%s, id=%s", err, validCode.ID)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	// 更新手機號碼並撤銷所有 token，使用者需以新號碼重新登入
+	arg2 := db.SetUserPhoneNumberWithLogParams{
+		ChangedAt:        time.Now().UnixMilli(),
+		ChangeType:       userChangedTypeChangePhoneNumber,
+		ChangedBy:        uuid.NullUUID{Valid: true, UUID: user.ID},
+		ChangedUserAgent: sql.NullString{Valid: true, String: c.Request.UserAgent()},
+		ChangedClientIp:  sql.NullString{Valid: true, String: c.ClientIP()},
+		ID:               user.ID,
+		PhoneNumber:      *req.NewPhoneNumber,
+	}
+	if err := s.store.SetUserPhoneNumberWithLog(c, arg2); err != nil {
+		logutil.GetLogger().Errorf("set user phone number with log error, err=%s, arg=%#v", err, arg2)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(codeInternalError, messageServerInternalError))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -2,7 +2,18 @@ package web
 
 import (
 	db "backend/db/sqlc"
+	passwordutil "backend/util/password"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 func isVerCodeValid(code db.VerCode) bool {
@@ -21,12 +32,150 @@ func dtoRecordType2DbRecordType(_type string) []string {
 	if _type == "all" || _type == "top-up" {
 		types = append(types,
 			db.RecordTypeCashTopUp,
+			db.RecordTypeBrandWalletCashTopUp,
 		)
 	}
 	if _type == "all" || _type == "device" {
 		types = append(types,
 			db.RecordTypeCoinAcceptorRemoteInsertCoins,
+			db.RecordTypeBrandWalletInsertCoins,
+		)
+	}
+	if _type == "all" || _type == "adjustment" {
+		types = append(types,
+			db.RecordTypeBalanceAdjustment,
+		)
+	}
+	if _type == "all" || _type == "transfer" {
+		types = append(types,
+			db.RecordTypeBalanceTransfer,
 		)
 	}
 	return types
 }
+
+// checkPasswordPolicy 檢查密碼是否符合 config 中的密碼政策，不符合時回傳對應的 error code 與 message
+func (s *Server) checkPasswordPolicy(password string) (code string, message string, ok bool) {
+	policy := passwordutil.Policy{
+		MinLength:     s.config.PasswordPolicy.MinLength,
+		RequireUpper:  s.config.PasswordPolicy.RequireUpper,
+		RequireLower:  s.config.PasswordPolicy.RequireLower,
+		RequireDigit:  s.config.PasswordPolicy.RequireDigit,
+		RequireSymbol: s.config.PasswordPolicy.RequireSymbol,
+		DenyList:      s.config.PasswordPolicy.DenyList,
+	}
+
+	switch err := policy.Check(password); err {
+	case nil:
+		return "", "", true
+	case passwordutil.ErrInvalidPasswordLength:
+		return codePasswordTooShortError, fmt.Sprintf("password shorter than %d characters", policy.MinLength), false
+	case passwordutil.ErrPasswordMissingUpper, passwordutil.ErrPasswordMissingLower,
+		passwordutil.ErrPasswordMissingDigit, passwordutil.ErrPasswordMissingSymbol:
+		return codePasswordMissingCharClassError, err.Error(), false
+	case passwordutil.ErrPasswordIsCommonPassword:
+		return codeCommonPasswordError, "password is too common", false
+	default:
+		return codeWeakPasswordError, "password is weak", false
+	}
+}
+
+// isPasswordRecentlyUsed 檢查密碼是否與使用者最近 N 組密碼重複 (N = config.PasswordPolicy.HistoryCount)
+func (s *Server) isPasswordRecentlyUsed(ctx context.Context, userID uuid.UUID, password string) (bool, error) {
+	if s.config.PasswordPolicy.HistoryCount <= 0 {
+		return false, nil
+	}
+
+	arg := db.GetUserRecentPasswordsParams{
+		UserID: userID,
+		Limit:  s.config.PasswordPolicy.HistoryCount,
+	}
+	hashedPasswords, err := s.store.GetUserRecentPasswords(ctx, arg)
+	if err != nil {
+		return false, err
+	}
+	return passwordutil.IsPasswordReused(password, hashedPasswords), nil
+}
+
+const apiKeyPrefix = "slk_"
+
+// genApiKey 產生新的 api key，db 中只保存 hashApiKey 的結果
+func genApiKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(b), nil
+}
+
+func hashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// joinCodeAlphabet 排除容易混淆的 0、O、1、I、L
+const joinCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// genStoreJoinCode 產生 store join code，只含大寫英數字以便製作 QR code 與人工輸入
+func genStoreJoinCode(length int) (string, error) {
+	b := make([]byte, length)
+	max := big.NewInt(int64(len(joinCodeAlphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = joinCodeAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// isValidIPOrCIDR 檢查是否為合法的 IP 或 CIDR，例如 203.0.113.5 或 203.0.113.0/24
+func isValidIPOrCIDR(s string) bool {
+	if net.ParseIP(s) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(s)
+	return err == nil
+}
+
+func isClientIPAllowed(allowedIPs []string, clientIP string) bool {
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+	for _, allowed := range allowedIPs {
+		if _, ipNet, err := net.ParseCIDR(allowed); err == nil {
+			if ipNet.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if allowedIP := net.ParseIP(allowed); allowedIP != nil && allowedIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// nullUUIDValue 將 nullable 欄位轉成 nil 或實際值，用於 json 回應與 audit 欄位
+func nullUUIDValue(v uuid.NullUUID) any {
+	if !v.Valid {
+		return nil
+	}
+	return v.UUID.String()
+}
+
+func nullStringValue(v sql.NullString) any {
+	if !v.Valid {
+		return nil
+	}
+	return v.String
+}
+
+func nullFloat64Value(v sql.NullFloat64) any {
+	if !v.Valid {
+		return nil
+	}
+	return v.Float64
+}
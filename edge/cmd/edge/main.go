@@ -45,6 +45,7 @@ func main() {
 	store := db.NewStore(conn)
 
 	deviceMapService := edge.NewDeviceMapService()
+	diagnosticsService := edge.NewDiagnosticsService(store, deviceMapService, config.Diagnostics.DiskPath, config.Diagnostics.MaxUnuploadedRecords)
 
 	iotContainer := &edge.IotContainer{}
 
@@ -60,7 +61,7 @@ func main() {
 				defer conn.Close()
 				logutil.GetLogger().Infof("connect to iot backend, url=%s", config.Iot.Url)
 
-				iot := edge.NewIot(systemInfo, deviceMapService, conn)
+				iot := edge.NewIot(systemInfo, deviceMapService, diagnosticsService, conn)
 
 				done := make(chan struct{}, 1)
 				go func() {
@@ -99,6 +100,7 @@ func main() {
 	opts.SetPassword(config.Mosquitto.Password)
 	opts.OnConnect = func(client mqtt.Client) {
 		logutil.GetLogger().Infof("connect to mosquitto, url=%s, client_id=%s", config.Mosquitto.Url, config.StoreID)
+		diagnosticsService.SetMqttConnected(true)
 		client.Subscribe(edge.CoinAcceptorEventKey, 2, func(c mqtt.Client, msg mqtt.Message) {
 			m3 := edge.MessageType3[edge.MqttEvent]{}
 			err := json.Unmarshal(msg.Payload(), &m3)
@@ -120,6 +122,7 @@ func main() {
 	}
 	opts.OnConnectionLost = func(client mqtt.Client, err error) {
 		logutil.GetLogger().Warnf("mosquitto connection lost, err=%s", err)
+		diagnosticsService.SetMqttConnected(false)
 	}
 	client := mqtt.NewClient(opts)
 	if token := client.Connect(); token.Wait() && token.Error() != nil {
@@ -167,6 +170,14 @@ func checkAndRegisterCoinAcceptors(ctx context.Context, s *edge.DeviceMapService
 					ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 					defer cancel()
 					if err := ca.CheckHealth(ctx); err != nil {
+						// 移除前先通知 iot 投幣器已離線，iot 斷線時則由 iot 自行結束 session
+						if iot := ic.Get(); iot != nil && !ca.OnlineReportedAt().IsZero() {
+							ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+							defer cancel()
+							if err := iot.ReportCoinAcceptorOffline(ctx, ca.GetDeviceID(), time.Now().UnixMilli()); err != nil {
+								logutil.GetLogger().Errorf("report coin acceptor offline error, err=%s, device_id=%s", err, ca.GetDeviceID())
+							}
+						}
 						s.DeleteCoinAcceptor(ca.GetDeviceID())
 						logutil.GetLogger().Infof("fail to check health and delete the coin acceptor, err=%s, device_id=%s", err, ca.GetDeviceID())
 						return
@@ -177,17 +188,28 @@ func checkAndRegisterCoinAcceptors(ctx context.Context, s *edge.DeviceMapService
 						return
 					}
 
-					if ca.Registered() {
+					if !ca.Registered() {
+						ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+						defer cancel()
+						if err := iot.RegisterCoinAcceptor(ctx, ca.GetDeviceID()); err != nil {
+							logutil.GetLogger().Errorf("register coin acceptor error, err=%s, device_id=%s", err, ca.GetDeviceID())
+							return
+						}
+						ca.SetRegistered(true)
+					}
+
+					if time.Since(ca.OnlineReportedAt()) < edge.CoinAcceptorOnlineReportInterval {
 						return
 					}
 
+					now := time.Now()
 					ctx, cancel = context.WithTimeout(context.Background(), time.Second)
 					defer cancel()
-					if err := iot.RegisterCoinAcceptor(ctx, ca.GetDeviceID()); err != nil {
-						logutil.GetLogger().Errorf("register coin acceptor error, err=%s, device_id=%s", err, ca.GetDeviceID())
+					if err := iot.ReportCoinAcceptorOnline(ctx, ca.GetDeviceID(), now.UnixMilli()); err != nil {
+						logutil.GetLogger().Errorf("report coin acceptor online error, err=%s, device_id=%s", err, ca.GetDeviceID())
 						return
 					}
-					ca.SetRegistered(true)
+					ca.SetOnlineReportedAt(now)
 				}(ca)
 				m.Unlock()
 			}
@@ -8,6 +8,15 @@ UPDATE records
 SET is_uploaded = $2, uploaded_at=$3
 WHERE id = $1;
 
+-- name: CountUnuploadedRecords :one
+SELECT COUNT(*)
+FROM (
+    SELECT 1
+    FROM records
+    WHERE is_uploaded = false
+    LIMIT $1
+) AS r;
+
 -- name: GetUnuploadedRecords :many
 SELECT *
 FROM records
@@ -9,6 +9,7 @@ import (
 )
 
 type Querier interface {
+	CountUnuploadedRecords(ctx context.Context, limit int32) (int64, error)
 	CreateRecord(ctx context.Context, arg CreateRecordParams) (Record, error)
 	GetUnuploadedRecords(ctx context.Context, limit int32) ([]Record, error)
 	SetRecordIsUploaded(ctx context.Context, arg SetRecordIsUploadedParams) error
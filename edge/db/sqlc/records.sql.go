@@ -12,6 +12,23 @@ import (
 	"github.com/google/uuid"
 )
 
+const countUnuploadedRecords = `-- name: CountUnuploadedRecords :one
+SELECT COUNT(*)
+FROM (
+    SELECT 1
+    FROM records
+    WHERE is_uploaded = false
+    LIMIT $1
+) AS r
+`
+
+func (q *Queries) CountUnuploadedRecords(ctx context.Context, limit int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUnuploadedRecords, limit)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createRecord = `-- name: CreateRecord :one
 INSERT INTO records (id, device_id, type, amount, is_uploaded, uploaded_at, ts)
 VALUES ($1, $2, $3, $4, $5, $6, $7)
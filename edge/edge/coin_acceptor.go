@@ -3,6 +3,7 @@ package edge
 import (
 	"context"
 	"fmt"
+	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
@@ -11,6 +12,8 @@ type CoinAcceptor struct {
 	deviceID    string
 	mqttRpcRepo MqttRpcRepo
 	registered  bool
+	// 上次向 iot 回報上線的時間，用來定期延續 iot 上的連線 session
+	onlineReportedAt time.Time
 }
 
 func NewCoinAcceptor(client mqtt.Client, deviceID string) *CoinAcceptor {
@@ -130,6 +133,14 @@ func (ca *CoinAcceptor) Registered() bool {
 	return ca.registered
 }
 
+func (ca *CoinAcceptor) SetOnlineReportedAt(t time.Time) {
+	ca.onlineReportedAt = t
+}
+
+func (ca *CoinAcceptor) OnlineReportedAt() time.Time {
+	return ca.onlineReportedAt
+}
+
 func (ca *CoinAcceptor) Close() {
 	ca.mqttRpcRepo.Close()
 }
@@ -1,7 +1,12 @@
 package edge
 
+import "time"
+
 const (
 	CoinAcceptorRequestKeyFmt  string = "api/v1/coin-acceptor/%s/request"
 	CoinAcceptorResponseKeyFmt string = "api/v1/coin-acceptor/%s/response/+"
 	CoinAcceptorEventKey       string = "api/v1/coin-acceptor/+/event"
 )
+
+// 投幣器連線中時向 iot 回報上線的間隔
+const CoinAcceptorOnlineReportInterval = 30 * time.Second
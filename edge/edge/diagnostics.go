@@ -0,0 +1,71 @@
+package edge
+
+import (
+	"context"
+	db "edge/db/sqlc"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+type EdgeDiagnostics struct {
+	UptimeMs                   int64
+	CoinAcceptorCount          int32
+	UnuploadedRecords          int32
+	UnuploadedRecordsTruncated bool
+	MqttConnected              bool
+	DiskTotalBytes             uint64
+	DiskFreeBytes              uint64
+}
+
+// DiagnosticsService 收集 edge 的執行狀態，供 iot 透過 rpc 查詢
+type DiagnosticsService struct {
+	startedAt            time.Time
+	store                db.IStore
+	deviceMapService     *DeviceMapService
+	diskPath             string
+	maxUnuploadedRecords int32
+	mqttConnected        atomic.Bool
+}
+
+func NewDiagnosticsService(store db.IStore, dms *DeviceMapService, diskPath string, maxUnuploadedRecords int32) *DiagnosticsService {
+	return &DiagnosticsService{
+		startedAt:            time.Now(),
+		store:                store,
+		deviceMapService:     dms,
+		diskPath:             diskPath,
+		maxUnuploadedRecords: maxUnuploadedRecords,
+	}
+}
+
+func (s *DiagnosticsService) SetMqttConnected(connected bool) {
+	s.mqttConnected.Store(connected)
+}
+
+func (s *DiagnosticsService) GetDiagnostics(ctx context.Context) (*EdgeDiagnostics, error) {
+	// 只計算到上限筆數，超過時以 UnuploadedRecordsTruncated 表示實際積壓更多
+	count, err := s.store.CountUnuploadedRecords(ctx, s.maxUnuploadedRecords+1)
+	if err != nil {
+		return nil, err
+	}
+	unuploadedRecords := int32(count)
+	truncated := unuploadedRecords > s.maxUnuploadedRecords
+	if truncated {
+		unuploadedRecords = s.maxUnuploadedRecords
+	}
+
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(s.diskPath, &stat); err != nil {
+		return nil, err
+	}
+
+	return &EdgeDiagnostics{
+		UptimeMs:                   time.Since(s.startedAt).Milliseconds(),
+		CoinAcceptorCount:          int32(len(s.deviceMapService.GetCoinAcceptorList())),
+		UnuploadedRecords:          unuploadedRecords,
+		UnuploadedRecordsTruncated: truncated,
+		MqttConnected:              s.mqttConnected.Load(),
+		DiskTotalBytes:             stat.Blocks * uint64(stat.Bsize),
+		DiskFreeBytes:              stat.Bavail * uint64(stat.Bsize),
+	}, nil
+}
@@ -19,6 +19,7 @@ type Error struct {
 type Iot struct {
 	// storeID
 	conn *websocket.Conn
+	si   infoutil.Info
 
 	toClientChan chan []byte
 
@@ -28,13 +29,13 @@ type Iot struct {
 	responseHandler func(bytes []byte, m2 MessageType2[WsResponse])
 }
 
-func NewIot(si infoutil.Info, dms *DeviceMapService, conn *websocket.Conn) *Iot {
-	c := &Iot{conn: conn}
+func NewIot(si infoutil.Info, dms *DeviceMapService, ds *DiagnosticsService, conn *websocket.Conn) *Iot {
+	c := &Iot{conn: conn, si: si}
 	c.toClientChan = make(chan []byte, 100)
 	rpcRepo := newWsRpcRepo(c.toClientChan)
 	c.rpcRepo = rpcRepo
 
-	c.requestHandler = NewWsCtrl(si, dms, c.toClientChan).handleRequest
+	c.requestHandler = NewWsCtrl(si, dms, ds, c.toClientChan).handleRequest
 	c.responseHandler = rpcRepo.handleResponse
 	return c
 }
@@ -95,8 +96,9 @@ func (c *Iot) WriteLoop(closedChan chan struct{}) {
 }
 
 type loginRequest struct {
-	StoreID  string `json:"store_id"`
-	Password string `json:"password"`
+	StoreID     string `json:"store_id"`
+	Password    string `json:"password"`
+	EdgeVersion string `json:"edge_version"`
 }
 
 func (c *Iot) Login(ctx context.Context, storeID string, password string) error {
@@ -105,8 +107,9 @@ func (c *Iot) Login(ctx context.Context, storeID string, password string) error
 		c.rpcRepo,
 		"login",
 		loginRequest{
-			StoreID:  storeID,
-			Password: password,
+			StoreID:     storeID,
+			Password:    password,
+			EdgeVersion: c.si.EdgeVersion,
 		})
 	return err
 }
@@ -147,6 +150,35 @@ func (c *Iot) AddCoinAcceptorCoinInsertedRecord(ctx context.Context, recordID uu
 	return err
 }
 
+type reportCoinAcceptorConnectionRequest struct {
+	DeviceID string `json:"device_id"`
+	Ts       int64  `json:"ts"`
+}
+
+func (c *Iot) ReportCoinAcceptorOnline(ctx context.Context, deviceID string, ts int64) error {
+	_, err := WsRpc[reportCoinAcceptorConnectionRequest](
+		ctx,
+		c.rpcRepo,
+		"report-coin-acceptor-online",
+		reportCoinAcceptorConnectionRequest{
+			DeviceID: deviceID,
+			Ts:       ts,
+		})
+	return err
+}
+
+func (c *Iot) ReportCoinAcceptorOffline(ctx context.Context, deviceID string, ts int64) error {
+	_, err := WsRpc[reportCoinAcceptorConnectionRequest](
+		ctx,
+		c.rpcRepo,
+		"report-coin-acceptor-offline",
+		reportCoinAcceptorConnectionRequest{
+			DeviceID: deviceID,
+			Ts:       ts,
+		})
+	return err
+}
+
 func (c *Iot) SendCoinAcceptorStatusChangedEvent(deviceID string, status CoinAcceptorStatus) {
 	m3 := MessageType3[any]{
 		Type: "coin-acceptor-status-changed",
@@ -6,27 +6,32 @@ import (
 	logutil "edge/util/log"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 )
 
 type WsCtrl struct {
 	systemInfo       infoutil.Info
 	deviceMapService *DeviceMapService
+	diagnostics      *DiagnosticsService
 	toClientChan     chan []byte
 	handlers         map[string]func(req WsRequest) (any, *Error)
 }
 
-func NewWsCtrl(si infoutil.Info, dms *DeviceMapService, toClientChan chan []byte) *WsCtrl {
+func NewWsCtrl(si infoutil.Info, dms *DeviceMapService, ds *DiagnosticsService, toClientChan chan []byte) *WsCtrl {
 	c := WsCtrl{
 		systemInfo:       si,
 		deviceMapService: dms,
+		diagnostics:      ds,
 		toClientChan:     toClientChan,
 	}
 	c.handlers = map[string]func(req WsRequest) (any, *Error){
 		"get-edge-system-info":        c.getEdgeSystemInfo,
+		"get-edge-diagnostics":        c.getEdgeDiagnostics,
 		"add-points-to-coin-acceptor": c.addPointsToCoinAcceptor,
 		"get-coin-acceptor-info":      c.getCoinAcceptorInfo,
 		"get-coin-acceptor-status":    c.getCoinAcceptorStatus,
+		"get-coin-acceptor-statuses":  c.getCoinAcceptorStatuses,
 		"blink-coin-acceptor":         c.blinkCoinAcceptor,
 	}
 	return &c
@@ -72,6 +77,27 @@ func (c *WsCtrl) getEdgeSystemInfo(req WsRequest) (any, *Error) {
 	}, nil
 }
 
+func (c *WsCtrl) getEdgeDiagnostics(req WsRequest) (any, *Error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	diagnostics, err := c.diagnostics.GetDiagnostics(ctx)
+	if err != nil {
+		logutil.GetLogger().Errorf("get edge diagnostics error, err=%s", err)
+		return nil, &Error{Code: codeInternalError, Message: err.Error()}
+	}
+	return map[string]any{
+		"edge_version":                 c.systemInfo.EdgeVersion,
+		"uptime_ms":                    diagnostics.UptimeMs,
+		"coin_acceptor_count":          diagnostics.CoinAcceptorCount,
+		"unuploaded_records":           diagnostics.UnuploadedRecords,
+		"unuploaded_records_truncated": diagnostics.UnuploadedRecordsTruncated,
+		"mqtt_connected":               diagnostics.MqttConnected,
+		"disk_total_bytes":             diagnostics.DiskTotalBytes,
+		"disk_free_bytes":              diagnostics.DiskFreeBytes,
+	}, nil
+}
+
 func (c *WsCtrl) addPointsToCoinAcceptor(req WsRequest) (any, *Error) {
 	if req.DeviceID == nil || *req.DeviceID == "" {
 		return nil, &Error{Code: codeInvalidParameterError, Message: "device_id is null or empty"}
@@ -152,6 +178,50 @@ func (c *WsCtrl) getCoinAcceptorStatus(req WsRequest) (any, *Error) {
 	}, nil
 }
 
+// getCoinAcceptorStatuses 同時查詢多台投幣器的狀態，未連線或逾時的投幣器不會出現在結果中
+func (c *WsCtrl) getCoinAcceptorStatuses(req WsRequest) (any, *Error) {
+	if len(req.DeviceIDs) == 0 {
+		return nil, &Error{Code: codeInvalidParameterError, Message: "device_ids is null or empty"}
+	}
+
+	type statusItem struct {
+		DeviceID string `json:"device_id"`
+		Points   int32  `json:"points"`
+		State    string `json:"state"`
+		Ts       int64  `json:"ts"`
+	}
+
+	var wg sync.WaitGroup
+	var m sync.Mutex
+	statuses := make([]statusItem, 0, len(req.DeviceIDs))
+	for _, deviceID := range req.DeviceIDs {
+		coinAcceptor := c.deviceMapService.GetCoinAcceptor(deviceID)
+		if coinAcceptor == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(deviceID string, coinAcceptor *CoinAcceptor) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			status, err := coinAcceptor.GetDeviceStatus(ctx)
+			if err != nil {
+				logutil.GetLogger().Warnf("get coin acceptor status error, err=%s, device_id=%s", err, deviceID)
+				return
+			}
+			m.Lock()
+			statuses = append(statuses, statusItem{DeviceID: deviceID, Points: status.Points, State: status.State, Ts: status.Ts})
+			m.Unlock()
+		}(deviceID, coinAcceptor)
+	}
+	wg.Wait()
+
+	return map[string]any{
+		"statuses": statuses,
+	}, nil
+}
+
 func (c *WsCtrl) blinkCoinAcceptor(req WsRequest) (any, *Error) {
 	if req.DeviceID == nil || *req.DeviceID == "" {
 		return nil, &Error{Code: codeInvalidParameterError, Message: "device_id is null or empty"}
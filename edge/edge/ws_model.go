@@ -1,8 +1,9 @@
 package edge
 
 type WsRequest struct {
-	DeviceID *string `json:"device_id"`
-	Amount   *int32  `json:"amount"`
+	DeviceID  *string  `json:"device_id"`
+	DeviceIDs []string `json:"device_ids"`
+	Amount    *int32   `json:"amount"`
 }
 
 type WsResponse struct {
@@ -28,6 +28,10 @@ type Config struct {
 	Info struct {
 		EdgeVersionFile string `mapstructure:"edge_version_file"`
 	} `mapstructure:"info"`
+	Diagnostics struct {
+		DiskPath             string `mapstructure:"disk_path"`
+		MaxUnuploadedRecords int32  `mapstructure:"max_unuploaded_records"`
+	} `mapstructure:"diagnostics"`
 }
 
 func Load(env string) (config Config, err error) {